@@ -0,0 +1,103 @@
+package apps
+
+import (
+	"encoding/json"
+	"fmt"
+
+	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/app_helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
+	. "github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
+)
+
+type containerReport struct {
+	Uid       int               `json:"uid"`
+	User      string            `json:"user"`
+	OSRelease map[string]string `json:"os_release"`
+	Cgroup    struct {
+		MemoryLimitBytes int64 `json:"memory_limit_bytes"`
+	} `json:"cgroup"`
+	Disk struct {
+		TotalBytes uint64 `json:"total_bytes"`
+	} `json:"disk"`
+}
+
+var stackCodenames = map[string]string{
+	"cflinuxfs2": "trusty",
+	"cflinuxfs3": "bionic",
+}
+
+var _ = AppsDescribe("Container introspection", func() {
+	const (
+		memoryLimitMB = 256
+		diskLimitMB   = 512
+	)
+
+	var (
+		appName string
+		report  containerReport
+	)
+
+	BeforeEach(func() {
+		appName = CATSRandomName("APP")
+		Expect(cf.Cf("push",
+			appName,
+			"-b", Config.GetBinaryBuildpackName(),
+			"-p", assets.NewAssets().Catnip,
+			"-c", "./catnip",
+			"-m", fmt.Sprintf("%dM", memoryLimitMB),
+			"-k", fmt.Sprintf("%dM", diskLimitMB),
+			"-d", Config.GetAppsDomain(),
+		).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+
+		response := helpers.CurlApp(Config, appName, "/container")
+		Expect(json.Unmarshal([]byte(response), &report)).To(Succeed(), response)
+	})
+
+	AfterEach(func() {
+		app_helpers.AppReport(appName, Config.DefaultTimeoutDuration())
+
+		Expect(cf.Cf("delete", appName, "-f", "-r").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+	})
+
+	It("runs the app as an unprivileged user", func() {
+		Expect(report.Uid).NotTo(BeZero())
+		Expect(report.User).To(Equal("vcap"))
+	})
+
+	It("applies the requested memory and disk limits", func() {
+		Expect(report.Cgroup.MemoryLimitBytes).To(Equal(int64(memoryLimitMB * 1024 * 1024)))
+
+		Expect(report.Disk.TotalBytes).To(BeNumerically(">", 0))
+		Expect(report.Disk.TotalBytes).To(BeNumerically("<=", uint64(diskLimitMB*1024*1024)))
+	})
+
+	It("runs on the rootfs of the app's stack", func() {
+		session := cf.Cf("curl", fmt.Sprintf("/v3/apps/%s", GuidForAppName(appName))).Wait(Config.DefaultTimeoutDuration())
+		Expect(session).To(Exit(0))
+
+		var app struct {
+			Lifecycle struct {
+				Data struct {
+					Stack string `json:"stack"`
+				} `json:"data"`
+			} `json:"lifecycle"`
+		}
+		Expect(json.Unmarshal(session.Out.Contents(), &app)).To(Succeed())
+
+		codename, known := stackCodenames[app.Lifecycle.Data.Stack]
+		if !known {
+			Skip(fmt.Sprintf("Skipping this test because the codename of stack %q is not known.", app.Lifecycle.Data.Stack))
+		}
+
+		Expect(report.OSRelease).To(HaveKeyWithValue("ID", "ubuntu"))
+		Expect(helpers.CurlApp(Config, appName, "/lsb_release")).To(ContainSubstring("Codename:\t" + codename))
+	})
+})
@@ -0,0 +1,102 @@
+package apps
+
+import (
+	"fmt"
+
+	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/app_helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
+	. "github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
+)
+
+var _ = AppsDescribe("Custom metrics", func() {
+	var appName, serviceName string
+
+	// registerWith binds the app to a user provided service whose syslog drain
+	// url tells the metric registrar where to find the app's metrics.
+	registerWith := func(drainUrl string) {
+		Expect(cf.Cf("create-user-provided-service", serviceName, "-l", drainUrl).Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+		Expect(cf.Cf("bind-service", appName, serviceName).Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+	}
+
+	// envelopes returns the app's recent envelopes of the given type from
+	// log-cache. The noaa firehose envelopes carry no tags, so log-cache is
+	// the only place the registrar's metrics can be tied to the app.
+	envelopes := func(envelopeType string) string {
+		session := cf.Cf("tail", appName, "--envelope-type", envelopeType, "--lines", "100").Wait(Config.DefaultTimeoutDuration())
+		Expect(session).To(Exit(0))
+		return string(session.Out.Contents())
+	}
+
+	BeforeEach(func() {
+		if !Config.GetIncludeMetricRegistrar() {
+			Skip("Skipping this test because Config.IncludeMetricRegistrar is set to 'false'.")
+		}
+		if !Config.GetUseLogCache() {
+			Skip("Skipping this test because Config.UseLogCache is set to 'false'.")
+		}
+
+		appName = CATSRandomName("APP")
+		serviceName = CATSRandomName("SVIN")
+
+		Expect(cf.Cf("push",
+			appName,
+			"-b", Config.GetBinaryBuildpackName(),
+			"-m", DEFAULT_MEMORY_LIMIT,
+			"-p", assets.NewAssets().Catnip,
+			"-c", "./catnip",
+			"-d", Config.GetAppsDomain(),
+		).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+	})
+
+	AfterEach(func() {
+		if !Config.GetIncludeMetricRegistrar() || !Config.GetUseLogCache() {
+			return
+		}
+
+		app_helpers.AppReport(appName, Config.DefaultTimeoutDuration())
+
+		Expect(cf.Cf("delete", appName, "-f", "-r").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+		Expect(cf.Cf("delete-service", serviceName, "-f").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+	})
+
+	It("scrapes gauges and counters from the app's Prometheus endpoint", func() {
+		Expect(helpers.CurlApp(Config, appName, "/metrics/gauge/cats_queue_depth/42", "-X", "PUT")).To(Equal("Set gauge cats_queue_depth to 42"))
+		Expect(helpers.CurlApp(Config, appName, "/metrics/counter/cats_requests_total/7", "-X", "POST")).To(Equal("Counter cats_requests_total is 7"))
+
+		registerWith("metrics-endpoint:///metrics")
+
+		Eventually(func() string {
+			return envelopes("gauge")
+		}, 2*Config.DefaultTimeoutDuration(), "5s").Should(MatchRegexp(`/0\] GAUGE .*cats_queue_depth:42(\.0*)?\b`))
+		Eventually(func() string {
+			return envelopes("counter")
+		}, 2*Config.DefaultTimeoutDuration(), "5s").Should(MatchRegexp(`/0\] COUNTER cats_requests_total:7\b`))
+	})
+
+	It("parses gauges and counters from structured log lines", func() {
+		registerWith("structured-format://json")
+
+		// Only lines logged after the registrar picks up the binding are
+		// parsed, so keep logging them until one arrives.
+		Eventually(func() string {
+			helpers.CurlApp(Config, appName, "/metrics/log/gauge/cats_temperature/21.5?unit=celsius", "-X", "POST")
+			return envelopes("gauge")
+		}, 2*Config.DefaultTimeoutDuration(), "5s").Should(MatchRegexp(`/0\] GAUGE .*cats_temperature:21\.50* celsius`))
+		// Loggregator sums counter deltas per name and tags, so tagging each
+		// attempt on its own keeps the total of every envelope at the delta.
+		attempt := 0
+		Eventually(func() string {
+			attempt++
+			helpers.CurlApp(Config, appName, fmt.Sprintf("/metrics/log/counter/cats_jobs_done/3?tag.attempt=%d", attempt), "-X", "POST")
+			return envelopes("counter")
+		}, 2*Config.DefaultTimeoutDuration(), "5s").Should(MatchRegexp(`/0\] COUNTER cats_jobs_done:3\b`))
+	})
+})
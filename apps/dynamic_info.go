@@ -4,7 +4,6 @@ import (
 	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
-	. "github.com/onsi/gomega/gbytes"
 	. "github.com/onsi/gomega/gexec"
 
 	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
@@ -12,6 +11,7 @@ import (
 	"github.com/cloudfoundry/cf-acceptance-tests/helpers/app_helpers"
 	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
 	"github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/v3_helpers"
 )
 
 var _ = AppsDescribe("A running application", func() {
@@ -52,10 +52,16 @@ var _ = AppsDescribe("A running application", func() {
 		})
 
 		It("can be queried for state by instance", func() {
-			app := cf.Cf("app", appName).Wait(Config.DefaultTimeoutDuration())
-			Expect(app).To(Exit(0))
-			Expect(app).To(Say("#0"))
-			Expect(app).To(Say("#1"))
+			appGuid := GuidForAppName(appName)
+			webProcess := v3_helpers.GetProcessByType(v3_helpers.GetProcesses(appGuid, appName), "web")
+
+			Eventually(func() []v3_helpers.ProcessInstance {
+				return v3_helpers.GetProcessStats(webProcess.Guid).Instances
+			}, Config.DefaultTimeoutDuration()).Should(HaveLen(2))
+
+			for _, index := range []int{0, 1} {
+				Expect(v3_helpers.GetProcessInstanceState(webProcess.Guid, index)).NotTo(BeEmpty())
+			}
 		})
 	})
 })
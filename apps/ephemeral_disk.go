@@ -0,0 +1,66 @@
+package apps
+
+import (
+	"encoding/json"
+
+	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/app_helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
+	. "github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
+)
+
+var _ = AppsDescribe("Ephemeral disk", func() {
+	var appName string
+
+	type catnipFile struct {
+		Bytes  int64  `json:"bytes"`
+		SHA256 string `json:"sha256"`
+	}
+
+	curlFile := func(path string, args ...string) catnipFile {
+		response := helpers.CurlApp(Config, appName, path, args...)
+
+		var file catnipFile
+		Expect(json.Unmarshal([]byte(response), &file)).To(Succeed(), response)
+		return file
+	}
+
+	BeforeEach(func() {
+		appName = CATSRandomName("APP")
+		Expect(cf.Cf("push",
+			appName,
+			"-b", Config.GetBinaryBuildpackName(),
+			"-m", DEFAULT_MEMORY_LIMIT,
+			"-p", assets.NewAssets().Catnip,
+			"-c", "./catnip",
+			"-d", Config.GetAppsDomain(),
+		).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+	})
+
+	AfterEach(func() {
+		app_helpers.AppReport(appName, Config.DefaultTimeoutDuration())
+
+		Expect(cf.Cf("delete", appName, "-f", "-r").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+	})
+
+	It("reads back what was written and loses it when the app restarts", func() {
+		written := curlFile("/files/tmp/cats/data?bytes=52428800", "-X", "PUT")
+		Expect(written.Bytes).To(Equal(int64(52428800)))
+
+		read := curlFile("/files/tmp/cats/data")
+		Expect(read).To(Equal(written))
+
+		Expect(cf.Cf("restart", appName).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+
+		Eventually(func() string {
+			return helpers.CurlApp(Config, appName, "/files/tmp/cats/data", "-o", "/dev/null", "-w", "%{http_code}")
+		}, Config.DefaultTimeoutDuration()).Should(Equal("404"))
+	})
+})
@@ -0,0 +1,145 @@
+package apps
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	. "github.com/onsi/gomega/gexec"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/app_helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/logs"
+	. "github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/v3_helpers"
+)
+
+// drainPeriod is kept well inside Diego's default ten second grace period so
+// that the app exits on its own rather than being killed.
+const drainPeriod = 5 * time.Second
+
+var _ = AppsDescribe("Graceful shutdown", func() {
+	var (
+		appName     string
+		appGuid     string
+		processGuid string
+	)
+
+	instanceHeader := func(index int) string {
+		return fmt.Sprintf("X-Cf-App-Instance: %s:%d", appGuid, index)
+	}
+
+	setDrainPeriod := func(index int) {
+		Expect(helpers.CurlApp(Config, appName, fmt.Sprintf("/drain/%d", drainPeriod/time.Millisecond),
+			"-X", "PUT", "-H", instanceHeader(index),
+		)).To(ContainSubstring("Will drain"))
+	}
+
+	BeforeEach(func() {
+		appName = CATSRandomName("APP")
+		Expect(cf.Cf("push",
+			appName,
+			"-b", Config.GetBinaryBuildpackName(),
+			"-p", assets.NewAssets().Catnip,
+			"-c", "./catnip",
+			"-i", "2",
+			"-m", DEFAULT_MEMORY_LIMIT,
+			"-d", Config.GetAppsDomain(),
+		).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+
+		appGuid = GuidForAppName(appName)
+		processGuid = v3_helpers.GetProcessByType(v3_helpers.GetProcesses(appGuid, appName), "web").Guid
+		v3_helpers.WaitForAllProcessInstancesToBeRunning(processGuid, 2)
+
+		setDrainPeriod(0)
+		setDrainPeriod(1)
+	})
+
+	AfterEach(func() {
+		app_helpers.AppReport(appName, Config.DefaultTimeoutDuration())
+
+		Expect(cf.Cf("delete", appName, "-f", "-r").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+	})
+
+	It("finishes in-flight requests when the app is stopped", func() {
+		events := helpers.Curl(Config, "-N", "-H", instanceHeader(0), helpers.AppUri(appName, "/events/4/1000", Config))
+		Eventually(events.Out, Config.DefaultTimeoutDuration()).Should(gbytes.Say("Event 1 of 4"))
+
+		Expect(cf.Cf("stop", appName).Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+
+		Eventually(events, Config.DefaultTimeoutDuration()).Should(Exit(0))
+		Expect(events.Out.Contents()).To(ContainSubstring("Event 4 of 4"))
+
+		Eventually(func() *Session {
+			return logs.Tail(Config.GetUseLogCache(), appName).Wait(Config.DefaultTimeoutDuration())
+		}, Config.DefaultTimeoutDuration()).Should(gbytes.Say(`drain: \S+ received SIGTERM, serving for 5s`))
+	})
+
+	It("does not fail requests while an instance restarts", func() {
+		statusCodes := pollStatusCodes(appName, func() {
+			v3_helpers.RestartProcessInstance(processGuid, 0)
+
+			Eventually(func() string {
+				return v3_helpers.GetProcessInstanceState(processGuid, 0)
+			}, 45*time.Second).ShouldNot(Equal("RUNNING"))
+			v3_helpers.WaitForAllProcessInstancesToBeRunning(processGuid, 2)
+		})
+
+		Expect(statusCodes).NotTo(ContainElement("502"))
+		Expect(statusCodes).To(ContainElement("200"))
+	})
+
+	It("does not fail requests while the app scales down", func() {
+		statusCodes := pollStatusCodes(appName, func() {
+			Expect(cf.Cf("scale", appName, "-i", "1").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+
+			Eventually(func() int {
+				return len(v3_helpers.GetProcessStats(processGuid).Instances)
+			}, Config.DefaultTimeoutDuration()).Should(Equal(1))
+			// Keep polling until the removed instance has had time to drain.
+			time.Sleep(drainPeriod)
+		})
+
+		Expect(statusCodes).NotTo(ContainElement("502"))
+		Expect(statusCodes).To(ContainElement("200"))
+	})
+})
+
+// pollStatusCodes requests the app over and over while action runs, returning
+// the status code of every response.
+func pollStatusCodes(appName string, action func()) []string {
+	var (
+		wg          sync.WaitGroup
+		statusCodes []string
+	)
+	done := make(chan struct{})
+
+	wg.Add(1)
+	go func() {
+		defer GinkgoRecover()
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			curl := helpers.Curl(Config, "-s", "-o", "/dev/null", "-w", "%{http_code}", helpers.AppUri(appName, "/id", Config)).Wait(Config.DefaultTimeoutDuration())
+			statusCodes = append(statusCodes, string(curl.Out.Contents()))
+		}
+	}()
+
+	action()
+	close(done)
+	wg.Wait()
+
+	return statusCodes
+}
@@ -94,4 +94,46 @@ var _ = AppsDescribe("Healthcheck", func() {
 			Eventually(helpers.CurlingAppRoot(Config, appName)).Should(ContainSubstring("Catnip?"))
 		})
 	})
+
+	Describe("when an http healthcheck starts failing", func() {
+		BeforeEach(func() {
+			Eventually(cf.Cf(
+				"push", appName,
+				"-b", Config.GetBinaryBuildpackName(),
+				"-m", DEFAULT_MEMORY_LIMIT,
+				"-p", assets.NewAssets().Catnip,
+				"-c", "./catnip",
+				"-d", Config.GetAppsDomain(),
+				"-i", "1",
+				"--no-start"),
+				Config.CfPushTimeoutDuration(),
+			).Should(Exit(0))
+
+			Expect(cf.Cf("set-health-check", appName, "http", "--endpoint", "/health").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+			Expect(cf.Cf("start", appName).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+
+			Eventually(func() string {
+				return helpers.CurlApp(Config, appName, "/health")
+			}, Config.DefaultTimeoutDuration()).Should(ContainSubstring("I'm alive"))
+		})
+
+		It("restarts the instance and recovers", func() {
+			instanceGuid := helpers.CurlApp(Config, appName, "/id")
+
+			By("making the instance unhealthy")
+			helpers.CurlApp(Config, appName, "/health/admin", "-X", "PUT", "-d", `{"state":"unhealthy"}`)
+
+			Eventually(func() string {
+				return string(cf.Cf("events", appName).Wait(Config.DefaultTimeoutDuration()).Out.Contents())
+			}, Config.DefaultTimeoutDuration()).Should(MatchRegexp("[eE]xited"))
+
+			By("verifying a healthy instance replaced it")
+			Eventually(func() string {
+				return helpers.CurlApp(Config, appName, "/id")
+			}, Config.DefaultTimeoutDuration()).ShouldNot(Equal(instanceGuid))
+			Eventually(func() string {
+				return helpers.CurlApp(Config, appName, "/health")
+			}, Config.DefaultTimeoutDuration()).Should(ContainSubstring("I'm alive"))
+		})
+	})
 })
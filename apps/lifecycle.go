@@ -18,6 +18,7 @@ import (
 	"github.com/cloudfoundry/cf-acceptance-tests/helpers/app_helpers"
 	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
 	"github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/v3_helpers"
 )
 
 type AppUsageEvent struct {
@@ -158,13 +159,14 @@ var _ = AppsDescribe("Application Lifecycle", func() {
 			})
 
 			It("is able to start all instances", func() {
-				Eventually(func() *Session {
-					return cf.Cf("app", appName).Wait(Config.DefaultTimeoutDuration())
-				}, Config.DefaultTimeoutDuration()).Should(Say("#0   running"))
+				appGuid := GuidForAppName(appName)
+				webProcess := v3_helpers.GetProcessByType(v3_helpers.GetProcesses(appGuid, appName), "web")
 
-				Eventually(func() *Session {
-					return cf.Cf("app", appName).Wait(Config.DefaultTimeoutDuration())
-				}, Config.DefaultTimeoutDuration()).Should(Say("#1   running"))
+				for _, index := range []int{0, 1} {
+					Eventually(func() string {
+						return v3_helpers.GetProcessInstanceState(webProcess.Guid, index)
+					}, Config.DefaultTimeoutDuration()).Should(Equal("RUNNING"))
+				}
 			})
 		})
 
@@ -209,6 +211,34 @@ var _ = AppsDescribe("Application Lifecycle", func() {
 			Expect(ports[0].External).NotTo(BeZero())
 		})
 
+		It("describes the app in VCAP_APPLICATION", func() {
+			Expect(cf.Cf("push",
+				appName,
+				"-b", Config.GetBinaryBuildpackName(),
+				"-m", DEFAULT_MEMORY_LIMIT,
+				"-p", assets.NewAssets().Catnip,
+				"-c", "./catnip",
+				"-d", Config.GetAppsDomain()).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+
+			applicationJSON := helpers.CurlApp(Config, appName, "/vcap/application")
+			var application struct {
+				ApplicationId   string   `json:"application_id"`
+				ApplicationName string   `json:"application_name"`
+				ApplicationURIs []string `json:"application_uris"`
+				SpaceName       string   `json:"space_name"`
+				Limits          struct {
+					Mem int `json:"mem"`
+				} `json:"limits"`
+			}
+			Expect(json.Unmarshal([]byte(applicationJSON), &application)).To(Succeed(), applicationJSON)
+
+			Expect(application.ApplicationId).To(Equal(GuidForAppName(appName)))
+			Expect(application.ApplicationName).To(Equal(appName))
+			Expect(application.ApplicationURIs).To(ContainElement(strings.ToLower(appName) + "." + Config.GetAppsDomain()))
+			Expect(application.SpaceName).To(Equal(TestSetup.RegularUserContext().Space))
+			Expect(application.Limits.Mem).To(BeNumerically(">", 0))
+		})
+
 		It("generates an app usage 'started' event", func() {
 			Expect(cf.Cf("push",
 				appName,
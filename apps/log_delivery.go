@@ -0,0 +1,129 @@
+package apps
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/app_helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
+	logshelper "github.com/cloudfoundry/cf-acceptance-tests/helpers/logs"
+	. "github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
+)
+
+// maxLogLoss is how much of a small, slow burst of logs may go missing before
+// we consider delivery broken.
+const maxLogLoss = 0.01
+
+type logRun struct {
+	Id   string `json:"id"`
+	Sent int    `json:"sent"`
+	Done bool   `json:"done"`
+}
+
+// startLogRun asks the catnip app to start a log generator run from the
+// JSON spec.
+func startLogRun(appName, spec string) logRun {
+	var run logRun
+	response := helpers.CurlApp(Config, appName, "/log/generate", "-X", "POST", "-d", spec)
+	Expect(json.Unmarshal([]byte(response), &run)).To(Succeed(), response)
+	return run
+}
+
+func getLogRun(appName, id string) logRun {
+	var run logRun
+	response := helpers.CurlApp(Config, appName, "/log/generate/"+id)
+	Expect(json.Unmarshal([]byte(response), &run)).To(Succeed(), response)
+	return run
+}
+
+func recentLogs(appName string) []byte {
+	session := logshelper.Tail(Config.GetUseLogCache(), appName).Wait(Config.DefaultTimeoutDuration())
+	Expect(session).To(Exit(0))
+	return session.Out.Contents()
+}
+
+var _ = AppsDescribe("Log delivery", func() {
+	var appName string
+
+	BeforeEach(func() {
+		appName = CATSRandomName("APP")
+		Expect(cf.Cf("push",
+			appName,
+			"-b", Config.GetBinaryBuildpackName(),
+			"-p", assets.NewAssets().Catnip,
+			"-c", "./catnip",
+			"-m", DEFAULT_MEMORY_LIMIT,
+			"-d", Config.GetAppsDomain(),
+		).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+	})
+
+	AfterEach(func() {
+		app_helpers.AppReport(appName, Config.DefaultTimeoutDuration())
+
+		Expect(cf.Cf("delete", appName, "-f", "-r").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+	})
+
+	It("delivers stdout lines in order without losing them", func() {
+		run := startLogRun(appName, `{"count":100,"rate":20}`)
+		Eventually(func() bool { return getLogRun(appName, run.Id).Done }, Config.DefaultTimeoutDuration()).Should(BeTrue())
+
+		var sequence []int
+		Eventually(func() float64 {
+			sequence = logshelper.SequenceNumbers(recentLogs(appName), run.Id)
+			return logshelper.Loss(sequence, 100)
+		}, Config.DefaultTimeoutDuration(), "5s").Should(BeNumerically("<=", maxLogLoss))
+
+		Expect(sort.IntsAreSorted(sequence)).To(BeTrue(), fmt.Sprintf("logs arrived out of order: %v", sequence))
+	})
+
+	It("delivers stderr lines as errors", func() {
+		run := startLogRun(appName, `{"stream":"stderr","count":5}`)
+
+		Eventually(func() []byte {
+			return recentLogs(appName)
+		}, Config.DefaultTimeoutDuration(), "5s").Should(MatchRegexp(`ERR catnip-log run=%s seq=5`, run.Id))
+	})
+
+	It("delivers each line of a multi-line entry separately", func() {
+		run := startLogRun(appName, `{"count":1,"lines":3,"line_size":200}`)
+
+		Eventually(func() []byte {
+			return recentLogs(appName)
+		}, Config.DefaultTimeoutDuration(), "5s").Should(MatchRegexp(`OUT catnip-log run=%s seq=1 line=3/3 x+`, run.Id))
+
+		output := recentLogs(appName)
+		for line := 1; line <= 3; line++ {
+			Expect(output).To(MatchRegexp(`OUT catnip-log run=%s seq=1 line=%d/3 x+`, run.Id, line))
+		}
+	})
+
+	It("delivers lines that are not valid UTF-8", func() {
+		run := startLogRun(appName, `{"count":3,"non_utf8":true}`)
+
+		Eventually(func() []int {
+			return logshelper.SequenceNumbers(recentLogs(appName), run.Id)
+		}, Config.DefaultTimeoutDuration(), "5s").Should(Equal([]int{1, 2, 3}))
+	})
+
+	It("stops logging when the run is stopped", func() {
+		run := startLogRun(appName, `{"rate":10}`)
+		Eventually(func() int { return getLogRun(appName, run.Id).Sent }, Config.DefaultTimeoutDuration()).Should(BeNumerically(">", 0))
+
+		var stopped logRun
+		response := helpers.CurlApp(Config, appName, "/log/generate/"+run.Id, "-X", "DELETE")
+		Expect(json.Unmarshal([]byte(response), &stopped)).To(Succeed(), response)
+		Expect(stopped.Done).To(BeTrue())
+
+		Consistently(func() int { return getLogRun(appName, run.Id).Sent }, 3*time.Second, time.Second).Should(Equal(stopped.Sent))
+	})
+})
@@ -2,6 +2,7 @@ package apps
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
@@ -12,12 +13,10 @@ import (
 	. "github.com/onsi/gomega/gexec"
 
 	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
-	"github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
 	"github.com/cloudfoundry-incubator/cf-test-helpers/workflowhelpers"
 	"github.com/cloudfoundry/cf-acceptance-tests/helpers/app_helpers"
 	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
 	logshelper "github.com/cloudfoundry/cf-acceptance-tests/helpers/logs"
-	. "github.com/cloudfoundry/cf-acceptance-tests/helpers/matchers"
 	. "github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
 	"github.com/cloudfoundry/noaa"
 	"github.com/cloudfoundry/noaa/events"
@@ -32,16 +31,22 @@ import (
 
 var _ = AppsDescribe("loggregator", func() {
 	var appName string
-	const hundredthOfOneSecond = 10000 // this app uses millionth of seconds
+
+	// Each spec has the catnip log generator write a small, slow burst and
+	// checks that it arrives in order and without more than maxLogLoss
+	// missing.
+	const logRunSpec = `{"count":100,"rate":20}`
+	const logRunCount = 100
 
 	BeforeEach(func() {
 		appName = CATSRandomName("APP")
 
 		Expect(cf.Cf("push",
 			appName,
-			"-b", Config.GetRubyBuildpackName(),
+			"-b", Config.GetBinaryBuildpackName(),
 			"-m", DEFAULT_MEMORY_LIMIT,
-			"-p", assets.NewAssets().LoggregatorLoadGenerator,
+			"-p", assets.NewAssets().Catnip,
+			"-c", "./catnip",
 			"-i", "2",
 			"-d", Config.GetAppsDomain()).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
 	})
@@ -69,30 +74,38 @@ var _ = AppsDescribe("loggregator", func() {
 		It("exercises basic loggregator behavior", func() {
 			Eventually(logs, (Config.DefaultTimeoutDuration() + time.Minute)).Should(Say("(Connected, tailing|Retrieving) logs for app"))
 
-			Eventually(func() string {
-				return helpers.CurlApp(Config, appName, fmt.Sprintf("/log/sleep/%d", hundredthOfOneSecond))
-			}, Config.DefaultTimeoutDuration()).Should(ContainSubstring("Muahaha"))
+			run := startLogRun(appName, logRunSpec)
+
+			var sequence []int
+			Eventually(func() float64 {
+				sequence = logshelper.SequenceNumbers(logs.Out.Contents(), run.Id)
+				return logshelper.Loss(sequence, logRunCount)
+			}, (Config.DefaultTimeoutDuration() + time.Minute), "5s").Should(BeNumerically("<=", maxLogLoss))
 
-			Eventually(logs, (Config.DefaultTimeoutDuration() + time.Minute)).Should(Say("Muahaha"))
+			Expect(sort.IntsAreSorted(sequence)).To(BeTrue(), fmt.Sprintf("logs arrived out of order: %v", sequence))
 		})
 	})
 
 	Context("cf logs --recent", func() {
 		It("makes loggregator buffer and dump log messages", func() {
-			Eventually(func() string {
-				return helpers.CurlApp(Config, appName, fmt.Sprintf("/log/sleep/%d", hundredthOfOneSecond))
-			}, Config.DefaultTimeoutDuration()).Should(ContainSubstring("Muahaha"))
-
-			Eventually(func() *Session {
-				appLogsSession := logshelper.Tail(Config.GetUseLogCache(), appName)
-				Expect(appLogsSession.Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
-				return appLogsSession
-			}, Config.DefaultTimeoutDuration()).Should(Say("Muahaha"))
+			run := startLogRun(appName, logRunSpec)
+
+			var sequence []int
+			Eventually(func() float64 {
+				sequence = logshelper.SequenceNumbers(recentLogs(appName), run.Id)
+				return logshelper.Loss(sequence, logRunCount)
+			}, Config.DefaultTimeoutDuration(), "5s").Should(BeNumerically("<=", maxLogLoss))
+
+			Expect(sort.IntsAreSorted(sequence)).To(BeTrue(), fmt.Sprintf("logs arrived out of order: %v", sequence))
 		})
 	})
 
 	Context("firehose data", func() {
+		// The firehose fans in from every doppler, so it only promises
+		// delivery, not order.
 		It("shows logs and metrics", func() {
+			appGuid := strings.TrimSpace(string(cf.Cf("app", appName, "--guid").Wait(Config.DefaultTimeoutDuration()).Out.Contents()))
+
 			noaaConnection := noaa.NewConsumer(getDopplerEndpoint(), &tls.Config{InsecureSkipVerify: Config.GetSkipSSLValidation()}, nil)
 			msgChan := make(chan *events.Envelope, 100000)
 			errorChan := make(chan error)
@@ -101,11 +114,21 @@ var _ = AppsDescribe("loggregator", func() {
 			go noaaConnection.Firehose(CATSRandomName("SUBSCRIPTION-ID"), getAdminUserAccessToken(), msgChan, errorChan, stopchan)
 			defer close(stopchan)
 
-			Eventually(func() string {
-				return helpers.CurlApp(Config, appName, fmt.Sprintf("/log/sleep/%d", hundredthOfOneSecond))
-			}, Config.DefaultTimeoutDuration()).Should(ContainSubstring("Muahaha"))
+			run := startLogRun(appName, logRunSpec)
 
-			Eventually(msgChan, Config.DefaultTimeoutDuration()).Should(Receive(EnvelopeContainingMessageLike("Muahaha")), "To enable the logging & metrics firehose feature, please ask your CF administrator to add the 'doppler.firehose' scope to your CF admin user.")
+			var sequence []int
+			Eventually(func() float64 {
+				for {
+					select {
+					case msg := <-msgChan:
+						if logMessage := msg.GetLogMessage(); logMessage != nil && logMessage.GetAppId() == appGuid {
+							sequence = append(sequence, logshelper.SequenceNumbers(logMessage.GetMessage(), run.Id)...)
+						}
+					default:
+						return logshelper.Loss(sequence, logRunCount)
+					}
+				}
+			}, Config.DefaultTimeoutDuration()).Should(BeNumerically("<=", maxLogLoss), "To enable the logging & metrics firehose feature, please ask your CF administrator to add the 'doppler.firehose' scope to your CF admin user.")
 		})
 
 		It("shows container metrics", func() {
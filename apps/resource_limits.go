@@ -0,0 +1,166 @@
+package apps
+
+import (
+	"crypto/tls"
+	"regexp"
+	"strconv"
+	"strings"
+
+	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/app_helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
+	. "github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
+	"github.com/cloudfoundry/noaa"
+	"github.com/cloudfoundry/noaa/events"
+)
+
+var _ = AppsDescribe("Resource limits", func() {
+	var appName string
+
+	pushCatnip := func(memory, disk string) {
+		Expect(cf.Cf("push",
+			appName,
+			"-b", Config.GetBinaryBuildpackName(),
+			"-m", memory,
+			"-k", disk,
+			"-p", assets.NewAssets().Catnip,
+			"-c", "./catnip",
+			"-d", Config.GetAppsDomain(),
+		).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+	}
+
+	BeforeEach(func() {
+		appName = CATSRandomName("APP")
+	})
+
+	AfterEach(func() {
+		app_helpers.AppReport(appName, Config.DefaultTimeoutDuration())
+
+		Expect(cf.Cf("delete", appName, "-f", "-r").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+	})
+
+	Describe("memory", func() {
+		BeforeEach(func() {
+			pushCatnip("64M", "256M")
+		})
+
+		It("crashes the app with an out of memory exit description when it exceeds its limit", func() {
+			// The app may be killed before it can respond, so the curl is not
+			// expected to succeed.
+			helpers.Curl(Config, helpers.AppUri(appName, "/stress/memory/256", Config)).Wait(Config.DefaultTimeoutDuration())
+
+			Eventually(func() string {
+				return string(cf.Cf("events", appName).Wait(Config.DefaultTimeoutDuration()).Out.Contents())
+			}, Config.DefaultTimeoutDuration()).Should(MatchRegexp("(?i)out of memory"))
+		})
+
+		It("does not crash the app while it stays within its limit", func() {
+			instanceGuid := helpers.CurlApp(Config, appName, "/id")
+
+			Expect(helpers.CurlApp(Config, appName, "/stress/memory/16")).To(HaveSuffix("Holding 16 MB"))
+
+			Consistently(func() string {
+				return helpers.CurlApp(Config, appName, "/id")
+			}, "10s", "2s").Should(Equal(instanceGuid))
+		})
+	})
+
+	Describe("disk", func() {
+		BeforeEach(func() {
+			pushCatnip(DEFAULT_MEMORY_LIMIT, "64M")
+		})
+
+		It("reports a disk quota error when the app fills its ephemeral disk", func() {
+			response := helpers.CurlApp(Config, appName, "/stress/disk/128")
+			Expect(response).To(MatchRegexp(`Wrote \d+ MB to .* before error: .*(?i)(disk quota exceeded|no space left on device)`))
+
+			Expect(helpers.CurlApp(Config, appName, "/stress/disk", "-X", "DELETE")).To(Equal("Removed 1 files"))
+			Expect(helpers.CurlAppRoot(Config, appName)).To(ContainSubstring("Catnip?"))
+		})
+	})
+
+	Describe("container metrics", func() {
+		var appGuid string
+
+		BeforeEach(func() {
+			pushCatnip(DEFAULT_MEMORY_LIMIT, "256M")
+			appGuid = GuidForAppName(appName)
+
+			Expect(helpers.CurlApp(Config, appName, "/stress/memory/100")).To(HaveSuffix("Holding 100 MB"))
+			Expect(helpers.CurlApp(Config, appName, "/stress/disk/100")).To(HavePrefix("Wrote 100 MB"))
+		})
+
+		It("reflects the load on the firehose", func() {
+			noaaConnection := noaa.NewConsumer(getDopplerEndpoint(), &tls.Config{InsecureSkipVerify: Config.GetSkipSSLValidation()}, nil)
+			msgChan := make(chan *events.Envelope, 100000)
+			errorChan := make(chan error)
+			stopchan := make(chan struct{})
+			go noaaConnection.Firehose(CATSRandomName("SUBSCRIPTION-ID"), getAdminUserAccessToken(), msgChan, errorChan, stopchan)
+			defer close(stopchan)
+
+			cpuStress := helpers.Curl(Config, helpers.AppUri(appName, "/stress/cpu/1/60", Config))
+			defer cpuStress.Kill()
+
+			const hundredMegabytes = 100 * 1024 * 1024
+			Eventually(func() bool {
+				for {
+					select {
+					case msg := <-msgChan:
+						if cm := msg.GetContainerMetric(); cm != nil && cm.GetApplicationId() == appGuid {
+							if cm.GetMemoryBytes() >= hundredMegabytes && cm.GetDiskBytes() >= hundredMegabytes && cm.GetCpuPercentage() >= 50 {
+								return true
+							}
+						}
+					case e := <-errorChan:
+						Expect(e).ToNot(HaveOccurred())
+					default:
+						return false
+					}
+				}
+			}, 2*Config.DefaultTimeoutDuration()).Should(BeTrue())
+		})
+
+		It("reflects the load in log-cache", func() {
+			if !Config.GetUseLogCache() {
+				Skip("Skipping this test because Config.UseLogCache is set to 'false'.")
+			}
+
+			cpuStress := helpers.Curl(Config, helpers.AppUri(appName, "/stress/cpu/1/60", Config))
+			defer cpuStress.Kill()
+
+			Eventually(func() float64 {
+				session := cf.Cf("tail", appName, "--envelope-type", "gauge", "--lines", "50").Wait(Config.DefaultTimeoutDuration())
+				Expect(session).To(Exit(0))
+				return maxGauge(string(session.Out.Contents()), "cpu")
+			}, 2*Config.DefaultTimeoutDuration(), "5s").Should(BeNumerically(">=", 50))
+
+			session := cf.Cf("tail", appName, "--envelope-type", "gauge", "--lines", "50").Wait(Config.DefaultTimeoutDuration())
+			Expect(session).To(Exit(0))
+			Expect(maxGauge(string(session.Out.Contents()), "memory")).To(BeNumerically(">=", 100*1024*1024))
+			Expect(maxGauge(string(session.Out.Contents()), "disk")).To(BeNumerically(">=", 100*1024*1024))
+		})
+	})
+})
+
+// maxGauge finds the largest value reported for the named gauge in cf tail
+// output, where gauges are printed as "name:value unit".
+func maxGauge(output, name string) float64 {
+	max := 0.0
+	gauge := regexp.MustCompile(name + `:\s*([0-9.eE+]+)`)
+	for _, line := range strings.Split(output, "\n") {
+		for _, match := range gauge.FindAllStringSubmatch(line, -1) {
+			value, err := strconv.ParseFloat(match[1], 64)
+			if err == nil && value > max {
+				max = value
+			}
+		}
+	}
+	return max
+}
@@ -1,11 +1,12 @@
 package apps
 
 import (
+	"encoding/json"
+
 	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gexec"
-	"path/filepath"
 
 	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
 	"github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
@@ -17,13 +18,27 @@ import (
 var _ = AppsDescribe("Routing Transparency", func() {
 	var appName string
 
+	type catnipRequest struct {
+		RawPath  string              `json:"raw_path"`
+		RawQuery string              `json:"raw_query"`
+		Query    map[string][]string `json:"query"`
+	}
+
+	inspectRequest := func(path string) catnipRequest {
+		var request catnipRequest
+		Eventually(func() error {
+			return json.Unmarshal([]byte(helpers.CurlApp(Config, appName, path)), &request)
+		}, Config.DefaultTimeoutDuration()).Should(Succeed())
+		return request
+	}
+
 	BeforeEach(func() {
 		appName = random_name.CATSRandomName("APP")
 		Expect(cf.Cf("push",
 			appName,
-			"-b", Config.GetGoBuildpackName(),
-			"-p", assets.NewAssets().Golang,
-			"-f", filepath.Join(assets.NewAssets().Golang, "manifest.yml"),
+			"-b", Config.GetBinaryBuildpackName(),
+			"-p", assets.NewAssets().Catnip,
+			"-c", "./catnip",
 			"-m", DEFAULT_MEMORY_LIMIT,
 			"-d", Config.GetAppsDomain()).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
 	})
@@ -34,22 +49,17 @@ var _ = AppsDescribe("Routing Transparency", func() {
 	})
 
 	It("Supports URLs with percent-encoded characters", func() {
-		var curlResponse string
-		Eventually(func() string {
-			curlResponse = helpers.CurlApp(Config, appName, "/requesturi/%21%7E%5E%24%20%27%28%29?foo=bar+baz%20bing")
-			return curlResponse
-		}, Config.DefaultTimeoutDuration()).Should(ContainSubstring("Request"))
-		Expect(curlResponse).To(ContainSubstring("/requesturi/%21%7E%5E%24%20%27%28%29"))
-		Expect(curlResponse).To(ContainSubstring("Query String is [foo=bar+baz%20bing]"))
+		request := inspectRequest("/request/%21%7E%5E%24%20%27%28%29?foo=bar+baz%20bing")
+
+		Expect(request.RawPath).To(Equal("/request/%21%7E%5E%24%20%27%28%29"))
+		Expect(request.RawQuery).To(Equal("foo=bar+baz%20bing"))
+		Expect(request.Query).To(HaveKeyWithValue("foo", []string{"bar baz bing"}))
 	})
 
 	It("transparently proxies both reserved characters and unsafe characters", func() {
-		var curlResponse string
-		Eventually(func() string {
-			curlResponse = helpers.CurlApp(Config, appName, "/requesturi/!~^'()$\"?!'()$#!'")
-			return curlResponse
-		}, Config.DefaultTimeoutDuration()).Should(ContainSubstring("Request"))
-		Expect(curlResponse).To(ContainSubstring("/requesturi/!~^'()$\""))
-		Expect(curlResponse).To(ContainSubstring("Query String is [!'()$]"))
+		request := inspectRequest("/request/!~^'()$\"?!'()$#!'")
+
+		Expect(request.RawPath).To(Equal("/request/!~^'()$\""))
+		Expect(request.RawQuery).To(Equal("!'()$"))
 	})
 })
@@ -1,7 +1,10 @@
 package apps
 
 import (
+	"encoding/json"
+	"fmt"
 	"regexp"
+	"strings"
 	"time"
 
 	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
@@ -28,6 +31,7 @@ var _ = AppsDescribe("Logging", func() {
 
 	Describe("Syslog drains", func() {
 		BeforeEach(func() {
+			logs = nil
 			interrupt = make(chan struct{}, 1)
 			serviceName = random_name.CATSRandomName("SVIN")
 			listenerAppName = random_name.CATSRandomName("APP")
@@ -66,7 +70,9 @@ var _ = AppsDescribe("Logging", func() {
 		})
 
 		AfterEach(func() {
-			logs.Kill()
+			if logs != nil {
+				logs.Kill()
+			}
 			close(interrupt)
 
 			app_helpers.AppReport(logWriterAppName1, Config.DefaultTimeoutDuration())
@@ -102,9 +108,87 @@ var _ = AppsDescribe("Logging", func() {
 			Eventually(logs, Config.DefaultTimeoutDuration()+2*time.Minute).Should(Say(randomMessage1))
 			Consistently(logs, 10).ShouldNot(Say(randomMessage2))
 		})
+
+		// forwardsToDrain binds a drain to the first log writer and waits for
+		// the listener to have parsed one of its messages as RFC 5424.
+		forwardsToDrain := func(syslogDrainURL, drain string) {
+			Eventually(cf.Cf("cups", serviceName, "-l", syslogDrainURL), Config.DefaultTimeoutDuration()).Should(Exit(0), "Failed to create syslog drain service")
+			Eventually(cf.Cf("bind-service", logWriterAppName1, serviceName), Config.DefaultTimeoutDuration()).Should(Exit(0), "Failed to bind service")
+
+			randomMessage1 := random_name.CATSRandomName("RANDOM-MESSAGE-A")
+			randomMessage2 := random_name.CATSRandomName("RANDOM-MESSAGE-B")
+			appGuid1 := app_helpers.GetAppGuid(logWriterAppName1)
+			appGuid2 := app_helpers.GetAppGuid(logWriterAppName2)
+
+			go writeLogsUntilInterrupted(interrupt, randomMessage1, logWriterAppName1)
+			go writeLogsUntilInterrupted(interrupt, randomMessage2, logWriterAppName2)
+
+			var received drainMessage
+			Eventually(func() bool {
+				for _, message := range drainMessages(listenerAppName, appGuid1) {
+					if message.Drain == drain && strings.Contains(message.Message, randomMessage1) {
+						received = message
+						return true
+					}
+				}
+				return false
+			}, Config.DefaultTimeoutDuration()+2*time.Minute, "5s").Should(BeTrue())
+
+			Expect(received.ProcID).To(Equal("[APP/PROC/WEB/0]"))
+			Expect(received.AppName).To(Equal(appGuid1))
+			Expect(received.Timestamp).NotTo(BeZero())
+			Expect(received.StructuredData).To(ContainElement(HaveKeyWithValue("id", HavePrefix("tags@"))))
+
+			Consistently(func() map[string]int {
+				var counts map[string]int
+				json.Unmarshal([]byte(helpers.CurlApp(Config, listenerAppName, "/apps")), &counts)
+				return counts
+			}, 10).ShouldNot(HaveKey(appGuid2))
+		}
+
+		It("forwards app messages to registered syslog-tls drains", func() {
+			// The listener's certificate is self-signed, so only environments
+			// that skip SSL validation can be expected to trust it.
+			if !Config.GetSkipSSLValidation() {
+				Skip("Skipping this test because Config.SkipSSLValidation is set to 'false'.")
+			}
+
+			forwardsToDrain("syslog-tls://"+getSyslogDrainAddress(listenerAppName), "syslog-tls")
+		})
+
+		It("forwards app messages to registered https drains", func() {
+			// Loggregator verifies the certificate of the router in front of
+			// the listener, which is only trusted where SSL validation is skipped.
+			if !Config.GetSkipSSLValidation() {
+				Skip("Skipping this test because Config.SkipSSLValidation is set to 'false'.")
+			}
+
+			forwardsToDrain(fmt.Sprintf("https://%s.%s/drain", listenerAppName, Config.GetAppsDomain()), "https")
+		})
 	})
 })
 
+// drainMessage is a message as stored by the syslog drain listener.
+type drainMessage struct {
+	AppName        string                   `json:"app_name"`
+	ProcID         string                   `json:"proc_id"`
+	Timestamp      time.Time                `json:"timestamp"`
+	StructuredData []map[string]interface{} `json:"structured_data"`
+	Message        string                   `json:"message"`
+	Drain          string                   `json:"drain"`
+}
+
+// drainMessages returns the most recent messages the listener has received
+// from the app, or none if it has not heard from it yet.
+func drainMessages(listenerAppName, appGuid string) []drainMessage {
+	var messages []drainMessage
+	body := helpers.CurlApp(Config, listenerAppName, "/apps/"+appGuid+"/messages")
+	if err := json.Unmarshal([]byte(body), &messages); err != nil {
+		return nil
+	}
+	return messages
+}
+
 func getSyslogDrainAddress(appName string) string {
 	var address []byte
 
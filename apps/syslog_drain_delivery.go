@@ -0,0 +1,222 @@
+package apps
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/app_helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+const (
+	// A burst is logged at a steady rate well below the log rate limits
+	// Loggregator applies, so that any loss is down to the drain.
+	burstRate  = 100
+	burstCount = 1000
+)
+
+// drainSequence is the listener's account of one catnip log generator run.
+type drainSequence struct {
+	Instance   string `json:"instance"`
+	Run        string `json:"run"`
+	Received   int    `json:"received"`
+	Duplicates int    `json:"duplicates"`
+	OutOfOrder int    `json:"out_of_order"`
+	Gaps       []struct {
+		From int `json:"from"`
+		To   int `json:"to"`
+	} `json:"gaps"`
+	LatencyMs *struct {
+		P50 float64 `json:"p50"`
+		P90 float64 `json:"p90"`
+		P99 float64 `json:"p99"`
+		Max float64 `json:"max"`
+	} `json:"latency_ms"`
+}
+
+var _ = AppsDescribe("Syslog drain delivery", func() {
+	var (
+		listenerAppName   string
+		generatorAppName1 string
+		generatorAppName2 string
+		serviceName       string
+	)
+
+	pushCatnip := func(appName string) {
+		Expect(cf.Cf(
+			"push", appName,
+			"-b", Config.GetBinaryBuildpackName(),
+			"-m", DEFAULT_MEMORY_LIMIT,
+			"-p", assets.NewAssets().Catnip,
+			"-c", "./catnip",
+			"-d", Config.GetAppsDomain(),
+		).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+	}
+
+	// startLogRun has catnip log count sequenced lines and returns the run's
+	// id. A rate of zero logs them as fast as possible.
+	startLogRun := func(appName string, count, rate int) string {
+		var status struct {
+			Id string `json:"id"`
+		}
+		spec := fmt.Sprintf(`{"count":%d,"rate":%d}`, count, rate)
+		body := helpers.CurlApp(Config, appName, "/log/generate", "-X", "POST", "-d", spec)
+		Expect(json.Unmarshal([]byte(body), &status)).To(Succeed(), body)
+		return status.Id
+	}
+
+	// sequence returns what the listener has received of the run, which is
+	// nothing at all until the first of its lines arrives.
+	sequence := func(appGuid, run string) drainSequence {
+		var app struct {
+			Sequences []drainSequence `json:"sequences"`
+		}
+		body := helpers.CurlApp(Config, listenerAppName, "/apps/"+appGuid)
+		if err := json.Unmarshal([]byte(body), &app); err != nil {
+			return drainSequence{Run: run}
+		}
+		for _, s := range app.Sequences {
+			if s.Instance == "[APP/PROC/WEB/0]" && s.Run == run {
+				return s
+			}
+		}
+		return drainSequence{Run: run}
+	}
+
+	// waitForDrain logs single lines until one reaches the listener, since
+	// new bindings take a while to be picked up by the syslog agents.
+	waitForDrain := func(appName, appGuid string) {
+		Eventually(func() int {
+			return sequence(appGuid, startLogRun(appName, 1, 0)).Received
+		}, Config.DefaultTimeoutDuration()+2*time.Minute, "10s").Should(Equal(1))
+	}
+
+	// awaitBurst waits for a run of burstCount lines to finish and for the
+	// listener to stop receiving them before reporting how they were
+	// delivered.
+	awaitBurst := func(appName, appGuid, run string) drainSequence {
+		Eventually(func() bool {
+			var status struct {
+				Done bool `json:"done"`
+			}
+			json.Unmarshal([]byte(helpers.CurlApp(Config, appName, "/log/generate/"+run)), &status)
+			return status.Done
+		}, Config.DefaultTimeoutDuration(), "2s").Should(BeTrue())
+
+		received := -1
+		Eventually(func() bool {
+			latest := sequence(appGuid, run).Received
+			settled := latest == received || latest == burstCount
+			received = latest
+			return settled
+		}, Config.DefaultTimeoutDuration(), "10s").Should(BeTrue())
+
+		return sequence(appGuid, run)
+	}
+
+	expectWithinLossThreshold := func(appName string, s drainSequence) {
+		lost := burstCount - s.Received
+		lossPercent := 100 * float64(lost) / float64(burstCount)
+
+		fmt.Fprintf(GinkgoWriter, "Syslog drain delivery for %s run %s: %d/%d delivered (%.2f%%), %d lost, %d duplicates, %d out of order\n",
+			appName, s.Run, s.Received, burstCount, 100*float64(s.Received)/float64(burstCount), lost, s.Duplicates, s.OutOfOrder)
+		if s.LatencyMs != nil {
+			fmt.Fprintf(GinkgoWriter, "Syslog drain latency in ms: p50 %.1f, p90 %.1f, p99 %.1f, max %.1f\n",
+				s.LatencyMs.P50, s.LatencyMs.P90, s.LatencyMs.P99, s.LatencyMs.Max)
+		}
+
+		Expect(lossPercent).To(BeNumerically("<=", Config.GetSyslogDrainMaxLossPercent()),
+			fmt.Sprintf("%d of %d lines did not reach the drain; gaps: %v", lost, burstCount, s.Gaps))
+	}
+
+	bind := func(appName string) {
+		Expect(cf.Cf("bind-service", appName, serviceName).Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+	}
+
+	BeforeEach(func() {
+		listenerAppName = random_name.CATSRandomName("APP")
+		generatorAppName1 = random_name.CATSRandomName("APP")
+		generatorAppName2 = random_name.CATSRandomName("APP")
+		serviceName = random_name.CATSRandomName("SVIN")
+
+		Expect(cf.Cf(
+			"push",
+			listenerAppName,
+			"--health-check-type", "port",
+			"-b", Config.GetGoBuildpackName(),
+			"-m", DEFAULT_MEMORY_LIMIT,
+			"-p", assets.NewAssets().SyslogDrainListener,
+			"-d", Config.GetAppsDomain(),
+			"-f", assets.NewAssets().SyslogDrainListener+"/manifest.yml",
+		).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+		pushCatnip(generatorAppName1)
+		pushCatnip(generatorAppName2)
+
+		syslogDrainURL := "syslog://" + getSyslogDrainAddress(listenerAppName)
+		Expect(cf.Cf("cups", serviceName, "-l", syslogDrainURL).Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+	})
+
+	AfterEach(func() {
+		app_helpers.AppReport(generatorAppName1, Config.DefaultTimeoutDuration())
+		app_helpers.AppReport(generatorAppName2, Config.DefaultTimeoutDuration())
+		app_helpers.AppReport(listenerAppName, Config.DefaultTimeoutDuration())
+
+		Expect(cf.Cf("delete", generatorAppName1, "-f", "-r").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+		Expect(cf.Cf("delete", generatorAppName2, "-f", "-r").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+		Expect(cf.Cf("delete", listenerAppName, "-f", "-r").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+		Expect(cf.Cf("delete-service", serviceName, "-f").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+	})
+
+	It("delivers a burst of log lines within the loss threshold", func() {
+		appGuid := app_helpers.GetAppGuid(generatorAppName1)
+		bind(generatorAppName1)
+		waitForDrain(generatorAppName1, appGuid)
+
+		run := startLogRun(generatorAppName1, burstCount, burstRate)
+		expectWithinLossThreshold(generatorAppName1, awaitBurst(generatorAppName1, appGuid, run))
+	})
+
+	It("delivers the logs of every app bound to the drain", func() {
+		appGuid1 := app_helpers.GetAppGuid(generatorAppName1)
+		appGuid2 := app_helpers.GetAppGuid(generatorAppName2)
+		bind(generatorAppName1)
+		bind(generatorAppName2)
+		waitForDrain(generatorAppName1, appGuid1)
+		waitForDrain(generatorAppName2, appGuid2)
+
+		run1 := startLogRun(generatorAppName1, burstCount, burstRate)
+		run2 := startLogRun(generatorAppName2, burstCount, burstRate)
+		expectWithinLossThreshold(generatorAppName1, awaitBurst(generatorAppName1, appGuid1, run1))
+		expectWithinLossThreshold(generatorAppName2, awaitBurst(generatorAppName2, appGuid2, run2))
+	})
+
+	It("stops delivering logs once the drain is unbound", func() {
+		appGuid := app_helpers.GetAppGuid(generatorAppName1)
+		bind(generatorAppName1)
+		waitForDrain(generatorAppName1, appGuid)
+
+		Expect(cf.Cf("unbind-service", generatorAppName1, serviceName).Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+
+		// Unbinding takes as long to reach the syslog agents as binding did,
+		// so wait for a run that the listener does not hear from at all.
+		Eventually(func() int {
+			run := startLogRun(generatorAppName1, 10, 0)
+			time.Sleep(10 * time.Second)
+			return sequence(appGuid, run).Received
+		}, Config.DefaultTimeoutDuration()+2*time.Minute, "1s").Should(BeZero())
+
+		run := startLogRun(generatorAppName1, 100, burstRate)
+		Consistently(func() int {
+			return sequence(appGuid, run).Received
+		}, 30*time.Second, "5s").Should(BeZero())
+	})
+})
@@ -0,0 +1,286 @@
+package container
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/linux"
+)
+
+// Unlimited is reported for cgroup limits that are not set.
+const Unlimited = -1
+
+type Container struct {
+	Uid        int               `json:"uid"`
+	Gid        int               `json:"gid"`
+	Groups     []int             `json:"groups"`
+	User       string            `json:"user"`
+	Hostname   string            `json:"hostname"`
+	Kernel     string            `json:"kernel"`
+	OSRelease  map[string]string `json:"os_release"`
+	Cgroup     Cgroup            `json:"cgroup"`
+	Ulimits    map[string]Limit  `json:"ulimits"`
+	Disk       Disk              `json:"disk"`
+	Mounts     []Mount           `json:"mounts"`
+	Interfaces []Interface       `json:"interfaces"`
+}
+
+// Cgroup limits are read from cgroup v2 when it is mounted and from the v1
+// controllers otherwise. CPUShares is only reported by v1 and CPUWeight only
+// by v2.
+type Cgroup struct {
+	Version          int   `json:"version"`
+	MemoryLimitBytes int64 `json:"memory_limit_bytes"`
+	CPUShares        int64 `json:"cpu_shares,omitempty"`
+	CPUWeight        int64 `json:"cpu_weight,omitempty"`
+	CPUQuotaUs       int64 `json:"cpu_quota_us"`
+	CPUPeriodUs      int64 `json:"cpu_period_us"`
+	PidsLimit        int64 `json:"pids_limit"`
+}
+
+type Limit struct {
+	Soft  string `json:"soft"`
+	Hard  string `json:"hard"`
+	Units string `json:"units,omitempty"`
+}
+
+type Disk struct {
+	Path       string `json:"path"`
+	TotalBytes uint64 `json:"total_bytes"`
+	FreeBytes  uint64 `json:"free_bytes"`
+}
+
+type Mount struct {
+	Device  string   `json:"device"`
+	Path    string   `json:"path"`
+	Type    string   `json:"type"`
+	Options []string `json:"options"`
+}
+
+type Interface struct {
+	Name      string   `json:"name"`
+	MTU       int      `json:"mtu"`
+	Addresses []string `json:"addresses"`
+}
+
+// Inspector reads /proc, /sys and /etc below Root, which is "/" outside of
+// tests.
+type Inspector struct {
+	Root string
+}
+
+func NewInspector(root string) *Inspector {
+	return &Inspector{Root: root}
+}
+
+func (i *Inspector) ContainerHandler(res http.ResponseWriter, req *http.Request) {
+	res.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(res).Encode(i.Inspect())
+}
+
+// Inspect reports whatever it can read; anything missing is left empty rather
+// than failing the whole report.
+func (i *Inspector) Inspect() Container {
+	container := Container{
+		Uid:        os.Getuid(),
+		Gid:        os.Getgid(),
+		Groups:     []int{},
+		OSRelease:  map[string]string{},
+		Cgroup:     i.cgroup(),
+		Ulimits:    i.ulimits(),
+		Disk:       disk(i.Root),
+		Mounts:     i.mounts(),
+		Interfaces: interfaces(),
+	}
+
+	if groups, err := os.Getgroups(); err == nil {
+		container.Groups = groups
+	}
+	if u, err := user.Current(); err == nil {
+		container.User = u.Username
+	}
+	container.Hostname, _ = os.Hostname()
+	container.Kernel = i.readString("proc/sys/kernel/osrelease")
+	if file, err := os.Open(i.path("etc/os-release")); err == nil {
+		container.OSRelease = linux.ParseOSRelease(file)
+		file.Close()
+	}
+
+	return container
+}
+
+func (i *Inspector) cgroup() Cgroup {
+	if _, err := os.Stat(i.path("sys/fs/cgroup/cgroup.controllers")); err == nil {
+		cgroup := Cgroup{
+			Version:          2,
+			MemoryLimitBytes: i.readLimit("sys/fs/cgroup/memory.max"),
+			CPUWeight:        i.readLimit("sys/fs/cgroup/cpu.weight"),
+			CPUQuotaUs:       Unlimited,
+			PidsLimit:        i.readLimit("sys/fs/cgroup/pids.max"),
+		}
+
+		// cpu.max holds the quota and the period, e.g. "max 100000".
+		cpuMax := strings.Fields(i.readString("sys/fs/cgroup/cpu.max"))
+		if len(cpuMax) == 2 {
+			cgroup.CPUQuotaUs = parseLimit(cpuMax[0])
+			cgroup.CPUPeriodUs = parseLimit(cpuMax[1])
+		}
+		return cgroup
+	}
+
+	cgroup := Cgroup{
+		Version:          1,
+		MemoryLimitBytes: i.readLimit("sys/fs/cgroup/memory/memory.limit_in_bytes"),
+		CPUShares:        i.readLimit("sys/fs/cgroup/cpu/cpu.shares"),
+		CPUQuotaUs:       i.readLimit("sys/fs/cgroup/cpu/cpu.cfs_quota_us"),
+		CPUPeriodUs:      i.readLimit("sys/fs/cgroup/cpu/cpu.cfs_period_us"),
+		PidsLimit:        i.readLimit("sys/fs/cgroup/pids/pids.max"),
+	}
+	// v1 reports an unset memory limit as a huge number rather than "max".
+	if cgroup.MemoryLimitBytes >= 1<<62 {
+		cgroup.MemoryLimitBytes = Unlimited
+	}
+	return cgroup
+}
+
+// ulimits parses /proc/self/limits, whose columns are aligned under the
+// "Soft Limit", "Hard Limit" and "Units" headings.
+func (i *Inspector) ulimits() map[string]Limit {
+	limits := map[string]Limit{}
+
+	file, err := os.Open(i.path("proc/self/limits"))
+	if err != nil {
+		return limits
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() {
+		return limits
+	}
+	header := scanner.Text()
+	soft := strings.Index(header, "Soft Limit")
+	if soft < 0 {
+		return limits
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) <= soft {
+			continue
+		}
+
+		name := strings.ToLower(strings.TrimSpace(line[:soft]))
+		fields := strings.Fields(line[soft:])
+		if len(fields) < 2 {
+			continue
+		}
+
+		limit := Limit{Soft: fields[0], Hard: fields[1]}
+		if len(fields) > 2 {
+			limit.Units = fields[2]
+		}
+		limits[name] = limit
+	}
+	return limits
+}
+
+func (i *Inspector) mounts() []Mount {
+	mounts := []Mount{}
+
+	file, err := os.Open(i.path("proc/self/mounts"))
+	if err != nil {
+		return mounts
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+
+		mounts = append(mounts, Mount{
+			Device:  fields[0],
+			Path:    fields[1],
+			Type:    fields[2],
+			Options: strings.Split(fields[3], ","),
+		})
+	}
+	return mounts
+}
+
+func (i *Inspector) readLimit(name string) int64 {
+	return parseLimit(i.readString(name))
+}
+
+func (i *Inspector) readString(name string) string {
+	contents, err := ioutil.ReadFile(i.path(name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(contents))
+}
+
+func (i *Inspector) path(name string) string {
+	return filepath.Join(i.Root, name)
+}
+
+func parseLimit(value string) int64 {
+	if value == "" || value == "max" {
+		return Unlimited
+	}
+
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return Unlimited
+	}
+	return limit
+}
+
+func disk(path string) Disk {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return Disk{Path: path}
+	}
+
+	return Disk{
+		Path:       path,
+		TotalBytes: uint64(stat.Blocks) * uint64(stat.Bsize),
+		FreeBytes:  uint64(stat.Bavail) * uint64(stat.Bsize),
+	}
+}
+
+func interfaces() []Interface {
+	result := []Interface{}
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return result
+	}
+
+	for _, iface := range ifaces {
+		i := Interface{
+			Name:      iface.Name,
+			MTU:       iface.MTU,
+			Addresses: []string{},
+		}
+		if addrs, err := iface.Addrs(); err == nil {
+			for _, addr := range addrs {
+				i.Addresses = append(i.Addresses, addr.String())
+			}
+		}
+		result = append(result, i)
+	}
+	return result
+}
@@ -0,0 +1,134 @@
+package container_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/container"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Inspector", func() {
+	var root string
+
+	writeFile := func(name, contents string) {
+		path := filepath.Join(root, name)
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		root, err = ioutil.TempDir("", "catnip-container")
+		Expect(err).NotTo(HaveOccurred())
+
+		writeFile("etc/os-release", "NAME=\"Ubuntu\"\nVERSION_ID=\"18.04\"\nVERSION_CODENAME=bionic\n")
+		writeFile("proc/sys/kernel/osrelease", "4.15.0-42-generic\n")
+		writeFile("proc/self/limits", `Limit                     Soft Limit           Hard Limit           Units     
+Max cpu time              unlimited            unlimited            seconds   
+Max open files            16384                16384                files     
+Max processes             1024                 1024                 processes 
+Max nice priority         0                    0                    
+`)
+		writeFile("proc/self/mounts", `/dev/loop1 / xfs rw,relatime,prjquota 0 0
+proc /proc proc ro,nosuid,nodev,noexec,relatime 0 0
+/dev/sda1 /var/vcap/data/nfs ext4 rw,relatime 0 0
+`)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(root)
+	})
+
+	It("reports the user, kernel and os release", func() {
+		report := container.NewInspector(root).Inspect()
+
+		Expect(report.Uid).To(Equal(os.Getuid()))
+		Expect(report.Gid).To(Equal(os.Getgid()))
+		Expect(report.Kernel).To(Equal("4.15.0-42-generic"))
+		Expect(report.OSRelease).To(Equal(map[string]string{
+			"NAME":             "Ubuntu",
+			"VERSION_ID":       "18.04",
+			"VERSION_CODENAME": "bionic",
+		}))
+	})
+
+	It("reports ulimits", func() {
+		report := container.NewInspector(root).Inspect()
+
+		Expect(report.Ulimits).To(HaveKeyWithValue("max open files", container.Limit{Soft: "16384", Hard: "16384", Units: "files"}))
+		Expect(report.Ulimits).To(HaveKeyWithValue("max cpu time", container.Limit{Soft: "unlimited", Hard: "unlimited", Units: "seconds"}))
+		Expect(report.Ulimits).To(HaveKeyWithValue("max nice priority", container.Limit{Soft: "0", Hard: "0"}))
+	})
+
+	It("reports mounts", func() {
+		report := container.NewInspector(root).Inspect()
+
+		Expect(report.Mounts).To(ContainElement(container.Mount{
+			Device:  "/dev/loop1",
+			Path:    "/",
+			Type:    "xfs",
+			Options: []string{"rw", "relatime", "prjquota"},
+		}))
+		Expect(report.Mounts).To(HaveLen(3))
+	})
+
+	It("reports the disk and network interfaces of the container", func() {
+		report := container.NewInspector(root).Inspect()
+
+		Expect(report.Disk.Path).To(Equal(root))
+		Expect(report.Disk.TotalBytes).To(BeNumerically(">", 0))
+		Expect(report.Interfaces).NotTo(BeEmpty())
+	})
+
+	Context("with cgroup v1", func() {
+		BeforeEach(func() {
+			writeFile("sys/fs/cgroup/memory/memory.limit_in_bytes", "268435456\n")
+			writeFile("sys/fs/cgroup/cpu/cpu.shares", "25\n")
+			writeFile("sys/fs/cgroup/cpu/cpu.cfs_quota_us", "-1\n")
+			writeFile("sys/fs/cgroup/cpu/cpu.cfs_period_us", "100000\n")
+			writeFile("sys/fs/cgroup/pids/pids.max", "1024\n")
+		})
+
+		It("reports the limits", func() {
+			Expect(container.NewInspector(root).Inspect().Cgroup).To(Equal(container.Cgroup{
+				Version:          1,
+				MemoryLimitBytes: 268435456,
+				CPUShares:        25,
+				CPUQuotaUs:       -1,
+				CPUPeriodUs:      100000,
+				PidsLimit:        1024,
+			}))
+		})
+
+		It("reports an unset memory limit as unlimited", func() {
+			writeFile("sys/fs/cgroup/memory/memory.limit_in_bytes", "9223372036854771712\n")
+
+			Expect(container.NewInspector(root).Inspect().Cgroup.MemoryLimitBytes).To(Equal(int64(container.Unlimited)))
+		})
+	})
+
+	Context("with cgroup v2", func() {
+		BeforeEach(func() {
+			writeFile("sys/fs/cgroup/cgroup.controllers", "cpu memory pids\n")
+			writeFile("sys/fs/cgroup/memory.max", "268435456\n")
+			writeFile("sys/fs/cgroup/cpu.weight", "10\n")
+			writeFile("sys/fs/cgroup/cpu.max", "max 100000\n")
+			writeFile("sys/fs/cgroup/pids.max", "max\n")
+		})
+
+		It("reports the limits", func() {
+			Expect(container.NewInspector(root).Inspect().Cgroup).To(Equal(container.Cgroup{
+				Version:          2,
+				MemoryLimitBytes: 268435456,
+				CPUWeight:        10,
+				CPUQuotaUs:       container.Unlimited,
+				CPUPeriodUs:      100000,
+				PidsLimit:        container.Unlimited,
+			}))
+		})
+	})
+})
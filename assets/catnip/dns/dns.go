@@ -0,0 +1,235 @@
+package dns
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/gorilla/mux"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/httperr"
+)
+
+const timeout = 5 * time.Second
+
+// Lookup is the outcome of asking the resolver for one record type. Rcode
+// and Records are only meaningful when Error is empty.
+type Lookup struct {
+	Type       string   `json:"type"`
+	Rcode      string   `json:"rcode,omitempty"`
+	Records    []Record `json:"records"`
+	DurationMs float64  `json:"duration_ms"`
+	Error      string   `json:"error,omitempty"`
+}
+
+type Answer struct {
+	Name     string   `json:"name"`
+	Resolver string   `json:"resolver"`
+	Lookups  []Lookup `json:"lookups"`
+}
+
+// Dial reports whether a TCP connection could be made, in the same shape as
+// the probe package's results.
+type Dial struct {
+	Address    string  `json:"address"`
+	Success    bool    `json:"success"`
+	RemoteAddr string  `json:"remote_addr,omitempty"`
+	LatencyMs  float64 `json:"latency_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// Resolver asks a nameserver directly rather than going through the Go
+// resolver so that it can report TTLs and which server answered. It does
+// not search domains, so names are always treated as fully qualified.
+type Resolver struct {
+	clock  clock.Clock
+	server string
+}
+
+func NewResolver(clock clock.Clock, server string) *Resolver {
+	return &Resolver{
+		clock:  clock,
+		server: server,
+	}
+}
+
+// Nameserver returns the first nameserver in a resolv.conf file as a
+// host:port, falling back to the local host.
+func Nameserver(resolvConf string) string {
+	file, err := os.Open(resolvConf)
+	if err != nil {
+		return "127.0.0.1:53"
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			return net.JoinHostPort(fields[1], "53")
+		}
+	}
+	return "127.0.0.1:53"
+}
+
+// DigHandler looks up A, AAAA and SRV records for the name, or just the
+// comma separated types in the type query parameter. The server query
+// parameter overrides the nameserver from resolv.conf.
+func (r *Resolver) DigHandler(res http.ResponseWriter, req *http.Request) {
+	name := mux.Vars(req)["name"]
+	server := r.server
+	if s := req.URL.Query().Get("server"); s != "" {
+		server = s
+	}
+
+	types := []uint16{TypeA, TypeAAAA, TypeSRV}
+	if t := req.URL.Query().Get("type"); t != "" {
+		types = nil
+		for _, typeName := range strings.Split(t, ",") {
+			rrType, ok := ParseType(typeName)
+			if !ok {
+				httperr.Write(res, http.StatusBadRequest, "Unsupported record type %q", typeName)
+				return
+			}
+			types = append(types, rrType)
+		}
+	}
+
+	answer := Answer{Name: name, Resolver: server}
+	for _, rrType := range types {
+		answer.Lookups = append(answer.Lookups, r.lookup(server, name, rrType))
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(res).Encode(answer)
+}
+
+// DialHandler opens a TCP connection to the host:port and reports the
+// address it connected to, which tells callers which instance behind an
+// internal route answered.
+func (r *Resolver) DialHandler(res http.ResponseWriter, req *http.Request) {
+	address := mux.Vars(req)["address"]
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		httperr.Write(res, http.StatusBadRequest, "Invalid address %q: %s", address, err)
+		return
+	}
+
+	start := r.clock.Now()
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	dial := Dial{Address: address, LatencyMs: milliseconds(r.clock.Since(start))}
+	if err != nil {
+		dial.Error = err.Error()
+	} else {
+		dial.Success = true
+		dial.RemoteAddr = conn.RemoteAddr().String()
+		conn.Close()
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(res).Encode(dial)
+}
+
+func (r *Resolver) lookup(server, name string, rrType uint16) Lookup {
+	lookup := Lookup{Type: TypeName(rrType), Records: []Record{}}
+
+	start := r.clock.Now()
+	reply, err := exchange(server, Message{
+		ID:        uint16(rand.Intn(1 << 16)),
+		Questions: []Question{{Name: name, Type: rrType}},
+	})
+	lookup.DurationMs = milliseconds(r.clock.Since(start))
+	if err != nil {
+		lookup.Error = err.Error()
+		return lookup
+	}
+
+	lookup.Rcode = RcodeName(reply.Rcode)
+	for _, record := range reply.Answers {
+		if record.Type == lookup.Type {
+			lookup.Records = append(lookup.Records, record)
+		}
+	}
+	return lookup
+}
+
+// exchange sends the query over UDP, retrying over TCP if the answer was
+// truncated.
+func exchange(server string, query Message) (Message, error) {
+	packed, err := query.Pack()
+	if err != nil {
+		return Message{}, err
+	}
+
+	conn, err := net.DialTimeout("udp", server, timeout)
+	if err != nil {
+		return Message{}, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if _, err := conn.Write(packed); err != nil {
+		return Message{}, err
+	}
+
+	buf := make([]byte, 65535)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return Message{}, err
+		}
+		reply, err := Unpack(buf[:n])
+		// Ignore anything that is not the reply to this query.
+		if err != nil || !reply.Response || reply.ID != query.ID {
+			continue
+		}
+		if reply.Truncated {
+			return exchangeTCP(server, packed, query.ID)
+		}
+		return reply, nil
+	}
+}
+
+func exchangeTCP(server string, packed []byte, id uint16) (Message, error) {
+	conn, err := net.DialTimeout("tcp", server, timeout)
+	if err != nil {
+		return Message{}, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	framed := appendUint16(nil, uint16(len(packed)))
+	if _, err := conn.Write(append(framed, packed...)); err != nil {
+		return Message{}, err
+	}
+
+	var length uint16
+	if err := binary.Read(conn, binary.BigEndian, &length); err != nil {
+		return Message{}, err
+	}
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return Message{}, err
+	}
+
+	reply, err := Unpack(buf)
+	if err != nil {
+		return Message{}, err
+	}
+	if reply.ID != id {
+		return Message{}, errors.New("reply does not match the query")
+	}
+	return reply, nil
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
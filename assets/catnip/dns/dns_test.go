@@ -0,0 +1,241 @@
+package dns_test
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/dns"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/router"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// nameserver answers over UDP and TCP from a fixed set of records,
+// truncating UDP answers with more records than truncateOver.
+type nameserver struct {
+	udp          net.PacketConn
+	tcp          net.Listener
+	records      []dns.Record
+	truncateOver int
+}
+
+func newNameserver(records []dns.Record) *nameserver {
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+	tcp, err := net.Listen("tcp", udp.LocalAddr().String())
+	Expect(err).NotTo(HaveOccurred())
+
+	n := &nameserver{udp: udp, tcp: tcp, records: records, truncateOver: 100}
+	go n.serveUDP()
+	go n.serveTCP()
+	return n
+}
+
+func (n *nameserver) Addr() string {
+	return n.udp.LocalAddr().String()
+}
+
+func (n *nameserver) Close() {
+	n.udp.Close()
+	n.tcp.Close()
+}
+
+func (n *nameserver) reply(packed []byte, udp bool) []byte {
+	query, err := dns.Unpack(packed)
+	if err != nil {
+		return nil
+	}
+
+	reply := dns.Message{ID: query.ID, Response: true, Questions: query.Questions, Rcode: 3}
+	for _, record := range n.records {
+		if record.Name == query.Questions[0].Name {
+			reply.Rcode = 0
+			if record.Type == dns.TypeName(query.Questions[0].Type) {
+				reply.Answers = append(reply.Answers, record)
+			}
+		}
+	}
+	if udp && len(reply.Answers) > n.truncateOver {
+		reply.Answers = reply.Answers[:n.truncateOver]
+		reply.Truncated = true
+	}
+
+	out, _ := reply.Pack()
+	return out
+}
+
+func (n *nameserver) serveUDP() {
+	buf := make([]byte, 512)
+	for {
+		size, addr, err := n.udp.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		n.udp.WriteTo(n.reply(buf[:size], true), addr)
+	}
+}
+
+func (n *nameserver) serveTCP() {
+	for {
+		conn, err := n.tcp.Accept()
+		if err != nil {
+			return
+		}
+		var length uint16
+		binary.Read(conn, binary.BigEndian, &length)
+		query := make([]byte, length)
+		io.ReadFull(conn, query)
+		reply := n.reply(query, false)
+		var framed [2]byte
+		binary.BigEndian.PutUint16(framed[:], uint16(len(reply)))
+		conn.Write(append(framed[:], reply...))
+		conn.Close()
+	}
+}
+
+var _ = Describe("DNS", func() {
+	var (
+		server *httptest.Server
+		ns     *nameserver
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(router.New(os.Stdout, fakeclock.NewFakeClock(time.Now())))
+		ns = newNameserver([]dns.Record{
+			{Type: "A", Name: "backend.apps.internal.", TTL: 0, Address: "10.255.0.1"},
+			{Type: "A", Name: "backend.apps.internal.", TTL: 0, Address: "10.255.0.2"},
+			{Type: "AAAA", Name: "backend.apps.internal.", TTL: 30, Address: "fd00::1"},
+			{Type: "SRV", Name: "_http._tcp.backend.apps.internal.", TTL: 5, Priority: 1, Weight: 10, Port: 8080, Target: "backend.apps.internal."},
+		})
+	})
+
+	AfterEach(func() {
+		ns.Close()
+		server.Close()
+	})
+
+	dig := func(path string) dns.Answer {
+		res, err := http.Get(server.URL + path)
+		Expect(err).NotTo(HaveOccurred())
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		Expect(res.Header.Get("Content-Type")).To(Equal("application/json"))
+
+		var answer dns.Answer
+		Expect(json.NewDecoder(res.Body).Decode(&answer)).To(Succeed())
+		return answer
+	}
+
+	Describe("/dig", func() {
+		It("reports A, AAAA and SRV records with their TTLs and the resolver used", func() {
+			answer := dig("/dig/backend.apps.internal?server=" + ns.Addr())
+			Expect(answer.Name).To(Equal("backend.apps.internal"))
+			Expect(answer.Resolver).To(Equal(ns.Addr()))
+			Expect(answer.Lookups).To(HaveLen(3))
+
+			Expect(answer.Lookups[0].Type).To(Equal("A"))
+			Expect(answer.Lookups[0].Rcode).To(Equal("NOERROR"))
+			Expect(answer.Lookups[0].Records).To(ConsistOf(
+				dns.Record{Type: "A", Name: "backend.apps.internal.", Address: "10.255.0.1"},
+				dns.Record{Type: "A", Name: "backend.apps.internal.", Address: "10.255.0.2"},
+			))
+			Expect(answer.Lookups[1].Records).To(ConsistOf(
+				dns.Record{Type: "AAAA", Name: "backend.apps.internal.", TTL: 30, Address: "fd00::1"},
+			))
+			Expect(answer.Lookups[2].Type).To(Equal("SRV"))
+			Expect(answer.Lookups[2].Records).To(BeEmpty())
+		})
+
+		It("looks up only the requested types", func() {
+			answer := dig("/dig/_http._tcp.backend.apps.internal?type=srv&server=" + ns.Addr())
+			Expect(answer.Lookups).To(HaveLen(1))
+			Expect(answer.Lookups[0].Records).To(ConsistOf(
+				dns.Record{Type: "SRV", Name: "_http._tcp.backend.apps.internal.", TTL: 5, Priority: 1, Weight: 10, Port: 8080, Target: "backend.apps.internal."},
+			))
+		})
+
+		It("reports names that do not exist", func() {
+			answer := dig("/dig/missing.apps.internal?type=A&server=" + ns.Addr())
+			Expect(answer.Lookups[0].Rcode).To(Equal("NXDOMAIN"))
+			Expect(answer.Lookups[0].Records).To(BeEmpty())
+		})
+
+		It("retries truncated answers over TCP", func() {
+			ns.truncateOver = 1
+
+			answer := dig("/dig/backend.apps.internal?type=A&server=" + ns.Addr())
+			Expect(answer.Lookups[0].Records).To(HaveLen(2))
+		})
+
+		It("reports resolver errors per lookup", func() {
+			answer := dig("/dig/backend.apps.internal?type=A&server=not-a-server")
+			Expect(answer.Lookups[0].Error).NotTo(BeEmpty())
+		})
+
+		It("rejects unsupported types", func() {
+			res, err := http.Get(server.URL + "/dig/backend.apps.internal?type=MX")
+			Expect(err).NotTo(HaveOccurred())
+			res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("/dial", func() {
+		dial := func(address string) dns.Dial {
+			res, err := http.Get(server.URL + "/dial/" + address)
+			Expect(err).NotTo(HaveOccurred())
+			defer res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+
+			var result dns.Dial
+			Expect(json.NewDecoder(res.Body).Decode(&result)).To(Succeed())
+			return result
+		}
+
+		It("reports the address it connected to", func() {
+			result := dial(ns.tcp.Addr().String())
+			Expect(result.Success).To(BeTrue())
+			Expect(result.RemoteAddr).To(Equal(ns.tcp.Addr().String()))
+		})
+
+		It("reports connection failures", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			address := listener.Addr().String()
+			listener.Close()
+
+			result := dial(address)
+			Expect(result.Success).To(BeFalse())
+			Expect(result.Error).To(ContainSubstring("refused"))
+		})
+	})
+
+	Describe("Unpack", func() {
+		It("follows compression pointers", func() {
+			msg := []byte{
+				0, 1, 0x81, 0x80, 0, 1, 0, 1, 0, 0, 0, 0,
+				3, 'f', 'o', 'o', 0, 0, 1, 0, 1,
+				0xc0, 12, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4, 10, 0, 0, 7,
+			}
+
+			m, err := dns.Unpack(msg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.Questions).To(Equal([]dns.Question{{Name: "foo.", Type: dns.TypeA}}))
+			Expect(m.Answers).To(Equal([]dns.Record{{Type: "A", Name: "foo.", TTL: 60, Address: "10.0.0.7"}}))
+		})
+
+		It("rejects pointer loops", func() {
+			msg := []byte{0, 1, 0x81, 0x80, 0, 1, 0, 0, 0, 0, 0, 0, 0xc0, 12}
+
+			_, err := dns.Unpack(msg)
+			Expect(err).To(MatchError(ContainSubstring("compression pointers")))
+		})
+	})
+})
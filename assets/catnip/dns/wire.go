@@ -0,0 +1,289 @@
+package dns
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+const (
+	TypeA    uint16 = 1
+	TypeAAAA uint16 = 28
+	TypeSRV  uint16 = 33
+
+	classINET uint16 = 1
+
+	flagResponse  = 1 << 15
+	flagTruncated = 1 << 9
+	flagRecursion = 1 << 8
+)
+
+var typeNames = map[uint16]string{
+	TypeA:    "A",
+	TypeAAAA: "AAAA",
+	TypeSRV:  "SRV",
+}
+
+var rcodeNames = map[int]string{
+	0: "NOERROR",
+	1: "FORMERR",
+	2: "SERVFAIL",
+	3: "NXDOMAIN",
+	4: "NOTIMP",
+	5: "REFUSED",
+}
+
+// Record is a resource record from an answer section. Address is set for A
+// and AAAA records; Priority, Weight, Port and Target for SRV records.
+type Record struct {
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	TTL      uint32 `json:"ttl"`
+	Address  string `json:"address,omitempty"`
+	Priority uint16 `json:"priority,omitempty"`
+	Weight   uint16 `json:"weight,omitempty"`
+	Port     uint16 `json:"port,omitempty"`
+	Target   string `json:"target,omitempty"`
+}
+
+type Question struct {
+	Name string
+	Type uint16
+}
+
+// Message is the subset of a DNS message catnip needs to ask a question and
+// read the answers: no authority or additional sections and no EDNS.
+type Message struct {
+	ID        uint16
+	Response  bool
+	Truncated bool
+	Rcode     int
+	Questions []Question
+	Answers   []Record
+}
+
+func TypeName(t uint16) string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("TYPE%d", t)
+}
+
+// ParseType accepts the record types catnip knows how to look up.
+func ParseType(name string) (uint16, bool) {
+	for t, n := range typeNames {
+		if strings.EqualFold(n, name) {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+func RcodeName(rcode int) string {
+	if name, ok := rcodeNames[rcode]; ok {
+		return name
+	}
+	return fmt.Sprintf("RCODE%d", rcode)
+}
+
+// Pack encodes the message without name compression.
+func (m Message) Pack() ([]byte, error) {
+	flags := uint16(flagRecursion) | uint16(m.Rcode&0xf)
+	if m.Response {
+		flags |= flagResponse
+	}
+	if m.Truncated {
+		flags |= flagTruncated
+	}
+
+	msg := make([]byte, 12)
+	binary.BigEndian.PutUint16(msg[0:], m.ID)
+	binary.BigEndian.PutUint16(msg[2:], flags)
+	binary.BigEndian.PutUint16(msg[4:], uint16(len(m.Questions)))
+	binary.BigEndian.PutUint16(msg[6:], uint16(len(m.Answers)))
+
+	var err error
+	for _, q := range m.Questions {
+		if msg, err = appendName(msg, q.Name); err != nil {
+			return nil, err
+		}
+		msg = appendUint16(msg, q.Type)
+		msg = appendUint16(msg, classINET)
+	}
+
+	for _, r := range m.Answers {
+		if msg, err = appendRecord(msg, r); err != nil {
+			return nil, err
+		}
+	}
+	return msg, nil
+}
+
+// Unpack decodes a message, skipping answers of types catnip does not know.
+func Unpack(msg []byte) (Message, error) {
+	if len(msg) < 12 {
+		return Message{}, errors.New("message shorter than its header")
+	}
+
+	flags := binary.BigEndian.Uint16(msg[2:])
+	m := Message{
+		ID:        binary.BigEndian.Uint16(msg[0:]),
+		Response:  flags&flagResponse != 0,
+		Truncated: flags&flagTruncated != 0,
+		Rcode:     int(flags & 0xf),
+	}
+	questions := int(binary.BigEndian.Uint16(msg[4:]))
+	answers := int(binary.BigEndian.Uint16(msg[6:]))
+
+	off := 12
+	for i := 0; i < questions; i++ {
+		name, next, err := readName(msg, off)
+		if err != nil {
+			return Message{}, err
+		}
+		if next+4 > len(msg) {
+			return Message{}, errors.New("question truncated")
+		}
+		m.Questions = append(m.Questions, Question{Name: name, Type: binary.BigEndian.Uint16(msg[next:])})
+		off = next + 4
+	}
+
+	for i := 0; i < answers; i++ {
+		name, next, err := readName(msg, off)
+		if err != nil {
+			return Message{}, err
+		}
+		if next+10 > len(msg) {
+			return Message{}, errors.New("answer truncated")
+		}
+		rrType := binary.BigEndian.Uint16(msg[next:])
+		ttl := binary.BigEndian.Uint32(msg[next+4:])
+		length := int(binary.BigEndian.Uint16(msg[next+8:]))
+		start := next + 10
+		if start+length > len(msg) {
+			return Message{}, errors.New("record data truncated")
+		}
+		off = start + length
+
+		record := Record{Type: TypeName(rrType), Name: name, TTL: ttl}
+		switch rrType {
+		case TypeA, TypeAAAA:
+			record.Address = net.IP(msg[start:off]).String()
+		case TypeSRV:
+			if length < 7 {
+				return Message{}, errors.New("SRV record too short")
+			}
+			record.Priority = binary.BigEndian.Uint16(msg[start:])
+			record.Weight = binary.BigEndian.Uint16(msg[start+2:])
+			record.Port = binary.BigEndian.Uint16(msg[start+4:])
+			if record.Target, _, err = readName(msg, start+6); err != nil {
+				return Message{}, err
+			}
+		default:
+			continue
+		}
+		m.Answers = append(m.Answers, record)
+	}
+	return m, nil
+}
+
+func appendRecord(msg []byte, r Record) ([]byte, error) {
+	rrType, ok := ParseType(r.Type)
+	if !ok {
+		return nil, fmt.Errorf("cannot encode %s records", r.Type)
+	}
+
+	var data []byte
+	switch rrType {
+	case TypeA:
+		data = net.ParseIP(r.Address).To4()
+	case TypeAAAA:
+		data = net.ParseIP(r.Address).To16()
+	case TypeSRV:
+		data = appendUint16(data, r.Priority)
+		data = appendUint16(data, r.Weight)
+		data = appendUint16(data, r.Port)
+		var err error
+		if data, err = appendName(data, r.Target); err != nil {
+			return nil, err
+		}
+	}
+	if data == nil {
+		return nil, fmt.Errorf("invalid address %q for %s record", r.Address, r.Type)
+	}
+
+	msg, err := appendName(msg, r.Name)
+	if err != nil {
+		return nil, err
+	}
+	msg = appendUint16(msg, rrType)
+	msg = appendUint16(msg, classINET)
+	msg = appendUint32(msg, r.TTL)
+	msg = appendUint16(msg, uint16(len(data)))
+	return append(msg, data...), nil
+}
+
+func appendUint16(msg []byte, v uint16) []byte {
+	var buf [2]byte
+	binary.BigEndian.PutUint16(buf[:], v)
+	return append(msg, buf[:]...)
+}
+
+func appendUint32(msg []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], v)
+	return append(msg, buf[:]...)
+}
+
+func appendName(msg []byte, name string) ([]byte, error) {
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if label == "" {
+			continue
+		}
+		if len(label) > 63 {
+			return nil, fmt.Errorf("label %q is longer than 63 bytes", label)
+		}
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	return append(msg, 0), nil
+}
+
+// readName reads the possibly compressed name at off, returning it fully
+// qualified along with the offset just past it.
+func readName(msg []byte, off int) (string, int, error) {
+	labels := []string{}
+	next := -1
+	for jumps := 0; ; {
+		if off >= len(msg) {
+			return "", 0, errors.New("name runs past the end of the message")
+		}
+		length := int(msg[off])
+		switch {
+		case length == 0:
+			if next < 0 {
+				next = off + 1
+			}
+			return strings.Join(labels, ".") + ".", next, nil
+		case length&0xc0 == 0xc0:
+			if off+1 >= len(msg) {
+				return "", 0, errors.New("compression pointer truncated")
+			}
+			if jumps++; jumps > 10 {
+				return "", 0, errors.New("too many compression pointers")
+			}
+			if next < 0 {
+				next = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3fff)
+		default:
+			if off+1+length > len(msg) {
+				return "", 0, errors.New("label truncated")
+			}
+			labels = append(labels, string(msg[off+1:off+1+length]))
+			off += 1 + length
+		}
+	}
+}
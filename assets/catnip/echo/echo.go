@@ -0,0 +1,71 @@
+package echo
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// ListenAndServe opens an echo listener for each port in the comma separated
+// list, so that non-HTTP traffic such as container-to-container policies can
+// be exercised. network is either "tcp" or "udp".
+func ListenAndServe(network, ports string, out io.Writer) error {
+	for _, port := range strings.Split(ports, ",") {
+		port = strings.TrimSpace(port)
+		if port == "" {
+			continue
+		}
+
+		address := fmt.Sprintf(":%s", port)
+		switch network {
+		case "tcp":
+			listener, err := net.Listen(network, address)
+			if err != nil {
+				return err
+			}
+			go ServeTCP(listener, out)
+		case "udp":
+			conn, err := net.ListenPacket(network, address)
+			if err != nil {
+				return err
+			}
+			go ServeUDP(conn, out)
+		default:
+			return fmt.Errorf("unsupported network %q", network)
+		}
+
+		fmt.Fprintf(out, "echoing %s on port %s...\n", network, port)
+	}
+	return nil
+}
+
+// ServeTCP writes back everything each connection sends until it closes.
+func ServeTCP(listener net.Listener, out io.Writer) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer conn.Close()
+			written, _ := io.Copy(conn, conn)
+			fmt.Fprintf(out, "echoed %d bytes over tcp to %s\n", written, conn.RemoteAddr())
+		}()
+	}
+}
+
+// ServeUDP sends each datagram back to where it came from.
+func ServeUDP(conn net.PacketConn, out io.Writer) {
+	buffer := make([]byte, 64*1024)
+	for {
+		n, addr, err := conn.ReadFrom(buffer)
+		if err != nil {
+			return
+		}
+
+		conn.WriteTo(buffer[:n], addr)
+		fmt.Fprintf(out, "echoed %d bytes over udp to %s\n", n, addr)
+	}
+}
@@ -0,0 +1,85 @@
+package echo_test
+
+import (
+	"io"
+	"net"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/echo"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Echo", func() {
+	var out *gbytes.Buffer
+
+	BeforeEach(func() {
+		out = gbytes.NewBuffer()
+	})
+
+	Describe("ServeTCP", func() {
+		It("echoes what each connection sends", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			defer listener.Close()
+			go echo.ServeTCP(listener, out)
+
+			conn, err := net.Dial("tcp", listener.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = io.WriteString(conn, "hello")
+			Expect(err).NotTo(HaveOccurred())
+
+			response := make([]byte, 5)
+			_, err = io.ReadFull(conn, response)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(response)).To(Equal("hello"))
+
+			conn.Close()
+			Eventually(out).Should(gbytes.Say("echoed 5 bytes over tcp"))
+		})
+	})
+
+	Describe("ServeUDP", func() {
+		It("sends each datagram back", func() {
+			listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			defer listener.Close()
+			go echo.ServeUDP(listener, out)
+
+			conn, err := net.Dial("udp", listener.LocalAddr().String())
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			_, err = io.WriteString(conn, "hello")
+			Expect(err).NotTo(HaveOccurred())
+
+			response := make([]byte, 64)
+			n, err := conn.Read(response)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(response[:n])).To(Equal("hello"))
+			Eventually(out).Should(gbytes.Say("echoed 5 bytes over udp"))
+		})
+	})
+
+	Describe("ListenAndServe", func() {
+		It("does nothing when there are no ports", func() {
+			Expect(echo.ListenAndServe("tcp", "", out)).To(Succeed())
+			Expect(out.Contents()).To(BeEmpty())
+		})
+
+		It("rejects unknown networks", func() {
+			Expect(echo.ListenAndServe("sctp", "9000", out)).To(MatchError(`unsupported network "sctp"`))
+		})
+
+		It("fails when a port is already in use", func() {
+			listener, err := net.Listen("tcp", ":0")
+			Expect(err).NotTo(HaveOccurred())
+			defer listener.Close()
+			_, port, err := net.SplitHostPort(listener.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(echo.ListenAndServe("tcp", port, out)).NotTo(Succeed())
+		})
+	})
+})
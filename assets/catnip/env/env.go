@@ -8,20 +8,35 @@ import (
 	"strings"
 
 	"github.com/gorilla/mux"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/httperr"
 )
 
+// NameHandler responds with the value of the environment variable, which may
+// be empty, or a 404 if it is not set at all.
 func NameHandler(res http.ResponseWriter, req *http.Request) {
-	io.WriteString(res, os.Getenv(mux.Vars(req)["name"]))
+	name := mux.Vars(req)["name"]
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		httperr.Write(res, http.StatusNotFound, "%s is not set", name)
+		return
+	}
+
+	io.WriteString(res, value)
 }
 
 func JSONHandler(res http.ResponseWriter, req *http.Request) {
 	envMap := make(map[string]string)
 	for _, e := range os.Environ() {
-		kv := strings.Split(e, "=")
+		kv := strings.SplitN(e, "=", 2)
 		envMap[kv[0]] = kv[1]
 	}
 
-	envJSON, _ := json.Marshal(envMap)
+	envJSON, err := json.Marshal(envMap)
+	if err != nil {
+		httperr.Write(res, http.StatusInternalServerError, "%s", err)
+		return
+	}
 
 	res.Header().Add("Content-Type", "application/json")
 	res.Write(envJSON)
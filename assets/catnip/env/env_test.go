@@ -46,6 +46,15 @@ var _ = Describe("Env", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(bodyBuf.String()).To(Equal("Jellybean"))
 		})
+
+		It("returns 404 for variables that are not set", func() {
+			res, err := http.Get(fmt.Sprintf("%s/env/CATNIP_UNSET", server.URL))
+			Expect(err).NotTo(HaveOccurred())
+			defer res.Body.Close()
+
+			Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+			Expect(res.Header.Get("Content-Type")).To(Equal("application/json"))
+		})
 	})
 
 	Describe("JsonHandler", func() {
@@ -64,6 +73,19 @@ var _ = Describe("Env", func() {
 
 			Expect(envMap["CATNIP_ENVTEST"]).To(Equal("Jellybean"))
 		})
+
+		It("keeps values that contain an equals sign", func() {
+			os.Setenv("CATNIP_ENVTEST", "dXNlcjpwYXNz=")
+			defer os.Setenv("CATNIP_ENVTEST", "Jellybean")
+
+			res, err := http.Get(fmt.Sprintf("%s/env.json", server.URL))
+			Expect(err).NotTo(HaveOccurred())
+			defer res.Body.Close()
+
+			envMap := make(map[string]string)
+			Expect(json.NewDecoder(res.Body).Decode(&envMap)).To(Succeed())
+			Expect(envMap["CATNIP_ENVTEST"]).To(Equal("dXNlcjpwYXNz="))
+		})
 	})
 
 	Describe("InstanceIdHandler", func() {
@@ -0,0 +1,167 @@
+package env
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/httperr"
+)
+
+// CredHubRefKey is the only key in a binding's credentials when they are
+// stored in CredHub and have not been interpolated into the environment.
+const CredHubRefKey = "credhub-ref"
+
+// Service is one entry of VCAP_SERVICES. Name is the binding name if one was
+// given, otherwise the instance name.
+type Service struct {
+	Name           string                 `json:"name"`
+	InstanceName   string                 `json:"instance_name"`
+	BindingName    *string                `json:"binding_name"`
+	Label          string                 `json:"label"`
+	Provider       *string                `json:"provider"`
+	Plan           string                 `json:"plan"`
+	Tags           []string               `json:"tags"`
+	Credentials    map[string]interface{} `json:"credentials"`
+	SyslogDrainURL *string                `json:"syslog_drain_url"`
+	VolumeMounts   []VolumeMount          `json:"volume_mounts"`
+
+	// Set by catnip rather than the platform.
+	CredHubRef          string `json:"credhub_ref,omitempty"`
+	CredentialsResolved bool   `json:"credentials_resolved"`
+}
+
+type VolumeMount struct {
+	ContainerDir string `json:"container_dir"`
+	Mode         string `json:"mode"`
+	DeviceType   string `json:"device_type"`
+}
+
+type Limits struct {
+	Disk int `json:"disk"`
+	FDs  int `json:"fds"`
+	Mem  int `json:"mem"`
+}
+
+type Application struct {
+	ApplicationId      string   `json:"application_id"`
+	ApplicationName    string   `json:"application_name"`
+	ApplicationURIs    []string `json:"application_uris"`
+	ApplicationVersion string   `json:"application_version"`
+	CFAPI              string   `json:"cf_api"`
+	Limits             Limits   `json:"limits"`
+	Name               string   `json:"name"`
+	OrganizationId     string   `json:"organization_id"`
+	OrganizationName   string   `json:"organization_name"`
+	ProcessId          string   `json:"process_id"`
+	ProcessType        string   `json:"process_type"`
+	SpaceId            string   `json:"space_id"`
+	SpaceName          string   `json:"space_name"`
+	URIs               []string `json:"uris"`
+	Version            string   `json:"version"`
+}
+
+// Services parses VCAP_SERVICES into bindings keyed by service label. An
+// unset VCAP_SERVICES means there are no bindings.
+func Services() (map[string][]Service, error) {
+	services := map[string][]Service{}
+	raw := os.Getenv("VCAP_SERVICES")
+	if raw == "" {
+		return services, nil
+	}
+
+	if err := json.Unmarshal([]byte(raw), &services); err != nil {
+		return nil, fmt.Errorf("invalid VCAP_SERVICES: %s", err)
+	}
+
+	for _, bindings := range services {
+		for i := range bindings {
+			ref, isRef := bindings[i].Credentials[CredHubRefKey].(string)
+			if isRef && len(bindings[i].Credentials) == 1 {
+				bindings[i].CredHubRef = ref
+			} else {
+				bindings[i].CredentialsResolved = true
+			}
+		}
+	}
+	return services, nil
+}
+
+func ApplicationEnv() (Application, error) {
+	var application Application
+	if err := json.Unmarshal([]byte(os.Getenv("VCAP_APPLICATION")), &application); err != nil {
+		return Application{}, fmt.Errorf("invalid VCAP_APPLICATION: %s", err)
+	}
+	return application, nil
+}
+
+func ServicesHandler(res http.ResponseWriter, req *http.Request) {
+	services, err := Services()
+	if err != nil {
+		httperr.Write(res, http.StatusInternalServerError, "%s", err)
+		return
+	}
+
+	httperr.WriteJSON(res, services)
+}
+
+func ServicesByLabelHandler(res http.ResponseWriter, req *http.Request) {
+	services, err := Services()
+	if err != nil {
+		httperr.Write(res, http.StatusInternalServerError, "%s", err)
+		return
+	}
+
+	label := mux.Vars(req)["label"]
+	bindings, ok := services[label]
+	if !ok {
+		httperr.Write(res, http.StatusNotFound, "No services with label %q", label)
+		return
+	}
+
+	httperr.WriteJSON(res, bindings)
+}
+
+func ServiceByInstanceNameHandler(res http.ResponseWriter, req *http.Request) {
+	findService(res, mux.Vars(req)["name"], "instance name", func(service Service, name string) bool {
+		return service.InstanceName == name
+	})
+}
+
+func ServiceByBindingNameHandler(res http.ResponseWriter, req *http.Request) {
+	findService(res, mux.Vars(req)["name"], "binding name", func(service Service, name string) bool {
+		return service.BindingName != nil && *service.BindingName == name
+	})
+}
+
+func ApplicationHandler(res http.ResponseWriter, req *http.Request) {
+	application, err := ApplicationEnv()
+	if err != nil {
+		httperr.Write(res, http.StatusInternalServerError, "%s", err)
+		return
+	}
+
+	httperr.WriteJSON(res, application)
+}
+
+func findService(res http.ResponseWriter, name, kind string, matches func(Service, string) bool) {
+	services, err := Services()
+	if err != nil {
+		httperr.Write(res, http.StatusInternalServerError, "%s", err)
+		return
+	}
+
+	for _, bindings := range services {
+		for _, service := range bindings {
+			if matches(service, name) {
+				httperr.WriteJSON(res, service)
+				return
+			}
+		}
+	}
+
+	httperr.Write(res, http.StatusNotFound, "No service with %s %q", kind, name)
+}
@@ -0,0 +1,215 @@
+package files
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/gorilla/mux"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/env"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/httperr"
+)
+
+const chunkSize = 1024 * 1024
+
+// File is what catnip reports about a file it wrote or read. FsyncMs is only
+// set for writes.
+type File struct {
+	Path    string   `json:"path"`
+	Bytes   int64    `json:"bytes"`
+	SHA256  string   `json:"sha256"`
+	FsyncMs *float64 `json:"fsync_ms,omitempty"`
+}
+
+type Entry struct {
+	Name  string `json:"name"`
+	Bytes int64  `json:"bytes"`
+	Dir   bool   `json:"dir"`
+}
+
+type Listing struct {
+	Path    string  `json:"path"`
+	Entries []Entry `json:"entries"`
+}
+
+// Store reads and writes files either under the container's temporary
+// directory or under a volume mounted for a bound service instance. Paths
+// in requests are relative to that root and cannot escape it.
+type Store struct {
+	clock   clock.Clock
+	tempDir string
+}
+
+func NewStore(clock clock.Clock, tempDir string) *Store {
+	return &Store{
+		clock:   clock,
+		tempDir: tempDir,
+	}
+}
+
+// WriteHandler writes the number of random bytes given by the bytes query
+// parameter and reports their hash and how long the fsync took.
+func (s *Store) WriteHandler(res http.ResponseWriter, req *http.Request) {
+	path, ok := s.resolve(res, req)
+	if !ok {
+		return
+	}
+
+	size, err := strconv.ParseInt(req.URL.Query().Get("bytes"), 10, 64)
+	if err != nil || size < 0 {
+		httperr.Write(res, http.StatusBadRequest, "bytes must be a non-negative integer")
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		fail(res, err)
+		return
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		fail(res, err)
+		return
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	written, err := io.CopyBuffer(io.MultiWriter(file, hash), io.LimitReader(rand.Reader, size), make([]byte, chunkSize))
+	if err != nil {
+		httperr.Write(res, http.StatusInsufficientStorage, "Wrote %d bytes to %s before error: %s", written, path, err)
+		return
+	}
+
+	start := s.clock.Now()
+	if err := file.Sync(); err != nil {
+		fail(res, err)
+		return
+	}
+	fsyncMs := float64(s.clock.Since(start)) / float64(time.Millisecond)
+
+	httperr.WriteJSON(res, File{
+		Path:    path,
+		Bytes:   written,
+		SHA256:  hex.EncodeToString(hash.Sum(nil)),
+		FsyncMs: &fsyncMs,
+	})
+}
+
+// ReadHandler reports the size and hash of a file, or lists a directory.
+func (s *Store) ReadHandler(res http.ResponseWriter, req *http.Request) {
+	path, ok := s.resolve(res, req)
+	if !ok {
+		return
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		fail(res, err)
+		return
+	}
+
+	if info.IsDir() {
+		infos, err := ioutil.ReadDir(path)
+		if err != nil {
+			fail(res, err)
+			return
+		}
+
+		listing := Listing{Path: path, Entries: []Entry{}}
+		for _, info := range infos {
+			listing.Entries = append(listing.Entries, Entry{
+				Name:  info.Name(),
+				Bytes: info.Size(),
+				Dir:   info.IsDir(),
+			})
+		}
+		httperr.WriteJSON(res, listing)
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		fail(res, err)
+		return
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	read, err := io.CopyBuffer(hash, file, make([]byte, chunkSize))
+	if err != nil {
+		fail(res, err)
+		return
+	}
+
+	httperr.WriteJSON(res, File{
+		Path:   path,
+		Bytes:  read,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+	})
+}
+
+func (s *Store) DeleteHandler(res http.ResponseWriter, req *http.Request) {
+	path, ok := s.resolve(res, req)
+	if !ok {
+		return
+	}
+
+	if err := os.Remove(path); err != nil {
+		fail(res, err)
+		return
+	}
+	io.WriteString(res, fmt.Sprintf("Removed %s", path))
+}
+
+// resolve maps the request onto a path under the temporary directory or,
+// when an instance is named, under that service's first volume mount.
+func (s *Store) resolve(res http.ResponseWriter, req *http.Request) (string, bool) {
+	root := s.tempDir
+	if instance, ok := mux.Vars(req)["instance"]; ok {
+		var err error
+		root, err = volumeDir(instance)
+		if err != nil {
+			httperr.Write(res, http.StatusNotFound, "%s", err)
+			return "", false
+		}
+	}
+
+	return filepath.Join(root, filepath.Clean("/"+mux.Vars(req)["path"])), true
+}
+
+func volumeDir(instance string) (string, error) {
+	services, err := env.Services()
+	if err != nil {
+		return "", err
+	}
+
+	for _, bindings := range services {
+		for _, service := range bindings {
+			if service.InstanceName != instance {
+				continue
+			}
+			if len(service.VolumeMounts) == 0 {
+				return "", fmt.Errorf("Service instance %q has no volume mounts", instance)
+			}
+			return service.VolumeMounts[0].ContainerDir, nil
+		}
+	}
+	return "", fmt.Errorf("No service instance %q is bound", instance)
+}
+
+func fail(res http.ResponseWriter, err error) {
+	if os.IsNotExist(err) {
+		httperr.Write(res, http.StatusNotFound, "%s", err)
+	} else {
+		httperr.Write(res, http.StatusInternalServerError, "%s", err)
+	}
+}
@@ -0,0 +1,148 @@
+package files_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/files"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/router"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Files", func() {
+	var (
+		server    *httptest.Server
+		tempDir   string
+		volumeDir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "catnip-files-tmp")
+		Expect(err).NotTo(HaveOccurred())
+		volumeDir, err = ioutil.TempDir("", "catnip-files-volume")
+		Expect(err).NotTo(HaveOccurred())
+
+		os.Setenv("TMPDIR", tempDir)
+		os.Setenv("VCAP_SERVICES", fmt.Sprintf(`{"nfs":[
+			{"instance_name":"my-volume","volume_mounts":[{"container_dir":%q,"mode":"rw","device_type":"shared"}]},
+			{"instance_name":"no-mounts"}
+		]}`, volumeDir))
+		server = httptest.NewServer(router.New(os.Stdout, clock.NewClock()))
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.Unsetenv("TMPDIR")
+		os.Unsetenv("VCAP_SERVICES")
+		os.RemoveAll(tempDir)
+		os.RemoveAll(volumeDir)
+	})
+
+	do := func(method, path string) *http.Response {
+		req, err := http.NewRequest(method, server.URL+path, nil)
+		Expect(err).NotTo(HaveOccurred())
+		res, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		return res
+	}
+
+	decode := func(res *http.Response, value interface{}) {
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		Expect(res.Header.Get("Content-Type")).To(Equal("application/json"))
+		Expect(json.NewDecoder(res.Body).Decode(value)).To(Succeed())
+	}
+
+	Describe("WriteHandler", func() {
+		It("writes random bytes to the temporary directory and reports their hash", func() {
+			var written files.File
+			decode(do(http.MethodPut, "/files/tmp/some/dir/data?bytes=3000000"), &written)
+
+			Expect(written.Path).To(Equal(filepath.Join(tempDir, "some/dir/data")))
+			Expect(written.Bytes).To(Equal(int64(3000000)))
+			Expect(written.FsyncMs).NotTo(BeNil())
+
+			contents, err := ioutil.ReadFile(written.Path)
+			Expect(err).NotTo(HaveOccurred())
+			sum := sha256.Sum256(contents)
+			Expect(written.SHA256).To(Equal(hex.EncodeToString(sum[:])))
+		})
+
+		It("writes to the volume mounted for a service instance", func() {
+			var written files.File
+			decode(do(http.MethodPut, "/files/volume/my-volume/data?bytes=10"), &written)
+
+			Expect(written.Path).To(Equal(filepath.Join(volumeDir, "data")))
+			Expect(filepath.Join(volumeDir, "data")).To(BeAnExistingFile())
+		})
+
+		It("rejects a missing size", func() {
+			res := do(http.MethodPut, "/files/tmp/data")
+			res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+
+		It("returns a 404 for instances that are not bound or have no volume", func() {
+			res := do(http.MethodPut, "/files/volume/other/data?bytes=10")
+			res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+
+			res = do(http.MethodPut, "/files/volume/no-mounts/data?bytes=10")
+			res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Describe("ReadHandler", func() {
+		It("reads back what was written", func() {
+			var written, read files.File
+			decode(do(http.MethodPut, "/files/volume/my-volume/data?bytes=2048"), &written)
+			decode(do(http.MethodGet, "/files/volume/my-volume/data"), &read)
+
+			Expect(read.Bytes).To(Equal(written.Bytes))
+			Expect(read.SHA256).To(Equal(written.SHA256))
+			Expect(read.FsyncMs).To(BeNil())
+		})
+
+		It("lists directories", func() {
+			decode(do(http.MethodPut, "/files/tmp/a?bytes=5"), &files.File{})
+			decode(do(http.MethodPut, "/files/tmp/dir/b?bytes=0"), &files.File{})
+
+			var listing files.Listing
+			decode(do(http.MethodGet, "/files/tmp/"), &listing)
+
+			Expect(listing.Path).To(Equal(tempDir))
+			Expect(listing.Entries).To(ConsistOf(
+				files.Entry{Name: "a", Bytes: 5},
+				files.Entry{Name: "dir", Bytes: listing.Entries[1].Bytes, Dir: true},
+			))
+		})
+
+		It("returns a 404 for missing files", func() {
+			res := do(http.MethodGet, "/files/tmp/missing")
+			res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Describe("DeleteHandler", func() {
+		It("removes the file", func() {
+			decode(do(http.MethodPut, "/files/tmp/data?bytes=5"), &files.File{})
+
+			res := do(http.MethodDelete, "/files/tmp/data")
+			res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+			Expect(filepath.Join(tempDir, "data")).NotTo(BeAnExistingFile())
+		})
+	})
+})
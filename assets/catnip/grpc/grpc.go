@@ -0,0 +1,184 @@
+package grpc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/httperr"
+)
+
+// Status codes from https://github.com/grpc/grpc/blob/master/doc/statuscodes.md
+const (
+	OK              = 0
+	InvalidArgument = 3
+	Unimplemented   = 12
+	Internal        = 13
+)
+
+// Health serving statuses from grpc.health.v1.
+const (
+	Serving        = 1
+	ServiceUnknown = 3
+)
+
+const maxMessageSize = 4 * 1024 * 1024
+
+// Server implements the catnip.Echo and grpc.health.v1.Health services
+// described in catnip.proto. Messages are framed and encoded by hand so that
+// catnip does not need the grpc and protobuf libraries.
+type Server struct {
+	clock clock.Clock
+}
+
+func NewServer(clock clock.Clock) *Server {
+	return &Server{clock: clock}
+}
+
+func (s *Server) HealthCheckHandler(res http.ResponseWriter, req *http.Request) {
+	fields, ok := readRequest(res, req)
+	if !ok {
+		return
+	}
+
+	status := ServiceUnknown
+	switch fields.String(1) {
+	case "", "catnip.Echo":
+		status = Serving
+	}
+
+	writeMessage(res, AppendVarint(nil, 1, uint64(status)))
+	finish(res, OK, "")
+}
+
+func (s *Server) EchoHandler(res http.ResponseWriter, req *http.Request) {
+	fields, ok := readRequest(res, req)
+	if !ok {
+		return
+	}
+
+	writeMessage(res, echoResponse(fields.String(1), 1, req.Proto))
+	finish(res, OK, "")
+}
+
+func (s *Server) EchoStreamHandler(res http.ResponseWriter, req *http.Request) {
+	fields, ok := readRequest(res, req)
+	if !ok {
+		return
+	}
+
+	count, interval := int(fields.Int(2)), int(fields.Int(3))
+	if count < 0 || interval < 0 {
+		finish(res, InvalidArgument, "count and interval_ms must not be negative")
+		return
+	}
+
+	for i := 1; i <= count; i++ {
+		if i > 1 && interval > 0 {
+			timer := s.clock.NewTimer(time.Duration(interval) * time.Millisecond)
+			select {
+			case <-timer.C():
+			case <-req.Context().Done():
+				timer.Stop()
+				return
+			}
+		}
+
+		writeMessage(res, echoResponse(fields.String(1), i, req.Proto))
+		if flusher, ok := res.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+	finish(res, OK, "")
+}
+
+// readRequest checks that this is a gRPC call and decodes its single request
+// message. Once it has been called the response headers have been sent, so
+// failures are reported in the trailers.
+func readRequest(res http.ResponseWriter, req *http.Request) (Fields, bool) {
+	if !strings.HasPrefix(req.Header.Get("Content-Type"), "application/grpc") {
+		httperr.Write(res, http.StatusUnsupportedMediaType, "Content-Type must be application/grpc")
+		return nil, false
+	}
+
+	res.Header().Set("Content-Type", "application/grpc")
+	res.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	res.WriteHeader(http.StatusOK)
+
+	message, err := ReadMessage(req.Body)
+	if err == nil {
+		// Unary and server-streaming calls send exactly one message.
+		_, err = io.Copy(ioutil.Discard, req.Body)
+	}
+	if err != nil {
+		finish(res, Internal, fmt.Sprintf("failed to read request: %s", err))
+		return nil, false
+	}
+
+	fields, err := Decode(message)
+	if err != nil {
+		finish(res, InvalidArgument, fmt.Sprintf("invalid request message: %s", err))
+		return nil, false
+	}
+	return fields, true
+}
+
+func echoResponse(message string, sequence int, protocol string) []byte {
+	var response []byte
+	response = AppendString(response, 1, message)
+	response = AppendVarint(response, 2, uint64(sequence))
+	response = AppendString(response, 3, protocol)
+	return response
+}
+
+func writeMessage(res http.ResponseWriter, message []byte) {
+	res.Write(Frame(message))
+}
+
+func finish(res http.ResponseWriter, code int, message string) {
+	res.Header().Set("Grpc-Status", strconv.Itoa(code))
+	if message != "" {
+		res.Header().Set("Grpc-Message", message)
+	}
+}
+
+// Frame prefixes an uncompressed message with its gRPC length header.
+func Frame(message []byte) []byte {
+	frame := make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+	return append(frame, message...)
+}
+
+// ReadMessage reads one length-prefixed message. It returns io.EOF if the
+// stream ends cleanly before a message starts.
+func ReadMessage(r io.Reader) ([]byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, errors.New("truncated message header")
+		}
+		return nil, err
+	}
+	if header[0] != 0 {
+		return nil, errors.New("compressed messages are not supported")
+	}
+
+	length := binary.BigEndian.Uint32(header[1:])
+	if length > maxMessageSize {
+		return nil, fmt.Errorf("message of %d bytes is too large", length)
+	}
+
+	message := make([]byte, length)
+	if _, err := io.ReadFull(r, message); err != nil {
+		return nil, errors.New("truncated message")
+	}
+	return message, nil
+}
@@ -0,0 +1,200 @@
+package grpc_test
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/grpc"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/router"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("gRPC", func() {
+	var (
+		fakeClock *fakeclock.FakeClock
+		server    *httptest.Server
+		client    *http.Client
+	)
+
+	BeforeEach(func() {
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		server = httptest.NewServer(h2c.NewHandler(router.New(os.Stdout, fakeClock), &http2.Server{}))
+
+		// Speak HTTP/2 with prior knowledge over plain TCP, as the gorouter
+		// does for routes with the http2 protocol.
+		client = &http.Client{Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		}}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	call := func(method string, request []byte) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, server.URL+method, bytes.NewReader(grpc.Frame(request)))
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Content-Type", "application/grpc")
+		req.Header.Set("TE", "trailers")
+
+		res, err := client.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.ProtoMajor).To(Equal(2))
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		Expect(res.Header.Get("Content-Type")).To(Equal("application/grpc"))
+		return res
+	}
+
+	readAll := func(res *http.Response) []grpc.Fields {
+		defer res.Body.Close()
+		responses := []grpc.Fields{}
+		for {
+			message, err := grpc.ReadMessage(res.Body)
+			if err == io.EOF {
+				return responses
+			}
+			Expect(err).NotTo(HaveOccurred())
+
+			fields, err := grpc.Decode(message)
+			Expect(err).NotTo(HaveOccurred())
+			responses = append(responses, fields)
+		}
+	}
+
+	Describe("Health/Check", func() {
+		It("reports catnip as serving", func() {
+			res := call("/grpc.health.v1.Health/Check", nil)
+			responses := readAll(res)
+
+			Expect(res.Trailer.Get("Grpc-Status")).To(Equal("0"))
+			Expect(responses).To(HaveLen(1))
+			Expect(responses[0].Int(1)).To(Equal(int64(grpc.Serving)))
+		})
+
+		It("reports unknown services", func() {
+			res := call("/grpc.health.v1.Health/Check", grpc.AppendString(nil, 1, "other.Service"))
+			responses := readAll(res)
+
+			Expect(responses[0].Int(1)).To(Equal(int64(grpc.ServiceUnknown)))
+		})
+	})
+
+	Describe("Echo/Echo", func() {
+		It("echoes the message and the protocol it arrived over", func() {
+			res := call("/catnip.Echo/Echo", grpc.AppendString(nil, 1, "hello"))
+			responses := readAll(res)
+
+			Expect(res.Trailer.Get("Grpc-Status")).To(Equal("0"))
+			Expect(responses).To(HaveLen(1))
+			Expect(responses[0].String(1)).To(Equal("hello"))
+			Expect(responses[0].Int(2)).To(Equal(int64(1)))
+			Expect(responses[0].String(3)).To(Equal("HTTP/2.0"))
+		})
+
+		It("fails with a status when the message is malformed", func() {
+			res := call("/catnip.Echo/Echo", []byte{0x0a, 0x05, 'h'})
+			Expect(readAll(res)).To(BeEmpty())
+
+			Expect(res.Trailer.Get("Grpc-Status")).To(Equal("3"))
+			Expect(res.Trailer.Get("Grpc-Message")).To(ContainSubstring("invalid length in field 1"))
+		})
+
+		It("rejects requests that are not gRPC", func() {
+			res, err := client.Post(server.URL+"/catnip.Echo/Echo", "application/json", bytes.NewReader(nil))
+			Expect(err).NotTo(HaveOccurred())
+			res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusUnsupportedMediaType))
+		})
+	})
+
+	Describe("Echo/EchoStream", func() {
+		It("streams the requested number of responses at the given interval", func() {
+			request := grpc.AppendString(nil, 1, "hello")
+			request = grpc.AppendVarint(request, 2, 3)
+			request = grpc.AppendVarint(request, 3, 1000)
+			res := call("/catnip.Echo/EchoStream", request)
+			defer res.Body.Close()
+
+			sequences := make(chan int64, 3)
+			go func() {
+				for {
+					message, err := grpc.ReadMessage(res.Body)
+					if err != nil {
+						close(sequences)
+						return
+					}
+					fields, _ := grpc.Decode(message)
+					sequences <- fields.Int(2)
+				}
+			}()
+
+			Eventually(sequences).Should(Receive(Equal(int64(1))))
+			Consistently(sequences).ShouldNot(Receive())
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			fakeClock.Increment(time.Second)
+			Eventually(sequences).Should(Receive(Equal(int64(2))))
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			fakeClock.Increment(time.Second)
+			Eventually(sequences).Should(Receive(Equal(int64(3))))
+
+			Eventually(sequences).Should(BeClosed())
+			Expect(res.Trailer.Get("Grpc-Status")).To(Equal("0"))
+		})
+	})
+
+	It("still serves HTTP/1.1 clients", func() {
+		res, err := http.Get(server.URL + "/")
+		Expect(err).NotTo(HaveOccurred())
+		defer res.Body.Close()
+
+		Expect(res.ProtoMajor).To(Equal(1))
+		Expect(ioutil.ReadAll(res.Body)).To(Equal([]byte("Catnip?")))
+	})
+})
+
+var _ = Describe("Wire format", func() {
+	It("round-trips strings and varints", func() {
+		message := grpc.AppendString(nil, 1, "catnip")
+		message = grpc.AppendVarint(message, 2, 300)
+
+		fields, err := grpc.Decode(message)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields.String(1)).To(Equal("catnip"))
+		Expect(fields.Int(2)).To(Equal(int64(300)))
+		Expect(fields.String(3)).To(BeEmpty())
+	})
+
+	It("skips fixed-width fields", func() {
+		message := []byte{0x09, 1, 2, 3, 4, 5, 6, 7, 8, 0x15, 1, 2, 3, 4}
+		message = grpc.AppendVarint(message, 3, 7)
+
+		fields, err := grpc.Decode(message)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields.Int(3)).To(Equal(int64(7)))
+	})
+
+	It("rejects compressed frames", func() {
+		frame := grpc.Frame([]byte("x"))
+		frame[0] = 1
+
+		_, err := grpc.ReadMessage(bytes.NewReader(frame))
+		Expect(err).To(MatchError("compressed messages are not supported"))
+	})
+})
@@ -0,0 +1,101 @@
+package grpc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	wireVarint          = 0
+	wireFixed64         = 1
+	wireLengthDelimited = 2
+	wireFixed32         = 5
+)
+
+// Fields holds the last value seen for each field number of a decoded
+// protobuf message. Varints are kept as uint64 and length-delimited fields
+// as []byte; fixed-width fields are skipped since catnip's messages have
+// none.
+type Fields map[int]interface{}
+
+func (f Fields) String(number int) string {
+	value, _ := f[number].([]byte)
+	return string(value)
+}
+
+func (f Fields) Int(number int) int64 {
+	value, _ := f[number].(uint64)
+	return int64(value)
+}
+
+// Decode parses the protobuf wire format well enough for the scalar and
+// string fields used by catnip's services.
+func Decode(message []byte) (Fields, error) {
+	fields := Fields{}
+	for len(message) > 0 {
+		key, n := binary.Uvarint(message)
+		if n <= 0 {
+			return nil, errors.New("invalid field key")
+		}
+		message = message[n:]
+		number := int(key >> 3)
+
+		switch key & 7 {
+		case wireVarint:
+			value, n := binary.Uvarint(message)
+			if n <= 0 {
+				return nil, fmt.Errorf("invalid varint in field %d", number)
+			}
+			fields[number] = value
+			message = message[n:]
+		case wireLengthDelimited:
+			length, n := binary.Uvarint(message)
+			if n <= 0 || uint64(len(message)-n) < length {
+				return nil, fmt.Errorf("invalid length in field %d", number)
+			}
+			fields[number] = message[n : n+int(length)]
+			message = message[n+int(length):]
+		case wireFixed64:
+			if len(message) < 8 {
+				return nil, fmt.Errorf("truncated field %d", number)
+			}
+			message = message[8:]
+		case wireFixed32:
+			if len(message) < 4 {
+				return nil, fmt.Errorf("truncated field %d", number)
+			}
+			message = message[4:]
+		default:
+			return nil, fmt.Errorf("unsupported wire type %d in field %d", key&7, number)
+		}
+	}
+	return fields, nil
+}
+
+// AppendVarint encodes an integer field. Zero values are omitted, as proto3
+// does.
+func AppendVarint(message []byte, number int, value uint64) []byte {
+	if value == 0 {
+		return message
+	}
+	message = appendUvarint(message, uint64(number)<<3|wireVarint)
+	return appendUvarint(message, value)
+}
+
+// AppendString encodes a string field. Empty strings are omitted, as proto3
+// does.
+func AppendString(message []byte, number int, value string) []byte {
+	if value == "" {
+		return message
+	}
+	message = appendUvarint(message, uint64(number)<<3|wireLengthDelimited)
+	message = appendUvarint(message, uint64(len(value)))
+	return append(message, value...)
+}
+
+func appendUvarint(message []byte, value uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], value)
+	return append(message, buf[:n]...)
+}
@@ -1,20 +1,129 @@
 package health
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/httperr"
 )
 
-var callCount = 0
+const (
+	Healthy   = "healthy"
+	Unhealthy = "unhealthy"
+	Slow      = "slow"
+	Hanging   = "hanging"
+)
+
+// State is what /health/admin accepts and reports. Calls limits how many
+// /health requests the state applies to before reverting to healthy; zero
+// means until the state is changed again.
+type State struct {
+	State   string `json:"state"`
+	DelayMs int    `json:"delay_ms,omitempty"`
+	Calls   int    `json:"calls,omitempty"`
+}
 
-func HealthHander(res http.ResponseWriter, req *http.Request) {
-	if callCount < 3 {
-		callCount++
+// Checker holds the health of this catnip instance. It starts unhealthy for
+// the first three calls so that http health checks see a failing app before
+// it becomes ready.
+type Checker struct {
+	clock clock.Clock
+
+	mutex     sync.Mutex
+	state     State
+	callCount int
+	changed   chan struct{}
+}
+
+func NewChecker(clock clock.Clock) *Checker {
+	return &Checker{
+		clock:   clock,
+		state:   State{State: Unhealthy, Calls: 3},
+		changed: make(chan struct{}),
+	}
+}
+
+func (c *Checker) HealthHandler(res http.ResponseWriter, req *http.Request) {
+	state, callCount, changed := c.nextCall()
+
+	switch state.State {
+	case Unhealthy:
 		res.WriteHeader(http.StatusInternalServerError)
 		io.WriteString(res, fmt.Sprintf("Hit /health %d times", callCount))
+	case Slow:
+		c.clock.Sleep(time.Duration(state.DelayMs) * time.Millisecond)
+		io.WriteString(res, "I'm alive")
+	case Hanging:
+		select {
+		case <-changed:
+		case <-req.Context().Done():
+		}
+		res.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(res, "I was hanging")
+	default:
+		io.WriteString(res, "I'm alive")
+	}
+}
+
+func (c *Checker) GetAdminHandler(res http.ResponseWriter, req *http.Request) {
+	c.mutex.Lock()
+	state := c.state
+	c.mutex.Unlock()
+
+	res.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(res).Encode(state)
+}
+
+func (c *Checker) SetAdminHandler(res http.ResponseWriter, req *http.Request) {
+	var state State
+	if err := json.NewDecoder(req.Body).Decode(&state); err != nil {
+		httperr.Write(res, http.StatusBadRequest, "Invalid health state: %s", err)
+		return
+	}
+
+	switch state.State {
+	case Healthy, Unhealthy, Slow, Hanging:
+	default:
+		httperr.Write(res, http.StatusBadRequest, "Unknown health state %q", state.State)
 		return
 	}
 
-	io.WriteString(res, "I'm alive")
+	if state.DelayMs < 0 || state.Calls < 0 {
+		httperr.Write(res, http.StatusBadRequest, "delay_ms and calls must not be negative")
+		return
+	}
+
+	c.set(state)
+
+	res.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(res).Encode(state)
+}
+
+func (c *Checker) set(state State) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.state = state
+	c.callCount = 0
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+func (c *Checker) nextCall() (State, int, chan struct{}) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.state.Calls > 0 && c.callCount >= c.state.Calls {
+		c.state = State{State: Healthy}
+		c.callCount = 0
+	}
+	c.callCount++
+
+	return c.state, c.callCount, c.changed
 }
@@ -6,8 +6,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
+	"time"
 
-	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/clock/fakeclock"
 	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/router"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -15,16 +17,19 @@ import (
 
 var _ = Describe("Health", func() {
 	var (
-		server *httptest.Server
+		fakeClock *fakeclock.FakeClock
+		server    *httptest.Server
 	)
 
 	BeforeEach(func() {
-		server = httptest.NewServer(router.New(os.Stdout, clock.NewClock()))
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		server = httptest.NewServer(router.New(os.Stdout, fakeClock))
 	})
 
 	AfterEach(func() {
 		server.Close()
 	})
+
 	Describe("HealthHandler", func() {
 		It("returns a 500 and prints the number of times it's been hit three times, then gets healthy", func() {
 			callAndValidateHealth(server.URL, http.StatusInternalServerError, "Hit /health 1 times")
@@ -33,11 +38,104 @@ var _ = Describe("Health", func() {
 
 			callAndValidateHealth(server.URL, http.StatusOK, "I'm alive")
 		})
+
+		It("does not share state between instances", func() {
+			otherServer := httptest.NewServer(router.New(os.Stdout, fakeClock))
+			defer otherServer.Close()
+
+			setHealth(server.URL, `{"state":"healthy"}`)
+
+			callAndValidateHealth(server.URL, http.StatusOK, "I'm alive")
+			callAndValidateHealth(otherServer.URL, http.StatusInternalServerError, "Hit /health 1 times")
+		})
+	})
+
+	Describe("admin API", func() {
+		It("reports the current state", func() {
+			callAndValidate(server.URL+"/health/admin", http.StatusOK, `{"state":"unhealthy","calls":3}`+"\n")
+
+			setHealth(server.URL, `{"state":"slow","delay_ms":250}`)
+			callAndValidate(server.URL+"/health/admin", http.StatusOK, `{"state":"slow","delay_ms":250}`+"\n")
+		})
+
+		It("makes the app healthy", func() {
+			setHealth(server.URL, `{"state":"healthy"}`)
+
+			callAndValidateHealth(server.URL, http.StatusOK, "I'm alive")
+		})
+
+		It("makes the app unhealthy until told otherwise", func() {
+			setHealth(server.URL, `{"state":"unhealthy"}`)
+
+			for i := 1; i <= 5; i++ {
+				callAndValidateHealth(server.URL, http.StatusInternalServerError, fmt.Sprintf("Hit /health %d times", i))
+			}
+
+			setHealth(server.URL, `{"state":"healthy"}`)
+			callAndValidateHealth(server.URL, http.StatusOK, "I'm alive")
+		})
+
+		It("makes the app unhealthy for a number of calls", func() {
+			setHealth(server.URL, `{"state":"unhealthy","calls":2}`)
+
+			callAndValidateHealth(server.URL, http.StatusInternalServerError, "Hit /health 1 times")
+			callAndValidateHealth(server.URL, http.StatusInternalServerError, "Hit /health 2 times")
+			callAndValidateHealth(server.URL, http.StatusOK, "I'm alive")
+		})
+
+		It("delays responses when slow", func() {
+			setHealth(server.URL, `{"state":"slow","delay_ms":500}`)
+
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				callAndValidateHealth(server.URL, http.StatusOK, "I'm alive")
+				close(done)
+			}()
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			Consistently(done).ShouldNot(BeClosed())
+
+			fakeClock.Increment(500 * time.Millisecond)
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("hangs until the state changes", func() {
+			setHealth(server.URL, `{"state":"hanging"}`)
+
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				callAndValidateHealth(server.URL, http.StatusServiceUnavailable, "I was hanging")
+				close(done)
+			}()
+
+			Consistently(done).ShouldNot(BeClosed())
+
+			setHealth(server.URL, `{"state":"healthy"}`)
+			Eventually(done).Should(BeClosed())
+
+			callAndValidateHealth(server.URL, http.StatusOK, "I'm alive")
+		})
+
+		It("rejects unknown states", func() {
+			res := put(server.URL+"/health/admin", `{"state":"sleepy"}`)
+			Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+
+		It("rejects malformed requests", func() {
+			res := put(server.URL+"/health/admin", `not json`)
+			Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+		})
 	})
 })
 
 func callAndValidateHealth(serverUrl string, statusCode int, responseBody string) {
-	res, err := http.Get(fmt.Sprintf("%s/health", serverUrl))
+	callAndValidate(fmt.Sprintf("%s/health", serverUrl), statusCode, responseBody)
+}
+
+func callAndValidate(url string, statusCode int, responseBody string) {
+	res, err := http.Get(url)
 	Expect(err).NotTo(HaveOccurred())
 
 	Expect(res.StatusCode).To(Equal(statusCode))
@@ -48,3 +146,18 @@ func callAndValidateHealth(serverUrl string, statusCode int, responseBody string
 
 	Expect(bodyBuf.String()).To(Equal(responseBody))
 }
+
+func setHealth(serverUrl, state string) {
+	res := put(fmt.Sprintf("%s/health/admin", serverUrl), state)
+	Expect(res.StatusCode).To(Equal(http.StatusOK))
+}
+
+func put(url, body string) *http.Response {
+	req, err := http.NewRequest(http.MethodPut, url, strings.NewReader(body))
+	Expect(err).NotTo(HaveOccurred())
+
+	res, err := http.DefaultClient.Do(req)
+	Expect(err).NotTo(HaveOccurred())
+	res.Body.Close()
+	return res
+}
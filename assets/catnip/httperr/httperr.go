@@ -0,0 +1,38 @@
+package httperr
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Error is the body of every catnip error response, so that a failing spec
+// shows why catnip refused rather than an empty body.
+type Error struct {
+	Status int    `json:"status"`
+	Error  string `json:"error"`
+}
+
+// Write responds with the status and a JSON Error whose message is formatted
+// from format and args.
+func Write(res http.ResponseWriter, status int, format string, args ...interface{}) {
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(status)
+	json.NewEncoder(res).Encode(Error{
+		Status: status,
+		Error:  fmt.Sprintf(format, args...),
+	})
+}
+
+// WriteJSON responds with value encoded as JSON, or with an Error if it
+// cannot be encoded.
+func WriteJSON(res http.ResponseWriter, value interface{}) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		Write(res, http.StatusInternalServerError, "%s", err)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(body)
+}
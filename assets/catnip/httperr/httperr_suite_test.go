@@ -0,0 +1,13 @@
+package httperr_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestHttperr(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Httperr Suite")
+}
@@ -0,0 +1,44 @@
+package httperr_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/httperr"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Write", func() {
+	It("writes the status and a formatted JSON message", func() {
+		res := httptest.NewRecorder()
+
+		httperr.Write(res, http.StatusBadRequest, "%s must be positive", "rate")
+
+		Expect(res.Code).To(Equal(http.StatusBadRequest))
+		Expect(res.Header().Get("Content-Type")).To(Equal("application/json"))
+		Expect(res.Body.String()).To(MatchJSON(`{"status":400,"error":"rate must be positive"}`))
+	})
+})
+
+var _ = Describe("WriteJSON", func() {
+	It("writes the value as JSON", func() {
+		res := httptest.NewRecorder()
+
+		httperr.WriteJSON(res, map[string]int{"rate": 5})
+
+		Expect(res.Code).To(Equal(http.StatusOK))
+		Expect(res.Header().Get("Content-Type")).To(Equal("application/json"))
+		Expect(res.Body.String()).To(MatchJSON(`{"rate":5}`))
+	})
+
+	It("writes an error when the value cannot be encoded", func() {
+		res := httptest.NewRecorder()
+
+		httperr.WriteJSON(res, make(chan int))
+
+		Expect(res.Code).To(Equal(http.StatusInternalServerError))
+		Expect(res.Body.String()).To(ContainSubstring("unsupported type"))
+	})
+})
@@ -0,0 +1,216 @@
+package identity
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/httperr"
+)
+
+// Identity describes the instance identity certificate Diego puts at
+// CF_INSTANCE_CERT. Diego encodes the app, space and organization in the
+// subject's organizational units as "app:GUID", "space:GUID" and
+// "organization:GUID".
+type Identity struct {
+	Subject      string        `json:"subject"`
+	CommonName   string        `json:"common_name"`
+	AppGuid      string        `json:"app_guid"`
+	SpaceGuid    string        `json:"space_guid"`
+	OrgGuid      string        `json:"organization_guid"`
+	DNSNames     []string      `json:"dns_names"`
+	IPAddresses  []string      `json:"ip_addresses"`
+	URIs         []string      `json:"uris"`
+	SerialNumber string        `json:"serial_number"`
+	NotBefore    time.Time     `json:"not_before"`
+	NotAfter     time.Time     `json:"not_after"`
+	Chain        []Certificate `json:"chain"`
+	KeyMatches   bool          `json:"key_matches"`
+}
+
+// Certificate summarises one of the intermediate certificates that follow the
+// instance certificate in CF_INSTANCE_CERT.
+type Certificate struct {
+	Subject      string    `json:"subject"`
+	Issuer       string    `json:"issuer"`
+	SerialNumber string    `json:"serial_number"`
+	NotBefore    time.Time `json:"not_before"`
+	NotAfter     time.Time `json:"not_after"`
+}
+
+type Rotation struct {
+	At                   time.Time `json:"at"`
+	PreviousSerialNumber string    `json:"previous_serial_number"`
+	PreviousNotAfter     time.Time `json:"previous_not_after"`
+	SerialNumber         string    `json:"serial_number"`
+	NotAfter             time.Time `json:"not_after"`
+}
+
+// Watcher reads the instance identity credentials and, while watching,
+// records every time Diego replaces them.
+type Watcher struct {
+	clock    clock.Clock
+	certPath string
+	keyPath  string
+
+	mutex     sync.Mutex
+	serial    string
+	notAfter  time.Time
+	rotations []Rotation
+}
+
+func NewWatcher(clock clock.Clock, certPath, keyPath string) *Watcher {
+	return &Watcher{
+		clock:     clock,
+		certPath:  certPath,
+		keyPath:   keyPath,
+		rotations: []Rotation{},
+	}
+}
+
+// Watch checks the credentials every interval until stop is closed.
+func (w *Watcher) Watch(interval time.Duration, stop <-chan struct{}) {
+	w.check()
+
+	ticker := w.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+			w.check()
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (w *Watcher) IdentityHandler(res http.ResponseWriter, req *http.Request) {
+	if w.certPath == "" {
+		httperr.Write(res, http.StatusNotFound, "CF_INSTANCE_CERT is not set")
+		return
+	}
+
+	identity, err := w.Read()
+	if err != nil {
+		httperr.Write(res, http.StatusInternalServerError, "Failed to read instance identity: %s", err)
+		return
+	}
+
+	httperr.WriteJSON(res, identity)
+}
+
+func (w *Watcher) RotationsHandler(res http.ResponseWriter, req *http.Request) {
+	w.mutex.Lock()
+	rotations := append([]Rotation{}, w.rotations...)
+	w.mutex.Unlock()
+
+	httperr.WriteJSON(res, rotations)
+}
+
+func (w *Watcher) Read() (Identity, error) {
+	certPEM, err := ioutil.ReadFile(w.certPath)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	var certs []*x509.Certificate
+	for block, rest := pem.Decode(certPEM); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return Identity{}, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return Identity{}, errors.New("no certificates found")
+	}
+
+	identity := describe(certs[0])
+	for _, cert := range certs[1:] {
+		identity.Chain = append(identity.Chain, Certificate{
+			Subject:      cert.Subject.String(),
+			Issuer:       cert.Issuer.String(),
+			SerialNumber: cert.SerialNumber.String(),
+			NotBefore:    cert.NotBefore,
+			NotAfter:     cert.NotAfter,
+		})
+	}
+
+	if keyPEM, err := ioutil.ReadFile(w.keyPath); err == nil {
+		_, err := tls.X509KeyPair(certPEM, keyPEM)
+		identity.KeyMatches = err == nil
+	}
+
+	return identity, nil
+}
+
+func (w *Watcher) check() {
+	if w.certPath == "" {
+		return
+	}
+
+	identity, err := w.Read()
+	if err != nil {
+		return
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.serial != "" && w.serial != identity.SerialNumber {
+		w.rotations = append(w.rotations, Rotation{
+			At:                   w.clock.Now(),
+			PreviousSerialNumber: w.serial,
+			PreviousNotAfter:     w.notAfter,
+			SerialNumber:         identity.SerialNumber,
+			NotAfter:             identity.NotAfter,
+		})
+	}
+	w.serial = identity.SerialNumber
+	w.notAfter = identity.NotAfter
+}
+
+func describe(cert *x509.Certificate) Identity {
+	identity := Identity{
+		Subject:      cert.Subject.String(),
+		CommonName:   cert.Subject.CommonName,
+		DNSNames:     append([]string{}, cert.DNSNames...),
+		IPAddresses:  []string{},
+		URIs:         []string{},
+		SerialNumber: cert.SerialNumber.String(),
+		NotBefore:    cert.NotBefore,
+		NotAfter:     cert.NotAfter,
+		Chain:        []Certificate{},
+	}
+
+	for _, ou := range cert.Subject.OrganizationalUnit {
+		switch {
+		case strings.HasPrefix(ou, "app:"):
+			identity.AppGuid = strings.TrimPrefix(ou, "app:")
+		case strings.HasPrefix(ou, "space:"):
+			identity.SpaceGuid = strings.TrimPrefix(ou, "space:")
+		case strings.HasPrefix(ou, "organization:"):
+			identity.OrgGuid = strings.TrimPrefix(ou, "organization:")
+		}
+	}
+	for _, ip := range cert.IPAddresses {
+		identity.IPAddresses = append(identity.IPAddresses, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		identity.URIs = append(identity.URIs, uri.String())
+	}
+
+	return identity
+}
@@ -0,0 +1,201 @@
+package identity_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/identity"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/router"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Identity", func() {
+	var (
+		fakeClock *fakeclock.FakeClock
+		dir       string
+		certPath  string
+		keyPath   string
+		ca        *x509.Certificate
+		caKey     *ecdsa.PrivateKey
+		notAfter  time.Time
+	)
+
+	writeCredentials := func(serial int64) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject: pkix.Name{
+				CommonName:         "instance-guid",
+				OrganizationalUnit: []string{"organization:org-guid", "space:space-guid", "app:app-guid"},
+			},
+			DNSNames:    []string{"instance-guid"},
+			IPAddresses: []net.IP{net.ParseIP("10.255.0.1")},
+			NotBefore:   notAfter.Add(-24 * time.Hour),
+			NotAfter:    notAfter,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw})...)
+		Expect(ioutil.WriteFile(certPath, certPEM, 0600)).To(Succeed())
+
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		notAfter = time.Now().Add(24 * time.Hour).Truncate(time.Second).UTC()
+
+		var err error
+		dir, err = ioutil.TempDir("", "catnip-identity")
+		Expect(err).NotTo(HaveOccurred())
+		certPath = filepath.Join(dir, "instance.crt")
+		keyPath = filepath.Join(dir, "instance.key")
+
+		caKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		caTemplate := &x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: "instanceIdentityCA"},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign,
+		}
+		caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+		Expect(err).NotTo(HaveOccurred())
+		ca, err = x509.ParseCertificate(caDER)
+		Expect(err).NotTo(HaveOccurred())
+
+		writeCredentials(100)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("Read", func() {
+		It("describes the instance certificate and its chain", func() {
+			identity, err := identity.NewWatcher(fakeClock, certPath, keyPath).Read()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(identity.CommonName).To(Equal("instance-guid"))
+			Expect(identity.AppGuid).To(Equal("app-guid"))
+			Expect(identity.SpaceGuid).To(Equal("space-guid"))
+			Expect(identity.OrgGuid).To(Equal("org-guid"))
+			Expect(identity.DNSNames).To(Equal([]string{"instance-guid"}))
+			Expect(identity.IPAddresses).To(Equal([]string{"10.255.0.1"}))
+			Expect(identity.SerialNumber).To(Equal("100"))
+			Expect(identity.NotAfter).To(Equal(notAfter))
+			Expect(identity.NotBefore).To(Equal(notAfter.Add(-24 * time.Hour)))
+			Expect(identity.KeyMatches).To(BeTrue())
+
+			Expect(identity.Chain).To(HaveLen(1))
+			Expect(identity.Chain[0].Subject).To(Equal("CN=instanceIdentityCA"))
+			Expect(identity.Chain[0].Issuer).To(Equal("CN=instanceIdentityCA"))
+		})
+
+		It("reports a key that does not match the certificate", func() {
+			otherKeyPath := keyPath
+			keyPath = filepath.Join(dir, "other.key")
+			writeCredentials(101)
+
+			identity, err := identity.NewWatcher(fakeClock, certPath, otherKeyPath).Read()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(identity.KeyMatches).To(BeFalse())
+		})
+
+		It("fails when there is no certificate", func() {
+			Expect(ioutil.WriteFile(certPath, []byte("not a certificate"), 0600)).To(Succeed())
+
+			_, err := identity.NewWatcher(fakeClock, certPath, keyPath).Read()
+			Expect(err).To(MatchError("no certificates found"))
+		})
+	})
+
+	Describe("Watch", func() {
+		It("records rotations", func() {
+			watcher := identity.NewWatcher(fakeClock, certPath, keyPath)
+			stop := make(chan struct{})
+			defer close(stop)
+			go watcher.Watch(time.Minute, stop)
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			previousNotAfter := notAfter
+			notAfter = notAfter.Add(time.Hour)
+			writeCredentials(200)
+			fakeClock.Increment(time.Minute)
+
+			var rotations []identity.Rotation
+			Eventually(func() []identity.Rotation {
+				res := httptest.NewRecorder()
+				watcher.RotationsHandler(res, nil)
+
+				Expect(json.Unmarshal(res.Body.Bytes(), &rotations)).To(Succeed())
+				return rotations
+			}).Should(HaveLen(1))
+
+			Expect(rotations[0].At).To(BeTemporally("==", fakeClock.Now()))
+			Expect(rotations[0].PreviousSerialNumber).To(Equal("100"))
+			Expect(rotations[0].PreviousNotAfter).To(BeTemporally("==", previousNotAfter))
+			Expect(rotations[0].SerialNumber).To(Equal("200"))
+			Expect(rotations[0].NotAfter).To(BeTemporally("==", notAfter))
+		})
+	})
+
+	Describe("the identity endpoints", func() {
+		var server *httptest.Server
+
+		AfterEach(func() {
+			server.Close()
+			os.Unsetenv("CF_INSTANCE_CERT")
+			os.Unsetenv("CF_INSTANCE_KEY")
+		})
+
+		It("serves the identity as JSON", func() {
+			os.Setenv("CF_INSTANCE_CERT", certPath)
+			os.Setenv("CF_INSTANCE_KEY", keyPath)
+			server = httptest.NewServer(router.New(os.Stdout, fakeClock))
+
+			res, err := http.Get(server.URL + "/identity")
+			Expect(err).NotTo(HaveOccurred())
+			defer res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+
+			var identity identity.Identity
+			Expect(json.NewDecoder(res.Body).Decode(&identity)).To(Succeed())
+			Expect(identity.AppGuid).To(Equal("app-guid"))
+		})
+
+		It("returns 404 without instance identity credentials", func() {
+			server = httptest.NewServer(router.New(os.Stdout, fakeClock))
+
+			res, err := http.Get(server.URL + "/identity")
+			Expect(err).NotTo(HaveOccurred())
+			res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+		})
+	})
+})
@@ -1,28 +1,90 @@
 package linux
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
 	"os/exec"
+	"strconv"
+	"strings"
 	"syscall"
 
 	"github.com/gorilla/mux"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/httperr"
 )
 
+// ReleaseHandler reports the distribution in the format of `lsb_release --all`
+// without relying on that binary being in the rootfs.
 func ReleaseHandler(res http.ResponseWriter, req *http.Request) {
-	cmd := exec.Command("lsb_release", "--all")
-	outBytes, _ := cmd.Output()
+	file, err := os.Open("/etc/os-release")
+	if err != nil {
+		httperr.Write(res, http.StatusInternalServerError, "Failed to read /etc/os-release: %s", err)
+		return
+	}
+	defer file.Close()
+
+	io.WriteString(res, FormatRelease(ParseOSRelease(file)))
+}
 
-	res.Write(outBytes)
+// ParseOSRelease reads the KEY=value pairs of an os-release file, unquoting
+// the values.
+func ParseOSRelease(r io.Reader) map[string]string {
+	release := map[string]string{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		if value, err := strconv.Unquote(kv[1]); err == nil {
+			kv[1] = value
+		} else {
+			kv[1] = strings.Trim(kv[1], `'"`)
+		}
+		release[kv[0]] = kv[1]
+	}
+	return release
 }
 
+func FormatRelease(release map[string]string) string {
+	codename := release["VERSION_CODENAME"]
+	if codename == "" {
+		codename = release["UBUNTU_CODENAME"]
+	}
+
+	return fmt.Sprintf("Distributor ID:\t%s\nDescription:\t%s\nRelease:\t%s\nCodename:\t%s\n",
+		release["NAME"], release["PRETTY_NAME"], release["VERSION_ID"], codename)
+}
+
+// MyIPHandler responds with the source address of the default route. Newer
+// versions of ip append fields such as "uid 0" to the route, so the address
+// is found by its "src" label rather than its position.
 func MyIPHandler(res http.ResponseWriter, req *http.Request) {
-	cmd := exec.Command("bash", "-c", "ip route get 1 | awk '{print $NF;exit}'")
-	outBytes, _ := cmd.Output()
+	outBytes, err := exec.Command("ip", "route", "get", "1").Output()
+	if err != nil {
+		httperr.Write(res, http.StatusInternalServerError, "Failed to find the container IP: %s", err)
+		return
+	}
 
-	res.Write(outBytes)
+	fields := strings.Fields(string(outBytes))
+	for i := 0; i+1 < len(fields); i++ {
+		if fields[i] == "src" {
+			fmt.Fprintln(res, fields[i+1])
+			return
+		}
+	}
+	httperr.Write(res, http.StatusInternalServerError, "Failed to find the container IP in %q", outBytes)
 }
 
 func CurlHandler(res http.ResponseWriter, req *http.Request) {
@@ -31,6 +93,10 @@ func CurlHandler(res http.ResponseWriter, req *http.Request) {
 	if port == "" {
 		port = "80"
 	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		httperr.Write(res, http.StatusBadRequest, "port must be between 1 and 65535")
+		return
+	}
 
 	cmd := exec.Command("curl", "-m", "3", "-v", "-i", fmt.Sprintf("%s:%s", host, port))
 	outBuf := bytes.NewBuffer([]byte{})
@@ -43,6 +109,9 @@ func CurlHandler(res http.ResponseWriter, req *http.Request) {
 	exitCode := 0
 	if e, ok := err.(*exec.ExitError); ok {
 		exitCode = e.ProcessState.Sys().(syscall.WaitStatus).ExitStatus()
+	} else if err != nil {
+		httperr.Write(res, http.StatusInternalServerError, "Failed to run curl: %s", err)
+		return
 	}
 
 	curlOutput := struct {
@@ -55,7 +124,11 @@ func CurlHandler(res http.ResponseWriter, req *http.Request) {
 		ReturnCode: exitCode,
 	}
 
-	curlOutputJSON, _ := json.Marshal(curlOutput)
+	curlOutputJSON, err := json.Marshal(curlOutput)
+	if err != nil {
+		httperr.Write(res, http.StatusInternalServerError, "%s", err)
+		return
+	}
 
 	res.Header().Add("Content-Type", "application/json")
 	res.Write(curlOutputJSON)
@@ -0,0 +1,13 @@
+package linux_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestLinux(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Linux Suite")
+}
@@ -0,0 +1,68 @@
+package linux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/linux"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/router"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Release", func() {
+	const osRelease = `NAME="Ubuntu"
+VERSION="14.04.5 LTS, Trusty Tahr"
+ID=ubuntu
+# a comment
+PRETTY_NAME="Ubuntu 14.04.5 LTS"
+VERSION_ID="14.04"
+UBUNTU_CODENAME=trusty
+`
+
+	It("parses os-release files", func() {
+		Expect(linux.ParseOSRelease(strings.NewReader(osRelease))).To(Equal(map[string]string{
+			"NAME":            "Ubuntu",
+			"VERSION":         "14.04.5 LTS, Trusty Tahr",
+			"ID":              "ubuntu",
+			"PRETTY_NAME":     "Ubuntu 14.04.5 LTS",
+			"VERSION_ID":      "14.04",
+			"UBUNTU_CODENAME": "trusty",
+		}))
+	})
+
+	It("formats the release like lsb_release", func() {
+		release := linux.ParseOSRelease(strings.NewReader(osRelease))
+
+		Expect(linux.FormatRelease(release)).To(Equal("Distributor ID:\tUbuntu\nDescription:\tUbuntu 14.04.5 LTS\nRelease:\t14.04\nCodename:\ttrusty\n"))
+	})
+})
+
+var _ = Describe("Curl", func() {
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		server = httptest.NewServer(router.New(os.Stdout, fakeclock.NewFakeClock(time.Now())))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("rejects ports out of range before running curl", func() {
+		for _, port := range []string{"0", "65536", "http"} {
+			res, err := http.Get(server.URL + "/curl/example.com/" + port)
+			Expect(err).NotTo(HaveOccurred())
+			res.Body.Close()
+
+			Expect(res.StatusCode).To(Equal(http.StatusBadRequest), port)
+			Expect(res.Header.Get("Content-Type")).To(Equal("application/json"))
+		}
+	})
+})
@@ -0,0 +1,245 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/gorilla/mux"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/httperr"
+)
+
+const (
+	Stdout = "stdout"
+	Stderr = "stderr"
+)
+
+// Spec describes the logs a Run writes. Every entry carries the run id and a
+// sequence number so that readers can spot lost or reordered lines.
+//
+// Rate is in entries per second, with zero meaning as fast as possible. A run
+// stops after Count entries or DurationMs milliseconds, whichever comes
+// first; with neither it runs until stopped. Lines above one makes each entry
+// span that many lines, LineSize pads every line to at least that many bytes
+// (not counting the newline) and NonUTF8 adds bytes that are not valid UTF-8.
+type Spec struct {
+	Stream     string `json:"stream"`
+	Rate       int    `json:"rate"`
+	Count      int    `json:"count"`
+	DurationMs int    `json:"duration_ms"`
+	LineSize   int    `json:"line_size"`
+	Lines      int    `json:"lines"`
+	NonUTF8    bool   `json:"non_utf8"`
+}
+
+type Status struct {
+	Id   string `json:"id"`
+	Sent int    `json:"sent"`
+	Done bool   `json:"done"`
+}
+
+type Run struct {
+	id       string
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+
+	mutex sync.Mutex
+	sent  int
+}
+
+// Stop ends the run and waits for it to finish writing.
+func (r *Run) Stop() {
+	r.stopOnce.Do(func() { close(r.stop) })
+	<-r.done
+}
+
+func (r *Run) Status() Status {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	status := Status{Id: r.id, Sent: r.sent}
+	select {
+	case <-r.done:
+		status.Done = true
+	default:
+	}
+	return status
+}
+
+// Generator starts log runs and keeps track of them so that they can be
+// inspected and stopped.
+type Generator struct {
+	clock  clock.Clock
+	stdout io.Writer
+	stderr io.Writer
+
+	mutex  sync.Mutex
+	runs   map[string]*Run
+	nextId int
+}
+
+func NewGenerator(clock clock.Clock, stdout, stderr io.Writer) *Generator {
+	return &Generator{
+		clock:  clock,
+		stdout: stdout,
+		stderr: stderr,
+		runs:   map[string]*Run{},
+	}
+}
+
+func (g *Generator) Start(spec Spec) (*Run, error) {
+	var out io.Writer
+	switch spec.Stream {
+	case Stdout, "":
+		out = g.stdout
+	case Stderr:
+		out = g.stderr
+	default:
+		return nil, fmt.Errorf("stream must be %s or %s", Stdout, Stderr)
+	}
+
+	if spec.Rate < 0 || spec.Count < 0 || spec.DurationMs < 0 || spec.LineSize < 0 || spec.Lines < 0 {
+		return nil, fmt.Errorf("rate, count, duration_ms, line_size and lines must not be negative")
+	}
+	if spec.Lines == 0 {
+		spec.Lines = 1
+	}
+
+	g.mutex.Lock()
+	g.nextId++
+	run := &Run{
+		id:   strconv.Itoa(g.nextId),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	g.runs[run.id] = run
+	g.mutex.Unlock()
+
+	go g.generate(run, spec, out)
+	return run, nil
+}
+
+func (g *Generator) Get(id string) (*Run, bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	run, ok := g.runs[id]
+	return run, ok
+}
+
+func (g *Generator) StartHandler(res http.ResponseWriter, req *http.Request) {
+	var spec Spec
+	if err := json.NewDecoder(req.Body).Decode(&spec); err != nil {
+		httperr.Write(res, http.StatusBadRequest, "Invalid log spec: %s", err)
+		return
+	}
+
+	run, err := g.Start(spec)
+	if err != nil {
+		httperr.Write(res, http.StatusBadRequest, "%s", err)
+		return
+	}
+
+	writeStatus(res, http.StatusAccepted, run.Status())
+}
+
+func (g *Generator) StatusHandler(res http.ResponseWriter, req *http.Request) {
+	run, ok := g.Get(mux.Vars(req)["id"])
+	if !ok {
+		httperr.Write(res, http.StatusNotFound, "No such log run")
+		return
+	}
+
+	writeStatus(res, http.StatusOK, run.Status())
+}
+
+func (g *Generator) StopHandler(res http.ResponseWriter, req *http.Request) {
+	run, ok := g.Get(mux.Vars(req)["id"])
+	if !ok {
+		httperr.Write(res, http.StatusNotFound, "No such log run")
+		return
+	}
+
+	run.Stop()
+	writeStatus(res, http.StatusOK, run.Status())
+}
+
+func (g *Generator) generate(run *Run, spec Spec, out io.Writer) {
+	defer close(run.done)
+
+	var deadline <-chan time.Time
+	if spec.DurationMs > 0 {
+		timer := g.clock.NewTimer(time.Duration(spec.DurationMs) * time.Millisecond)
+		defer timer.Stop()
+		deadline = timer.C()
+	}
+
+	var tick <-chan time.Time
+	if spec.Rate > 0 {
+		ticker := g.clock.NewTicker(time.Second / time.Duration(spec.Rate))
+		defer ticker.Stop()
+		tick = ticker.C()
+	}
+
+	for seq := 1; spec.Count == 0 || seq <= spec.Count; seq++ {
+		if tick != nil {
+			select {
+			case <-tick:
+			case <-run.stop:
+				return
+			case <-deadline:
+				return
+			}
+		} else {
+			select {
+			case <-run.stop:
+				return
+			case <-deadline:
+				return
+			default:
+			}
+		}
+
+		out.Write(entry(run.id, seq, spec))
+
+		run.mutex.Lock()
+		run.sent++
+		run.mutex.Unlock()
+	}
+}
+
+// entry is written in a single call so that the lines of a multi-line entry
+// are not interleaved with other output.
+func entry(id string, seq int, spec Spec) []byte {
+	var buf bytes.Buffer
+	for line := 1; line <= spec.Lines; line++ {
+		start := buf.Len()
+		fmt.Fprintf(&buf, "catnip-log run=%s seq=%d", id, seq)
+		if spec.Lines > 1 {
+			fmt.Fprintf(&buf, " line=%d/%d", line, spec.Lines)
+		}
+		if spec.NonUTF8 {
+			buf.Write([]byte{' ', 0xff, 0xfe})
+		}
+		if padding := spec.LineSize - (buf.Len() - start); padding > 0 {
+			buf.WriteString(" " + strings.Repeat("x", padding-1))
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+func writeStatus(res http.ResponseWriter, statusCode int, status Status) {
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(statusCode)
+	json.NewEncoder(res).Encode(status)
+}
@@ -0,0 +1,214 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/log"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/router"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// syncBuffer can be read while a run is writing to it.
+type syncBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.String()
+}
+
+func (b *syncBuffer) Lines() []string {
+	return strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+}
+
+var _ = Describe("Generator", func() {
+	var (
+		fakeClock *fakeclock.FakeClock
+		stdout    *syncBuffer
+		stderr    *syncBuffer
+		generator *log.Generator
+	)
+
+	BeforeEach(func() {
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		stdout = &syncBuffer{}
+		stderr = &syncBuffer{}
+		generator = log.NewGenerator(fakeClock, stdout, stderr)
+	})
+
+	waitForRun := func(run *log.Run) {
+		Eventually(func() bool { return run.Status().Done }).Should(BeTrue())
+	}
+
+	It("writes the requested number of sequenced lines to stdout", func() {
+		run, err := generator.Start(log.Spec{Count: 3})
+		Expect(err).NotTo(HaveOccurred())
+		waitForRun(run)
+
+		Expect(stdout.Lines()).To(Equal([]string{
+			"catnip-log run=1 seq=1",
+			"catnip-log run=1 seq=2",
+			"catnip-log run=1 seq=3",
+		}))
+		Expect(stderr.String()).To(BeEmpty())
+		Expect(run.Status()).To(Equal(log.Status{Id: "1", Sent: 3, Done: true}))
+	})
+
+	It("writes to stderr", func() {
+		run, err := generator.Start(log.Spec{Stream: log.Stderr, Count: 1})
+		Expect(err).NotTo(HaveOccurred())
+		waitForRun(run)
+
+		Expect(stderr.Lines()).To(Equal([]string{"catnip-log run=1 seq=1"}))
+		Expect(stdout.String()).To(BeEmpty())
+	})
+
+	It("writes at the requested rate", func() {
+		run, err := generator.Start(log.Spec{Rate: 10, Count: 5})
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(fakeClock.WatcherCount).Should(Equal(1))
+		Consistently(stdout.String).Should(BeEmpty())
+
+		fakeClock.Increment(100 * time.Millisecond)
+		Eventually(stdout.Lines).Should(Equal([]string{"catnip-log run=1 seq=1"}))
+
+		for i := 2; i <= 5; i++ {
+			fakeClock.Increment(100 * time.Millisecond)
+			Eventually(stdout.Lines).Should(HaveLen(i))
+		}
+		waitForRun(run)
+	})
+
+	It("stops after the requested duration", func() {
+		run, err := generator.Start(log.Spec{DurationMs: 1000})
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func() int { return run.Status().Sent }).Should(BeNumerically(">", 0))
+		Expect(run.Status().Done).To(BeFalse())
+
+		fakeClock.Increment(time.Second)
+		waitForRun(run)
+		Expect(stdout.Lines()).To(HaveLen(run.Status().Sent))
+	})
+
+	It("can be stopped", func() {
+		run, err := generator.Start(log.Spec{Rate: 1})
+		Expect(err).NotTo(HaveOccurred())
+
+		run.Stop()
+		Expect(run.Status().Done).To(BeTrue())
+		Expect(fakeClock.WatcherCount()).To(Equal(0))
+	})
+
+	It("writes multi-line entries in one go", func() {
+		run, err := generator.Start(log.Spec{Count: 2, Lines: 2})
+		Expect(err).NotTo(HaveOccurred())
+		waitForRun(run)
+
+		Expect(stdout.Lines()).To(Equal([]string{
+			"catnip-log run=1 seq=1 line=1/2",
+			"catnip-log run=1 seq=1 line=2/2",
+			"catnip-log run=1 seq=2 line=1/2",
+			"catnip-log run=1 seq=2 line=2/2",
+		}))
+	})
+
+	It("pads lines to the requested size", func() {
+		run, err := generator.Start(log.Spec{Count: 1, LineSize: 100})
+		Expect(err).NotTo(HaveOccurred())
+		waitForRun(run)
+
+		line := stdout.Lines()[0]
+		Expect(line).To(HavePrefix("catnip-log run=1 seq=1 xxx"))
+		Expect(line).To(HaveLen(100))
+	})
+
+	It("writes bytes that are not valid UTF-8", func() {
+		run, err := generator.Start(log.Spec{Count: 1, NonUTF8: true})
+		Expect(err).NotTo(HaveOccurred())
+		waitForRun(run)
+
+		Expect(stdout.String()).To(HavePrefix("catnip-log run=1 seq=1 "))
+		Expect(utf8.ValidString(stdout.String())).To(BeFalse())
+	})
+
+	It("rejects unknown streams and negative values", func() {
+		_, err := generator.Start(log.Spec{Stream: "stdin"})
+		Expect(err).To(HaveOccurred())
+
+		_, err = generator.Start(log.Spec{Count: -1})
+		Expect(err).To(HaveOccurred())
+	})
+
+	Describe("handlers", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			server = httptest.NewServer(router.New(stdout, fakeClock))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		do := func(method, path, body string) (int, log.Status) {
+			req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+			Expect(err).NotTo(HaveOccurred())
+			res, err := http.DefaultClient.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer res.Body.Close()
+
+			var status log.Status
+			json.NewDecoder(res.Body).Decode(&status)
+			return res.StatusCode, status
+		}
+
+		It("starts, reports and stops runs", func() {
+			statusCode, status := do(http.MethodPost, "/log/generate", `{"rate":1}`)
+			Expect(statusCode).To(Equal(http.StatusAccepted))
+			Expect(status.Id).To(Equal("1"))
+
+			statusCode, status = do(http.MethodGet, "/log/generate/1", "")
+			Expect(statusCode).To(Equal(http.StatusOK))
+			Expect(status.Done).To(BeFalse())
+
+			statusCode, status = do(http.MethodDelete, "/log/generate/1", "")
+			Expect(statusCode).To(Equal(http.StatusOK))
+			Expect(status.Done).To(BeTrue())
+		})
+
+		It("rejects invalid specs", func() {
+			statusCode, _ := do(http.MethodPost, "/log/generate", `{"stream":"stdin"}`)
+			Expect(statusCode).To(Equal(http.StatusBadRequest))
+
+			statusCode, _ = do(http.MethodPost, "/log/generate", `not json`)
+			Expect(statusCode).To(Equal(http.StatusBadRequest))
+		})
+
+		It("returns 404 for unknown runs", func() {
+			statusCode, _ := do(http.MethodGet, fmt.Sprintf("/log/generate/%d", 42), "")
+			Expect(statusCode).To(Equal(http.StatusNotFound))
+		})
+	})
+})
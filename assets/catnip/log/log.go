@@ -5,15 +5,22 @@ import (
 	"io"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"code.cloudfoundry.org/clock"
 	"github.com/gorilla/mux"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/httperr"
 )
 
 func MakeSpewHandler(w io.Writer) func(http.ResponseWriter, *http.Request) {
 	return func(res http.ResponseWriter, req *http.Request) {
-		kbytes, _ := strconv.Atoi(mux.Vars(req)["kbytes"])
+		kbytes, err := strconv.Atoi(mux.Vars(req)["kbytes"])
+		if err != nil || kbytes < 0 {
+			httperr.Write(res, http.StatusBadRequest, "kbytes must be a non-negative integer")
+			return
+		}
 
 		k := make([]byte, 1024)
 		for i := range k {
@@ -28,22 +35,60 @@ func MakeSpewHandler(w io.Writer) func(http.ResponseWriter, *http.Request) {
 	}
 }
 
-func MakeSleepHandler(w io.Writer, clock clock.Clock) func(http.ResponseWriter, *http.Request) {
-	return func(res http.ResponseWriter, req *http.Request) {
-		logSpeed, _ := strconv.Atoi(mux.Vars(req)["logspeed"])
+// MakeSleepHandler logs at the requested speed until it is called again, which
+// replaces the previous logger, or until DELETE /log/sleep stops it.
+func MakeSleepHandler(w io.Writer, clock clock.Clock) (func(http.ResponseWriter, *http.Request), func(http.ResponseWriter, *http.Request)) {
+	var (
+		mutex sync.Mutex
+		stop  chan struct{}
+	)
+
+	stopLogging := func() {
+		if stop != nil {
+			close(stop)
+			stop = nil
+		}
+	}
+
+	start := func(res http.ResponseWriter, req *http.Request) {
+		logSpeed, err := strconv.Atoi(mux.Vars(req)["logspeed"])
+		if err != nil || logSpeed <= 0 {
+			httperr.Write(res, http.StatusBadRequest, "logspeed must be a positive number of microseconds")
+			return
+		}
 
 		fmt.Fprintf(w, "Muahaha... let's go. Waiting %f seconds between loglines. Logging 'Muahaha...' every time.\n", float64(logSpeed)/1000000.0)
 
+		mutex.Lock()
+		defer mutex.Unlock()
+		stopLogging()
+		stop = make(chan struct{})
+
 		sequence := 1
 		ticker := clock.NewTicker(time.Duration(logSpeed) * time.Microsecond)
-		go func() {
+		go func(host string, stop <-chan struct{}) {
+			defer ticker.Stop()
 			for {
 				select {
 				case t := <-ticker.C():
-					fmt.Fprintf(w, "Log: %s Muahaha...%d...%s\n", req.Host, sequence, t.Format(time.RFC3339))
+					fmt.Fprintf(w, "Log: %s Muahaha...%d...%s\n", host, sequence, t.Format(time.RFC3339))
 					sequence++
+				case <-stop:
+					return
 				}
 			}
-		}()
+		}(req.Host, stop)
+
+		io.WriteString(res, fmt.Sprintf("Logging every %d microseconds", logSpeed))
 	}
+
+	stopHandler := func(res http.ResponseWriter, req *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		stopLogging()
+
+		io.WriteString(res, "Stopped logging")
+	}
+
+	return start, stopHandler
 }
@@ -52,6 +52,15 @@ var _ = Describe("Log", func() {
 
 			Expect(bodyBuf.String()).To(Equal("Just wrote 4 kbytes to the log"))
 		})
+
+		It("rejects invalid sizes", func() {
+			res, err := http.Get(fmt.Sprintf("%s/logspew/lots", server.URL))
+			Expect(err).NotTo(HaveOccurred())
+			res.Body.Close()
+
+			Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+			Expect(logBuf.Len()).To(BeZero())
+		})
 	})
 
 	Describe("SleepHandler", func() {
@@ -66,5 +75,40 @@ var _ = Describe("Log", func() {
 			fakeClock.Increment(4 * time.Microsecond)
 			Eventually(logBuf.String).Should(ContainSubstring("Muahaha...2"))
 		})
+
+		It("stops logging when asked to", func() {
+			res, err := http.Get(fmt.Sprintf("%s/log/sleep/4", server.URL))
+			Expect(err).NotTo(HaveOccurred())
+			res.Body.Close()
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+
+			req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/log/sleep", server.URL), nil)
+			Expect(err).NotTo(HaveOccurred())
+			res, err = http.DefaultClient.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			res.Body.Close()
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(0))
+		})
+
+		It("rejects speeds that are not positive", func() {
+			res, err := http.Get(fmt.Sprintf("%s/log/sleep/0", server.URL))
+			Expect(err).NotTo(HaveOccurred())
+			res.Body.Close()
+
+			Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+			Consistently(fakeClock.WatcherCount).Should(BeZero())
+		})
+
+		It("replaces the previous logger when called again", func() {
+			for i := 0; i < 3; i++ {
+				res, err := http.Get(fmt.Sprintf("%s/log/sleep/4", server.URL))
+				Expect(err).NotTo(HaveOccurred())
+				res.Body.Close()
+			}
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			Consistently(fakeClock.WatcherCount).Should(Equal(1))
+		})
 	})
 })
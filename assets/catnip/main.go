@@ -5,13 +5,63 @@ import (
 	"log"
 	"net/http"
 	"os"
+	ossignal "os/signal"
+	"syscall"
+	"time"
 
 	"code.cloudfoundry.org/clock"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/echo"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/identity"
 	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/router"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/signal"
 )
 
 func main() {
-	fmt.Printf("listening on port %s...\n", os.Getenv("PORT"))
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%s", os.Getenv("PORT")), router.New(os.Stdout, clock.NewClock())))
+	if err := echo.ListenAndServe("tcp", os.Getenv("CATNIP_TCP_PORTS"), os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+	if err := echo.ListenAndServe("udp", os.Getenv("CATNIP_UDP_PORTS"), os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+
+	sigterm := make(chan os.Signal, 1)
+	ossignal.Notify(sigterm, syscall.SIGTERM)
+
+	realClock := clock.NewClock()
+	drainer := signal.NewDrainer(realClock, os.Stdout)
+
+	identityWatcher := identity.NewWatcher(realClock, os.Getenv("CF_INSTANCE_CERT"), os.Getenv("CF_INSTANCE_KEY"))
+	stopWatching := make(chan struct{})
+	if os.Getenv("CF_INSTANCE_CERT") != "" {
+		// Diego rotates the credentials well before they expire, so checking
+		// every few seconds is plenty to see it happen.
+		go identityWatcher.Watch(5*time.Second, stopWatching)
+	}
+
+	server := &http.Server{
+		Addr:      fmt.Sprintf(":%s", os.Getenv("PORT")),
+		Handler:   router.NewWithDrainer(os.Stdout, realClock, drainer, identityWatcher),
+		ConnState: drainer.ConnState,
+	}
+	if os.Getenv("CATNIP_H2C") == "true" {
+		// Accept HTTP/2 with prior knowledge alongside HTTP/1.1, as the
+		// gorouter uses for routes with the http2 protocol.
+		server.Handler = h2c.NewHandler(server.Handler, &http2.Server{})
+	}
+
+	go func() {
+		fmt.Printf("listening on port %s...\n", os.Getenv("PORT"))
+		if err := server.ListenAndServe(); err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	<-sigterm
+	close(stopWatching)
+	if err := drainer.Drain(server); err != nil {
+		os.Exit(1)
+	}
 }
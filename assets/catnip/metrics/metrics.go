@@ -0,0 +1,165 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/httperr"
+)
+
+var validName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// Metric is a line in the metric registrar's structured log format. Gauges
+// set Value and counters set Delta.
+type Metric struct {
+	Type  string            `json:"type"`
+	Name  string            `json:"name"`
+	Value *float64          `json:"value,omitempty"`
+	Delta *uint64           `json:"delta,omitempty"`
+	Unit  string            `json:"unit,omitempty"`
+	Tags  map[string]string `json:"tags,omitempty"`
+}
+
+// Registry holds gauges and counters that are set over HTTP and scraped
+// from /metrics in the Prometheus text format. It can also write metrics
+// straight to the app's log for the registrar's structured log parsing.
+type Registry struct {
+	out io.Writer
+
+	mutex    sync.Mutex
+	gauges   map[string]float64
+	counters map[string]uint64
+}
+
+func NewRegistry(out io.Writer) *Registry {
+	return &Registry{
+		out:      out,
+		gauges:   map[string]float64{},
+		counters: map[string]uint64{},
+	}
+}
+
+func (r *Registry) PrometheusHandler(res http.ResponseWriter, req *http.Request) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	res.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	counters := []string{}
+	for name := range r.counters {
+		counters = append(counters, name)
+	}
+	sort.Strings(counters)
+	for _, name := range counters {
+		fmt.Fprintf(res, "# TYPE %s counter\n%s %d\n", name, name, r.counters[name])
+	}
+
+	gauges := []string{}
+	for name := range r.gauges {
+		gauges = append(gauges, name)
+	}
+	sort.Strings(gauges)
+	for _, name := range gauges {
+		fmt.Fprintf(res, "# TYPE %s gauge\n%s %s\n", name, name, strconv.FormatFloat(r.gauges[name], 'g', -1, 64))
+	}
+}
+
+func (r *Registry) SetGaugeHandler(res http.ResponseWriter, req *http.Request) {
+	name, value, ok := parseGauge(res, req)
+	if !ok {
+		return
+	}
+
+	r.mutex.Lock()
+	r.gauges[name] = value
+	r.mutex.Unlock()
+
+	io.WriteString(res, fmt.Sprintf("Set gauge %s to %s", name, strconv.FormatFloat(value, 'g', -1, 64)))
+}
+
+func (r *Registry) IncrementCounterHandler(res http.ResponseWriter, req *http.Request) {
+	name, delta, ok := parseCounter(res, req)
+	if !ok {
+		return
+	}
+
+	r.mutex.Lock()
+	r.counters[name] += delta
+	total := r.counters[name]
+	r.mutex.Unlock()
+
+	io.WriteString(res, fmt.Sprintf("Counter %s is %d", name, total))
+}
+
+// LogGaugeHandler writes a gauge to the log in the registrar's JSON format.
+// The unit query parameter is passed through.
+func (r *Registry) LogGaugeHandler(res http.ResponseWriter, req *http.Request) {
+	name, value, ok := parseGauge(res, req)
+	if !ok {
+		return
+	}
+
+	r.log(res, Metric{Type: "gauge", Name: name, Value: &value, Unit: req.URL.Query().Get("unit"), Tags: tags(req)})
+}
+
+// LogCounterHandler writes a counter increment to the log in the
+// registrar's JSON format.
+func (r *Registry) LogCounterHandler(res http.ResponseWriter, req *http.Request) {
+	name, delta, ok := parseCounter(res, req)
+	if !ok {
+		return
+	}
+
+	r.log(res, Metric{Type: "counter", Name: name, Delta: &delta, Tags: tags(req)})
+}
+
+func (r *Registry) log(res http.ResponseWriter, metric Metric) {
+	line, err := json.Marshal(metric)
+	if err != nil {
+		httperr.Write(res, http.StatusInternalServerError, "%s", err)
+		return
+	}
+
+	fmt.Fprintf(r.out, "%s\n", line)
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(line)
+}
+
+func parseGauge(res http.ResponseWriter, req *http.Request) (string, float64, bool) {
+	name := mux.Vars(req)["name"]
+	value, err := strconv.ParseFloat(mux.Vars(req)["value"], 64)
+	if !validName.MatchString(name) || err != nil {
+		httperr.Write(res, http.StatusBadRequest, "name must be a valid Prometheus metric name and value must be a number")
+		return "", 0, false
+	}
+	return name, value, true
+}
+
+func parseCounter(res http.ResponseWriter, req *http.Request) (string, uint64, bool) {
+	name := mux.Vars(req)["name"]
+	delta, err := strconv.ParseUint(mux.Vars(req)["delta"], 10, 64)
+	if !validName.MatchString(name) || err != nil {
+		httperr.Write(res, http.StatusBadRequest, "name must be a valid Prometheus metric name and delta must be a non-negative integer")
+		return "", 0, false
+	}
+	return name, delta, true
+}
+
+// tags turns tag.<key>=<value> query parameters into metric tags.
+func tags(req *http.Request) map[string]string {
+	tags := map[string]string{}
+	for key, values := range req.URL.Query() {
+		if strings.HasPrefix(key, "tag.") {
+			tags[strings.TrimPrefix(key, "tag.")] = values[0]
+		}
+	}
+	return tags
+}
@@ -0,0 +1,105 @@
+package metrics_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/metrics"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/router"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Metrics", func() {
+	var (
+		out    *gbytes.Buffer
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		out = gbytes.NewBuffer()
+		server = httptest.NewServer(router.New(out, clock.NewClock()))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	do := func(method, path string) (int, string) {
+		req, err := http.NewRequest(method, server.URL+path, nil)
+		Expect(err).NotTo(HaveOccurred())
+		res, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer res.Body.Close()
+
+		body, err := ioutil.ReadAll(res.Body)
+		Expect(err).NotTo(HaveOccurred())
+		return res.StatusCode, string(body)
+	}
+
+	Describe("PrometheusHandler", func() {
+		It("exposes nothing until metrics are set", func() {
+			status, body := do(http.MethodGet, "/metrics")
+			Expect(status).To(Equal(http.StatusOK))
+			Expect(body).To(BeEmpty())
+		})
+
+		It("exposes gauges and counters in the Prometheus text format", func() {
+			_, body := do(http.MethodPut, "/metrics/gauge/queue_depth/12.5")
+			Expect(body).To(Equal("Set gauge queue_depth to 12.5"))
+			do(http.MethodPut, "/metrics/gauge/queue_depth/3")
+			do(http.MethodPost, "/metrics/counter/requests_total/2")
+			_, body = do(http.MethodPost, "/metrics/counter/requests_total/5")
+			Expect(body).To(Equal("Counter requests_total is 7"))
+
+			_, body = do(http.MethodGet, "/metrics")
+			Expect(body).To(Equal(strings.Join([]string{
+				"# TYPE requests_total counter",
+				"requests_total 7",
+				"# TYPE queue_depth gauge",
+				"queue_depth 3",
+				"",
+			}, "\n")))
+		})
+
+		It("rejects invalid names and values", func() {
+			status, _ := do(http.MethodPut, "/metrics/gauge/not-valid/1")
+			Expect(status).To(Equal(http.StatusBadRequest))
+
+			status, _ = do(http.MethodPut, "/metrics/gauge/valid/one")
+			Expect(status).To(Equal(http.StatusBadRequest))
+
+			status, _ = do(http.MethodPost, "/metrics/counter/valid/-1")
+			Expect(status).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("structured metric logs", func() {
+		It("logs gauges in the registrar's JSON format", func() {
+			status, body := do(http.MethodPost, "/metrics/log/gauge/latency/42?unit=ms&tag.route=home")
+			Expect(status).To(Equal(http.StatusOK))
+			Eventually(out).Should(gbytes.Say(`\{"type":"gauge","name":"latency","value":42,"unit":"ms","tags":\{"route":"home"\}\}` + "\n"))
+
+			var metric metrics.Metric
+			Expect(json.Unmarshal([]byte(body), &metric)).To(Succeed())
+			Expect(metric.Type).To(Equal("gauge"))
+			Expect(*metric.Value).To(Equal(42.0))
+		})
+
+		It("logs counters in the registrar's JSON format", func() {
+			status, _ := do(http.MethodPost, "/metrics/log/counter/jobs/3")
+			Expect(status).To(Equal(http.StatusOK))
+			Eventually(out).Should(gbytes.Say(`\{"type":"counter","name":"jobs","delta":3\}` + "\n"))
+		})
+
+		It("logs zero values", func() {
+			do(http.MethodPost, "/metrics/log/gauge/idle/0")
+			Eventually(out).Should(gbytes.Say(`\{"type":"gauge","name":"idle","value":0\}`))
+		})
+	})
+})
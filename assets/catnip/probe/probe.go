@@ -0,0 +1,145 @@
+package probe
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/httperr"
+)
+
+const defaultTimeout = 3 * time.Second
+
+// Result reports whether the target answered. For tcp the latency is the time
+// taken to connect, for udp the round trip of the message and for http the
+// time until the response headers arrived.
+type Result struct {
+	Protocol  string  `json:"protocol"`
+	Address   string  `json:"address"`
+	Success   bool    `json:"success"`
+	LatencyMs float64 `json:"latency_ms"`
+	Response  string  `json:"response,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// ProbeHandler dials /probe/{protocol}/{address}. tcp and udp targets are
+// sent the message query parameter, "ping" by default, and expected to echo it
+// back. timeout_ms bounds the whole probe.
+func ProbeHandler(res http.ResponseWriter, req *http.Request) {
+	protocol := mux.Vars(req)["protocol"]
+	address := mux.Vars(req)["address"]
+
+	message := req.URL.Query().Get("message")
+	if message == "" {
+		message = "ping"
+	}
+
+	timeout := defaultTimeout
+	if timeoutMs := req.URL.Query().Get("timeout_ms"); timeoutMs != "" {
+		ms, err := strconv.Atoi(timeoutMs)
+		if err != nil || ms <= 0 {
+			httperr.Write(res, http.StatusBadRequest, "timeout_ms must be a positive integer")
+			return
+		}
+		timeout = time.Duration(ms) * time.Millisecond
+	}
+
+	var result Result
+	switch protocol {
+	case "tcp":
+		result = probeTCP(address, message, timeout)
+	case "udp":
+		result = probeUDP(address, message, timeout)
+	case "http":
+		result = probeHTTP(address, timeout)
+	default:
+		httperr.Write(res, http.StatusBadRequest, "Unknown protocol %q, expected tcp, udp or http", protocol)
+		return
+	}
+	result.Protocol = protocol
+	result.Address = address
+
+	res.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(res).Encode(result)
+}
+
+func probeTCP(address, message string, timeout time.Duration) Result {
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return Result{Error: err.Error()}
+	}
+	defer conn.Close()
+	result := Result{LatencyMs: millisecondsSince(start)}
+
+	response, err := roundTrip(conn, message, start.Add(timeout))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Success = true
+	result.Response = response
+	return result
+}
+
+func probeUDP(address, message string, timeout time.Duration) Result {
+	start := time.Now()
+	conn, err := net.DialTimeout("udp", address, timeout)
+	if err != nil {
+		return Result{Error: err.Error()}
+	}
+	defer conn.Close()
+
+	response, err := roundTrip(conn, message, start.Add(timeout))
+	if err != nil {
+		return Result{Error: err.Error()}
+	}
+
+	return Result{
+		Success:   true,
+		LatencyMs: millisecondsSince(start),
+		Response:  response,
+	}
+}
+
+func probeHTTP(address string, timeout time.Duration) Result {
+	client := http.Client{Timeout: timeout}
+
+	start := time.Now()
+	res, err := client.Get(fmt.Sprintf("http://%s/", address))
+	if err != nil {
+		return Result{Error: err.Error()}
+	}
+	defer res.Body.Close()
+
+	return Result{
+		Success:   true,
+		LatencyMs: millisecondsSince(start),
+		Response:  res.Status,
+	}
+}
+
+func roundTrip(conn net.Conn, message string, deadline time.Time) (string, error) {
+	conn.SetDeadline(deadline)
+
+	if _, err := io.WriteString(conn, message); err != nil {
+		return "", err
+	}
+
+	response := make([]byte, len(message))
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return "", err
+	}
+	return string(response), nil
+}
+
+func millisecondsSince(start time.Time) float64 {
+	return float64(time.Since(start)) / float64(time.Millisecond)
+}
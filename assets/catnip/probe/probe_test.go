@@ -0,0 +1,118 @@
+package probe_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/echo"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/probe"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/router"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Probe", func() {
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		server = httptest.NewServer(router.New(os.Stdout, clock.NewClock()))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	getProbe := func(path string) probe.Result {
+		res, err := http.Get(server.URL + path)
+		Expect(err).NotTo(HaveOccurred())
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+
+		var result probe.Result
+		Expect(json.NewDecoder(res.Body).Decode(&result)).To(Succeed())
+		return result
+	}
+
+	Context("tcp", func() {
+		It("reports the echoed message", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			defer listener.Close()
+			go echo.ServeTCP(listener, ioutil.Discard)
+
+			result := getProbe(fmt.Sprintf("/probe/tcp/%s?message=meow", listener.Addr()))
+			Expect(result.Protocol).To(Equal("tcp"))
+			Expect(result.Address).To(Equal(listener.Addr().String()))
+			Expect(result.Success).To(BeTrue())
+			Expect(result.Response).To(Equal("meow"))
+			Expect(result.LatencyMs).To(BeNumerically(">", 0))
+			Expect(result.Error).To(BeEmpty())
+		})
+
+		It("reports connection errors", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			address := listener.Addr().String()
+			listener.Close()
+
+			result := getProbe(fmt.Sprintf("/probe/tcp/%s", address))
+			Expect(result.Success).To(BeFalse())
+			Expect(result.Error).To(ContainSubstring("connection refused"))
+		})
+	})
+
+	Context("udp", func() {
+		It("reports the echoed message", func() {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+			go echo.ServeUDP(conn, ioutil.Discard)
+
+			result := getProbe(fmt.Sprintf("/probe/udp/%s", conn.LocalAddr()))
+			Expect(result.Success).To(BeTrue())
+			Expect(result.Response).To(Equal("ping"))
+		})
+
+		It("times out when nothing answers", func() {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			result := getProbe(fmt.Sprintf("/probe/udp/%s?timeout_ms=100", conn.LocalAddr()))
+			Expect(result.Success).To(BeFalse())
+			Expect(result.Error).To(ContainSubstring("timeout"))
+		})
+	})
+
+	Context("http", func() {
+		It("reports the response status", func() {
+			address := strings.TrimPrefix(server.URL, "http://")
+
+			result := getProbe(fmt.Sprintf("/probe/http/%s", address))
+			Expect(result.Success).To(BeTrue())
+			Expect(result.Response).To(Equal("200 OK"))
+		})
+	})
+
+	It("rejects unknown protocols", func() {
+		res, err := http.Get(server.URL + "/probe/sctp/127.0.0.1:9000")
+		Expect(err).NotTo(HaveOccurred())
+		res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+
+	It("rejects invalid timeouts", func() {
+		res, err := http.Get(server.URL + "/probe/tcp/127.0.0.1:9000?timeout_ms=soon")
+		Expect(err).NotTo(HaveOccurred())
+		res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+})
@@ -0,0 +1,121 @@
+package request
+
+import (
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/httperr"
+)
+
+type Request struct {
+	Method         string              `json:"method"`
+	Protocol       string              `json:"protocol"`
+	Host           string              `json:"host"`
+	RawPath        string              `json:"raw_path"`
+	Path           string              `json:"path"`
+	RawQuery       string              `json:"raw_query"`
+	Query          map[string][]string `json:"query"`
+	Headers        map[string][]string `json:"headers"`
+	RemoteAddr     string              `json:"remote_addr"`
+	ForwardedFor   []string            `json:"x_forwarded_for"`
+	ForwardedProto string              `json:"x_forwarded_proto"`
+	Trace          Trace               `json:"trace"`
+	BodySize       int64               `json:"body_size"`
+	TLS            *TLS                `json:"tls,omitempty"`
+}
+
+type Trace struct {
+	B3TraceId      string `json:"x_b3_traceid"`
+	B3SpanId       string `json:"x_b3_spanid"`
+	B3ParentSpanId string `json:"x_b3_parentspanid"`
+	B3Sampled      string `json:"x_b3_sampled"`
+	B3             string `json:"b3"`
+	TraceParent    string `json:"traceparent"`
+	TraceState     string `json:"tracestate"`
+}
+
+type TLS struct {
+	Version     string `json:"version"`
+	CipherSuite string `json:"cipher_suite"`
+	ServerName  string `json:"server_name"`
+	ClientCerts int    `json:"client_certificates"`
+}
+
+func RequestHandler(res http.ResponseWriter, req *http.Request) {
+	httperr.WriteJSON(res, Inspect(req))
+}
+
+func HeadersHandler(res http.ResponseWriter, req *http.Request) {
+	httperr.WriteJSON(res, req.Header)
+}
+
+// Inspect describes the request as it reached the app, draining the body to
+// measure its size.
+func Inspect(req *http.Request) Request {
+	rawPath := req.RequestURI
+	if i := strings.Index(rawPath, "?"); i >= 0 {
+		rawPath = rawPath[:i]
+	}
+
+	bodySize, _ := io.Copy(ioutil.Discard, req.Body)
+
+	return Request{
+		Method:         req.Method,
+		Protocol:       req.Proto,
+		Host:           req.Host,
+		RawPath:        rawPath,
+		Path:           req.URL.Path,
+		RawQuery:       req.URL.RawQuery,
+		Query:          req.URL.Query(),
+		Headers:        req.Header,
+		RemoteAddr:     req.RemoteAddr,
+		ForwardedFor:   forwardedFor(req.Header),
+		ForwardedProto: req.Header.Get("X-Forwarded-Proto"),
+		Trace: Trace{
+			B3TraceId:      req.Header.Get("X-B3-TraceId"),
+			B3SpanId:       req.Header.Get("X-B3-SpanId"),
+			B3ParentSpanId: req.Header.Get("X-B3-ParentSpanId"),
+			B3Sampled:      req.Header.Get("X-B3-Sampled"),
+			B3:             req.Header.Get("B3"),
+			TraceParent:    req.Header.Get("Traceparent"),
+			TraceState:     req.Header.Get("Tracestate"),
+		},
+		BodySize: bodySize,
+		TLS:      inspectTLS(req.TLS),
+	}
+}
+
+func forwardedFor(header http.Header) []string {
+	chain := []string{}
+	for _, value := range header["X-Forwarded-For"] {
+		for _, addr := range strings.Split(value, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				chain = append(chain, addr)
+			}
+		}
+	}
+	return chain
+}
+
+func inspectTLS(state *tls.ConnectionState) *TLS {
+	if state == nil {
+		return nil
+	}
+
+	versions := map[uint16]string{
+		tls.VersionTLS10: "TLS 1.0",
+		tls.VersionTLS11: "TLS 1.1",
+		tls.VersionTLS12: "TLS 1.2",
+		tls.VersionTLS13: "TLS 1.3",
+	}
+
+	return &TLS{
+		Version:     versions[state.Version],
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		ServerName:  state.ServerName,
+		ClientCerts: len(state.PeerCertificates),
+	}
+}
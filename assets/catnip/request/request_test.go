@@ -0,0 +1,127 @@
+package request_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/request"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/router"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Request", func() {
+	var (
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(router.New(os.Stdout, clock.NewClock()))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("RequestHandler", func() {
+		It("describes the method, path, query and body", func() {
+			req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/request/some%%20path?foo=bar+baz%%20bing&foo=2", server.URL), strings.NewReader("twelve bytes"))
+			Expect(err).NotTo(HaveOccurred())
+
+			inspected := inspect(req)
+			Expect(inspected.Method).To(Equal(http.MethodPost))
+			Expect(inspected.Protocol).To(Equal("HTTP/1.1"))
+			Expect(inspected.RawPath).To(Equal("/request/some%20path"))
+			Expect(inspected.Path).To(Equal("/request/some path"))
+			Expect(inspected.RawQuery).To(Equal("foo=bar+baz%20bing&foo=2"))
+			Expect(inspected.Query).To(HaveKeyWithValue("foo", []string{"bar baz bing", "2"}))
+			Expect(inspected.BodySize).To(Equal(int64(12)))
+			Expect(inspected.RemoteAddr).To(HavePrefix("127.0.0.1:"))
+			Expect(inspected.TLS).To(BeNil())
+		})
+
+		It("splits the X-Forwarded-For chain and reports the forwarded protocol", func() {
+			req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/request", server.URL), nil)
+			Expect(err).NotTo(HaveOccurred())
+			req.Header.Add("X-Forwarded-For", "10.0.0.1, 10.0.0.2")
+			req.Header.Add("X-Forwarded-For", "10.0.0.3")
+			req.Header.Set("X-Forwarded-Proto", "https")
+
+			inspected := inspect(req)
+			Expect(inspected.ForwardedFor).To(Equal([]string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}))
+			Expect(inspected.ForwardedProto).To(Equal("https"))
+		})
+
+		It("reports B3 and W3C trace headers", func() {
+			req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/request", server.URL), nil)
+			Expect(err).NotTo(HaveOccurred())
+			req.Header.Set("X-B3-TraceId", "fee1f7ba6aeec41c")
+			req.Header.Set("X-B3-SpanId", "579b36fd31cd8714")
+			req.Header.Set("X-B3-ParentSpanId", "1234567890abcdef")
+			req.Header.Set("X-B3-Sampled", "1")
+			req.Header.Set("B3", "fee1f7ba6aeec41c-579b36fd31cd8714-1")
+			req.Header.Set("Traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+			req.Header.Set("Tracestate", "congo=t61rcWkgMzE")
+
+			Expect(inspect(req).Trace).To(Equal(request.Trace{
+				B3TraceId:      "fee1f7ba6aeec41c",
+				B3SpanId:       "579b36fd31cd8714",
+				B3ParentSpanId: "1234567890abcdef",
+				B3Sampled:      "1",
+				B3:             "fee1f7ba6aeec41c-579b36fd31cd8714-1",
+				TraceParent:    "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+				TraceState:     "congo=t61rcWkgMzE",
+			}))
+		})
+
+		It("reports TLS details when served over TLS", func() {
+			tlsServer := httptest.NewTLSServer(router.New(os.Stdout, clock.NewClock()))
+			defer tlsServer.Close()
+
+			res, err := tlsServer.Client().Get(fmt.Sprintf("%s/request", tlsServer.URL))
+			Expect(err).NotTo(HaveOccurred())
+			defer res.Body.Close()
+
+			var inspected request.Request
+			Expect(json.NewDecoder(res.Body).Decode(&inspected)).To(Succeed())
+			Expect(inspected.TLS).NotTo(BeNil())
+			Expect(inspected.TLS.Version).To(HavePrefix("TLS 1."))
+			Expect(inspected.TLS.CipherSuite).NotTo(BeEmpty())
+		})
+	})
+
+	Describe("HeadersHandler", func() {
+		It("returns all headers as JSON", func() {
+			req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/headers", server.URL), nil)
+			Expect(err).NotTo(HaveOccurred())
+			req.Header.Add("X-Cats", "one")
+			req.Header.Add("X-Cats", "two")
+
+			res, err := http.DefaultClient.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer res.Body.Close()
+			Expect(res.Header.Get("Content-Type")).To(Equal("application/json"))
+
+			var headers map[string][]string
+			Expect(json.NewDecoder(res.Body).Decode(&headers)).To(Succeed())
+			Expect(headers).To(HaveKeyWithValue("X-Cats", []string{"one", "two"}))
+		})
+	})
+})
+
+func inspect(req *http.Request) request.Request {
+	res, err := http.DefaultClient.Do(req)
+	Expect(err).NotTo(HaveOccurred())
+	defer res.Body.Close()
+	Expect(res.StatusCode).To(Equal(http.StatusOK))
+
+	var inspected request.Request
+	Expect(json.NewDecoder(res.Body).Decode(&inspected)).To(Succeed())
+	return inspected
+}
@@ -1,43 +1,180 @@
 package router
 
 import (
+	"encoding/json"
 	"io"
 	"net/http"
+	"os"
+	"regexp"
+	"strings"
 
 	"code.cloudfoundry.org/clock"
 	"github.com/gorilla/mux"
 
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/container"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/dns"
 	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/env"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/files"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/grpc"
 	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/health"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/httperr"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/identity"
 	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/linux"
 	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/log"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/metrics"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/probe"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/request"
 	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/session"
 	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/signal"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/stream"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/stress"
 	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/text"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/transfer"
 )
 
+var pathVariable = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// Route is a catnip endpoint as registered with mux and as listed by the /
+// catalogue. Routes without Methods accept any method, and Prefix routes
+// also serve every path below Path.
+type Route struct {
+	Methods     []string         `json:"methods,omitempty"`
+	Path        string           `json:"path"`
+	Description string           `json:"description"`
+	Parameters  []string         `json:"parameters,omitempty"`
+	Query       []string         `json:"query,omitempty"`
+	Prefix      bool             `json:"prefix,omitempty"`
+	Handler     http.HandlerFunc `json:"-"`
+}
+
 func New(out io.Writer, clock clock.Clock) *mux.Router {
+	identityWatcher := identity.NewWatcher(clock, os.Getenv("CF_INSTANCE_CERT"), os.Getenv("CF_INSTANCE_KEY"))
+	return NewWithDrainer(out, clock, signal.NewDrainer(clock, out), identityWatcher)
+}
+
+// NewWithDrainer lets the caller keep hold of the drainer so that it can be
+// told about SIGTERM and server connections, and of the identity watcher so
+// that it can decide how long to watch for.
+func NewWithDrainer(out io.Writer, clock clock.Clock, drainer *signal.Drainer, identityWatcher *identity.Watcher) *mux.Router {
 	r := mux.NewRouter()
+	healthChecker := health.NewChecker(clock)
+	stresser := stress.NewStresser(clock, os.TempDir())
+	grpcServer := grpc.NewServer(clock)
+	fileStore := files.NewStore(clock, os.TempDir())
+	metricsRegistry := metrics.NewRegistry(out)
+	resolver := dns.NewResolver(clock, dns.Nameserver("/etc/resolv.conf"))
+	logGenerator := log.NewGenerator(clock, out, os.Stderr)
+	startSleepLogging, stopSleepLogging := log.MakeSleepHandler(out, clock)
 
-	r.HandleFunc("/", HomeHandler).Methods(http.MethodGet)
-	r.HandleFunc("/id", env.InstanceGuidHandler).Methods(http.MethodGet)
-	r.HandleFunc("/myip", linux.MyIPHandler).Methods(http.MethodGet)
-	r.HandleFunc("/health", health.HealthHander).Methods(http.MethodGet)
-	r.HandleFunc("/session", session.StickyHandler).Methods(http.MethodPost)
-	r.HandleFunc("/env.json", env.JSONHandler).Methods(http.MethodGet)
-	r.HandleFunc("/env/{name}", env.NameHandler).Methods(http.MethodGet)
-	r.HandleFunc("/lsb_release", linux.ReleaseHandler).Methods(http.MethodGet)
-	r.HandleFunc("/sigterm/KILL", signal.KillHandler).Methods(http.MethodGet)
-	r.HandleFunc("/logspew/{kbytes}", log.MakeSpewHandler(out)).Methods(http.MethodGet)
-	r.HandleFunc("/largetext/{kbytes}", text.LargeHandler).Methods(http.MethodGet)
-	r.HandleFunc("/log/sleep/{logspeed}", log.MakeSleepHandler(out, clock)).Methods(http.MethodGet)
-	r.HandleFunc("/curl/{host}", linux.CurlHandler).Methods(http.MethodGet)
-	r.HandleFunc("/curl/{host}/", linux.CurlHandler).Methods(http.MethodGet)
-	r.HandleFunc("/curl/{host}/{port}", linux.CurlHandler).Methods(http.MethodGet)
+	var routes []Route
+	routes = []Route{
+		{Methods: []string{http.MethodGet}, Path: "/", Description: "Says \"Catnip?\", or lists these endpoints when JSON is accepted", Handler: MakeHomeHandler(&routes)},
+		{Methods: []string{http.MethodGet}, Path: "/id", Description: "The instance GUID", Handler: env.InstanceGuidHandler},
+		{Methods: []string{http.MethodGet}, Path: "/myip", Description: "The container IP", Handler: linux.MyIPHandler},
+		{Methods: []string{http.MethodGet}, Path: "/health", Description: "Health check whose behaviour is set through /health/admin", Handler: healthChecker.HealthHandler},
+		{Methods: []string{http.MethodGet}, Path: "/health/admin", Description: "The health check state", Handler: healthChecker.GetAdminHandler},
+		{Methods: []string{http.MethodPut}, Path: "/health/admin", Description: "Sets the health check state from a JSON body", Handler: healthChecker.SetAdminHandler},
+		{Methods: []string{http.MethodPost}, Path: "/session", Description: "Sets a JSESSIONID cookie for sticky sessions", Handler: session.StickyHandler},
+		{Methods: []string{http.MethodGet}, Path: "/env.json", Description: "The whole environment as JSON", Handler: env.JSONHandler},
+		{Methods: []string{http.MethodGet}, Path: "/env/{name}", Description: "The value of an environment variable", Handler: env.NameHandler},
+		{Methods: []string{http.MethodGet}, Path: "/vcap/application", Description: "VCAP_APPLICATION as typed JSON", Handler: env.ApplicationHandler},
+		{Methods: []string{http.MethodGet}, Path: "/vcap/services", Description: "VCAP_SERVICES bindings keyed by label", Handler: env.ServicesHandler},
+		{Methods: []string{http.MethodGet}, Path: "/vcap/services/label/{label}", Description: "The bindings of services with a label", Handler: env.ServicesByLabelHandler},
+		{Methods: []string{http.MethodGet}, Path: "/vcap/services/instance/{name}", Description: "The binding of a service instance", Handler: env.ServiceByInstanceNameHandler},
+		{Methods: []string{http.MethodGet}, Path: "/vcap/services/binding/{name}", Description: "The binding with a binding name", Handler: env.ServiceByBindingNameHandler},
+		{Methods: []string{http.MethodGet}, Path: "/lsb_release", Description: "The distribution, formatted like lsb_release --all", Handler: linux.ReleaseHandler},
+		{Methods: []string{http.MethodGet}, Path: "/container", Description: "Limits, mounts and identity of the container", Handler: container.NewInspector("/").ContainerHandler},
+		{Methods: []string{http.MethodGet}, Path: "/sigterm/KILL", Description: "Kills catnip without draining", Handler: signal.KillHandler},
+		{Methods: []string{http.MethodGet}, Path: "/drain", Description: "The drain period after SIGTERM", Handler: drainer.GetHandler},
+		{Methods: []string{http.MethodPut}, Path: "/drain/{ms}", Description: "Sets the drain period after SIGTERM", Handler: drainer.SetHandler},
+		{Methods: []string{http.MethodGet}, Path: "/logspew/{kbytes}", Description: "Writes kbytes of log output at once", Handler: log.MakeSpewHandler(out)},
+		{Methods: []string{http.MethodGet}, Path: "/largetext/{kbytes}", Description: "Responds with kbytes of text", Handler: text.LargeHandler},
+		{Methods: []string{http.MethodGet}, Path: "/slow/{ms}", Description: "Waits before responding", Handler: transfer.MakeDelayHandler(clock)},
+		{Methods: []string{http.MethodGet}, Path: "/trickle/{bytes}/{rate}", Description: "Sends bytes at rate bytes per second", Handler: transfer.MakeTrickleHandler(clock)},
+		{Methods: []string{http.MethodGet}, Path: "/chunked/{count}/{size}", Description: "Streams count chunks of size bytes", Query: []string{"interval_ms"}, Handler: transfer.MakeChunkedHandler(clock)},
+		{Methods: []string{http.MethodPost, http.MethodPut}, Path: "/upload", Description: "Reports the size and SHA-256 of the request body", Handler: transfer.UploadHandler},
+		{Methods: []string{http.MethodGet}, Path: "/log/sleep/{logspeed}", Description: "Logs every logspeed microseconds until stopped", Handler: startSleepLogging},
+		{Methods: []string{http.MethodDelete}, Path: "/log/sleep", Description: "Stops /log/sleep logging", Handler: stopSleepLogging},
+		{Methods: []string{http.MethodPost}, Path: "/log/generate", Description: "Starts a sequenced log run from a JSON spec", Handler: logGenerator.StartHandler},
+		{Methods: []string{http.MethodGet}, Path: "/log/generate/{id}", Description: "The progress of a log run", Handler: logGenerator.StatusHandler},
+		{Methods: []string{http.MethodDelete}, Path: "/log/generate/{id}", Description: "Stops a log run", Handler: logGenerator.StopHandler},
+		{Methods: []string{http.MethodGet}, Path: "/metrics", Description: "Gauges and counters in the Prometheus text format", Handler: metricsRegistry.PrometheusHandler},
+		{Methods: []string{http.MethodPut}, Path: "/metrics/gauge/{name}/{value}", Description: "Sets a gauge", Handler: metricsRegistry.SetGaugeHandler},
+		{Methods: []string{http.MethodPost}, Path: "/metrics/counter/{name}/{delta}", Description: "Adds to a counter", Handler: metricsRegistry.IncrementCounterHandler},
+		{Methods: []string{http.MethodPost}, Path: "/metrics/log/gauge/{name}/{value}", Description: "Logs a gauge in the metric registrar format", Query: []string{"unit", "tag.<key>"}, Handler: metricsRegistry.LogGaugeHandler},
+		{Methods: []string{http.MethodPost}, Path: "/metrics/log/counter/{name}/{delta}", Description: "Logs a counter increment in the metric registrar format", Query: []string{"tag.<key>"}, Handler: metricsRegistry.LogCounterHandler},
+		{Methods: []string{http.MethodGet}, Path: "/identity", Description: "The instance identity certificate", Handler: identityWatcher.IdentityHandler},
+		{Methods: []string{http.MethodGet}, Path: "/identity/rotations", Description: "When the instance identity credentials were replaced", Handler: identityWatcher.RotationsHandler},
+		{Methods: []string{http.MethodGet}, Path: "/curl/{host}", Description: "Runs curl against host on port 80", Handler: linux.CurlHandler},
+		{Methods: []string{http.MethodGet}, Path: "/curl/{host}/", Description: "Runs curl against host on port 80", Handler: linux.CurlHandler},
+		{Methods: []string{http.MethodGet}, Path: "/curl/{host}/{port}", Description: "Runs curl against host and port", Handler: linux.CurlHandler},
+		{Methods: []string{http.MethodGet}, Path: "/probe/{protocol}/{address}", Description: "Checks a tcp, udp or http target answers", Query: []string{"message", "timeout_ms"}, Handler: probe.ProbeHandler},
+		{Methods: []string{http.MethodGet}, Path: "/dig/{name}", Description: "A, AAAA and SRV records with TTLs", Query: []string{"type", "server"}, Handler: resolver.DigHandler},
+		{Methods: []string{http.MethodGet}, Path: "/dial/{address}", Description: "Opens a TCP connection to host:port", Handler: resolver.DialHandler},
+		{Path: "/headers", Description: "The request headers", Handler: request.HeadersHandler},
+		{Path: "/request", Description: "Describes the request, including any path under /request", Prefix: true, Handler: request.RequestHandler},
+		{Methods: []string{http.MethodGet}, Path: "/websocket", Description: "Echoes WebSocket messages", Handler: stream.WebSocketEchoHandler},
+		{Methods: []string{http.MethodGet}, Path: "/events/{count}/{intervalms}", Description: "Streams count server-sent events", Handler: stream.MakeEventsHandler(clock)},
+		{Methods: []string{http.MethodPost}, Path: "/grpc.health.v1.Health/Check", Description: "gRPC health check", Handler: grpcServer.HealthCheckHandler},
+		{Methods: []string{http.MethodPost}, Path: "/catnip.Echo/Echo", Description: "gRPC unary echo", Handler: grpcServer.EchoHandler},
+		{Methods: []string{http.MethodPost}, Path: "/catnip.Echo/EchoStream", Description: "gRPC server streaming echo", Handler: grpcServer.EchoStreamHandler},
+		{Methods: []string{http.MethodPut}, Path: "/files/tmp/{path:.*}", Description: "Writes random bytes under $TMPDIR", Query: []string{"bytes"}, Handler: fileStore.WriteHandler},
+		{Methods: []string{http.MethodGet}, Path: "/files/tmp/{path:.*}", Description: "Hashes a file or lists a directory under $TMPDIR", Handler: fileStore.ReadHandler},
+		{Methods: []string{http.MethodDelete}, Path: "/files/tmp/{path:.*}", Description: "Removes a file under $TMPDIR", Handler: fileStore.DeleteHandler},
+		{Methods: []string{http.MethodPut}, Path: "/files/volume/{instance}/{path:.*}", Description: "Writes random bytes to a bound volume", Query: []string{"bytes"}, Handler: fileStore.WriteHandler},
+		{Methods: []string{http.MethodGet}, Path: "/files/volume/{instance}/{path:.*}", Description: "Hashes a file or lists a directory on a bound volume", Handler: fileStore.ReadHandler},
+		{Methods: []string{http.MethodDelete}, Path: "/files/volume/{instance}/{path:.*}", Description: "Removes a file on a bound volume", Handler: fileStore.DeleteHandler},
+		{Methods: []string{http.MethodGet}, Path: "/stress/memory/{mb}", Description: "Allocates and holds mb megabytes", Handler: stresser.MemoryHandler},
+		{Methods: []string{http.MethodDelete}, Path: "/stress/memory", Description: "Releases held memory", Handler: stresser.ReleaseMemoryHandler},
+		{Methods: []string{http.MethodGet}, Path: "/stress/cpu/{cores}/{seconds}", Description: "Keeps cores busy for seconds", Handler: stresser.CPUHandler},
+		{Methods: []string{http.MethodGet}, Path: "/stress/disk/{mb}", Description: "Writes mb megabytes to $TMPDIR", Handler: stresser.DiskHandler},
+		{Methods: []string{http.MethodDelete}, Path: "/stress/disk", Description: "Removes files written by /stress/disk", Handler: stresser.ReleaseDiskHandler},
+	}
+	for _, route := range routes {
+		var muxRoute *mux.Route
+		if route.Prefix {
+			muxRoute = r.PathPrefix(route.Path).HandlerFunc(route.Handler)
+		} else {
+			muxRoute = r.HandleFunc(route.Path, route.Handler)
+		}
+		if len(route.Methods) > 0 {
+			muxRoute.Methods(route.Methods...)
+		}
+	}
+	r.NotFoundHandler = http.HandlerFunc(NotFoundHandler)
 
 	return r
 }
 
-func HomeHandler(res http.ResponseWriter, req *http.Request) {
-	io.WriteString(res, "Catnip?")
+// MakeHomeHandler says "Catnip?" to browsers, curl and the many specs that
+// look for it, and lists the routes to clients that accept JSON.
+func MakeHomeHandler(routes *[]Route) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		if !strings.Contains(req.Header.Get("Accept"), "application/json") {
+			io.WriteString(res, "Catnip?")
+			return
+		}
+
+		catalogue := []Route{}
+		for _, route := range *routes {
+			route.Parameters = pathParameters(route.Path)
+			catalogue = append(catalogue, route)
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(res).Encode(catalogue)
+	}
+}
+
+func NotFoundHandler(res http.ResponseWriter, req *http.Request) {
+	httperr.Write(res, http.StatusNotFound, "No route for %s %s; GET / with Accept: application/json lists them", req.Method, req.URL.Path)
+}
+
+// pathParameters returns the names of the variables in a mux path template.
+func pathParameters(path string) []string {
+	parameters := []string{}
+	for _, match := range pathVariable.FindAllStringSubmatch(path, -1) {
+		parameters = append(parameters, match[1])
+	}
+	return parameters
 }
@@ -0,0 +1,264 @@
+package router_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/gorilla/mux"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/httperr"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/identity"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/router"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/signal"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("Router", func() {
+	var (
+		r      *mux.Router
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		r = router.New(os.Stdout, fakeclock.NewFakeClock(time.Now()))
+		server = httptest.NewServer(r)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	getCatalogue := func() []router.Route {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/", nil)
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Accept", "application/json")
+
+		res, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		Expect(res.Header.Get("Content-Type")).To(Equal("application/json"))
+
+		var catalogue []router.Route
+		Expect(json.NewDecoder(res.Body).Decode(&catalogue)).To(Succeed())
+		return catalogue
+	}
+
+	Describe("/", func() {
+		It("says Catnip?", func() {
+			res, err := http.Get(server.URL + "/")
+			Expect(err).NotTo(HaveOccurred())
+			defer res.Body.Close()
+
+			Expect(ioutil.ReadAll(res.Body)).To(Equal([]byte("Catnip?")))
+		})
+
+		It("lists the endpoints with their parameters to clients that accept JSON", func() {
+			catalogue := getCatalogue()
+			Expect(catalogue).To(ContainElement(router.Route{
+				Methods:     []string{http.MethodGet},
+				Path:        "/files/volume/{instance}/{path:.*}",
+				Description: "Hashes a file or lists a directory on a bound volume",
+				Parameters:  []string{"instance", "path"},
+			}))
+			Expect(catalogue).To(ContainElement(router.Route{
+				Methods:     []string{http.MethodGet},
+				Path:        "/dig/{name}",
+				Description: "A, AAAA and SRV records with TTLs",
+				Parameters:  []string{"name"},
+				Query:       []string{"type", "server"},
+			}))
+		})
+
+		It("routes every endpoint it lists to that endpoint", func() {
+			variable := regexp.MustCompile(`\{[^}]+\}`)
+
+			for _, route := range getCatalogue() {
+				path := variable.ReplaceAllString(route.Path, "1")
+				methods := route.Methods
+				if len(methods) == 0 {
+					methods = []string{http.MethodGet, http.MethodPost}
+				}
+
+				for _, method := range methods {
+					req := httptest.NewRequest(method, path, nil)
+					var match mux.RouteMatch
+					Expect(r.Match(req, &match)).To(BeTrue(), method+" "+path)
+
+					template, err := match.Route.GetPathTemplate()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(template).To(Equal(route.Path), method+" "+path)
+				}
+			}
+		})
+	})
+
+	Describe("/myip", func() {
+		It("responds with an address of this host", func() {
+			res, err := http.Get(server.URL + "/myip")
+			Expect(err).NotTo(HaveOccurred())
+			defer res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+
+			body, err := ioutil.ReadAll(res.Body)
+			Expect(err).NotTo(HaveOccurred())
+			ip := net.ParseIP(strings.TrimSpace(string(body)))
+			Expect(ip).NotTo(BeNil(), string(body))
+
+			interfaceAddrs, err := net.InterfaceAddrs()
+			Expect(err).NotTo(HaveOccurred())
+			hostIPs := []string{}
+			for _, addr := range interfaceAddrs {
+				if ipNet, ok := addr.(*net.IPNet); ok {
+					hostIPs = append(hostIPs, ipNet.IP.String())
+				}
+			}
+			Expect(hostIPs).To(ContainElement(ip.String()))
+		})
+	})
+
+	Describe("/identity/rotations", func() {
+		var (
+			fakeClock *fakeclock.FakeClock
+			dir       string
+			certPath  string
+			stop      chan struct{}
+		)
+
+		writeCert := func(serial int64) {
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+			template := &x509.Certificate{
+				SerialNumber: big.NewInt(serial),
+				Subject:      pkix.Name{CommonName: "instance-guid"},
+				NotBefore:    time.Now().Add(-time.Hour),
+				NotAfter:     time.Now().Add(time.Hour),
+			}
+			der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)).To(Succeed())
+		}
+
+		getRotations := func() []identity.Rotation {
+			res, err := http.Get(server.URL + "/identity/rotations")
+			Expect(err).NotTo(HaveOccurred())
+			defer res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+			Expect(res.Header.Get("Content-Type")).To(Equal("application/json"))
+
+			var rotations []identity.Rotation
+			Expect(json.NewDecoder(res.Body).Decode(&rotations)).To(Succeed())
+			return rotations
+		}
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "catnip-router")
+			Expect(err).NotTo(HaveOccurred())
+			certPath = filepath.Join(dir, "instance.crt")
+			writeCert(100)
+
+			fakeClock = fakeclock.NewFakeClock(time.Now())
+			watcher := identity.NewWatcher(fakeClock, certPath, filepath.Join(dir, "instance.key"))
+			stop = make(chan struct{})
+			go watcher.Watch(time.Minute, stop)
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+
+			server.Close()
+			server = httptest.NewServer(router.NewWithDrainer(os.Stdout, fakeClock, signal.NewDrainer(fakeClock, os.Stdout), watcher))
+		})
+
+		AfterEach(func() {
+			close(stop)
+			os.RemoveAll(dir)
+		})
+
+		It("is an empty list until the credentials are replaced", func() {
+			Expect(getRotations()).To(BeEmpty())
+
+			writeCert(200)
+			fakeClock.Increment(time.Minute)
+
+			Eventually(getRotations).Should(HaveLen(1))
+			rotation := getRotations()[0]
+			Expect(rotation.PreviousSerialNumber).To(Equal("100"))
+			Expect(rotation.SerialNumber).To(Equal("200"))
+		})
+	})
+
+	Describe("/sigterm/KILL", func() {
+		It("kills catnip without letting it drain", func() {
+			catnipPath, err := gexec.Build("github.com/cloudfoundry/cf-acceptance-tests/assets/catnip")
+			Expect(err).NotTo(HaveOccurred())
+			defer gexec.CleanupBuildArtifacts()
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			_, port, err := net.SplitHostPort(listener.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+			listener.Close()
+
+			cmd := exec.Command(catnipPath)
+			cmd.Env = append(os.Environ(), "PORT="+port)
+			session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			defer session.Kill()
+			Eventually(session).Should(gbytes.Say("listening on port %s", port))
+
+			Eventually(func() error {
+				res, err := http.Get("http://127.0.0.1:" + port + "/id")
+				if err == nil {
+					res.Body.Close()
+				}
+				return err
+			}).Should(Succeed())
+
+			// The connection dies with catnip, so there is no response.
+			http.Get("http://127.0.0.1:" + port + "/sigterm/KILL")
+
+			Eventually(session.Exited).Should(BeClosed())
+			status := session.Command.ProcessState.Sys().(syscall.WaitStatus)
+			Expect(status.Signaled()).To(BeTrue())
+			Expect(status.Signal()).To(Equal(syscall.SIGKILL))
+		})
+	})
+
+	It("responds with a JSON error for unknown routes", func() {
+		req, err := http.NewRequest(http.MethodPatch, server.URL+"/id", nil)
+		Expect(err).NotTo(HaveOccurred())
+		res, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer res.Body.Close()
+
+		Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+		Expect(res.Header.Get("Content-Type")).To(Equal("application/json"))
+		var body httperr.Error
+		Expect(json.NewDecoder(res.Body).Decode(&body)).To(Succeed())
+		Expect(body).To(Equal(httperr.Error{
+			Status: http.StatusNotFound,
+			Error:  "No route for PATCH /id; GET / with Accept: application/json lists them",
+		}))
+	})
+})
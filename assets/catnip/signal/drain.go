@@ -0,0 +1,119 @@
+package signal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/gorilla/mux"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/httperr"
+)
+
+type Shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+// DrainState is what /drain reports.
+type DrainState struct {
+	PeriodMs int `json:"period_ms"`
+	InFlight int `json:"in_flight"`
+}
+
+// Drainer keeps serving for a configurable period after SIGTERM before
+// shutting the server down, logging timestamped events along the way so that
+// specs can see how the platform's graceful shutdown played out.
+type Drainer struct {
+	clock clock.Clock
+	out   io.Writer
+
+	mutex  sync.Mutex
+	period time.Duration
+	active map[net.Conn]bool
+}
+
+func NewDrainer(clock clock.Clock, out io.Writer) *Drainer {
+	return &Drainer{
+		clock:  clock,
+		out:    out,
+		active: map[net.Conn]bool{},
+	}
+}
+
+func (d *Drainer) GetHandler(res http.ResponseWriter, req *http.Request) {
+	d.mutex.Lock()
+	state := DrainState{
+		PeriodMs: int(d.period / time.Millisecond),
+		InFlight: len(d.active),
+	}
+	d.mutex.Unlock()
+
+	res.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(res).Encode(state)
+}
+
+func (d *Drainer) SetHandler(res http.ResponseWriter, req *http.Request) {
+	ms, err := strconv.Atoi(mux.Vars(req)["ms"])
+	if err != nil || ms < 0 {
+		httperr.Write(res, http.StatusBadRequest, "ms must be a non-negative integer")
+		return
+	}
+
+	d.mutex.Lock()
+	d.period = time.Duration(ms) * time.Millisecond
+	d.mutex.Unlock()
+
+	io.WriteString(res, fmt.Sprintf("Will drain for %d ms after SIGTERM", ms))
+}
+
+// ConnState counts the connections that are serving a request. It is meant to
+// be set as the http.Server's ConnState hook.
+func (d *Drainer) ConnState(conn net.Conn, state http.ConnState) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if state == http.StateActive {
+		d.active[conn] = true
+	} else {
+		delete(d.active, conn)
+	}
+}
+
+// Drain keeps serving new and in-flight requests for the configured period,
+// then shuts the server down, waiting for the remaining requests to finish.
+func (d *Drainer) Drain(server Shutdowner) error {
+	d.mutex.Lock()
+	period := d.period
+	d.mutex.Unlock()
+
+	d.log("received SIGTERM, serving for %s with %d requests in flight", period, d.InFlight())
+	d.clock.Sleep(period)
+
+	d.log("shutting down with %d requests in flight", d.InFlight())
+	err := server.Shutdown(context.Background())
+	if err != nil {
+		d.log("shutdown failed: %s", err)
+		return err
+	}
+
+	d.log("finished")
+	return nil
+}
+
+func (d *Drainer) InFlight() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return len(d.active)
+}
+
+func (d *Drainer) log(format string, args ...interface{}) {
+	fmt.Fprintf(d.out, "drain: %s %s\n", d.clock.Now().UTC().Format(time.RFC3339Nano), fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,118 @@
+package signal_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/identity"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/router"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/signal"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+type fakeServer struct {
+	shutdowns chan struct{}
+}
+
+func (s *fakeServer) Shutdown(ctx context.Context) error {
+	close(s.shutdowns)
+	return nil
+}
+
+var _ = Describe("Drainer", func() {
+	var (
+		fakeClock *fakeclock.FakeClock
+		out       *gbytes.Buffer
+		drainer   *signal.Drainer
+		server    *httptest.Server
+	)
+
+	BeforeEach(func() {
+		fakeClock = fakeclock.NewFakeClock(time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC))
+		out = gbytes.NewBuffer()
+		drainer = signal.NewDrainer(fakeClock, out)
+
+		server = httptest.NewUnstartedServer(router.NewWithDrainer(out, fakeClock, drainer, identity.NewWatcher(fakeClock, "", "")))
+		server.Config.ConnState = drainer.ConnState
+		server.Start()
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	getState := func() signal.DrainState {
+		res, err := http.Get(server.URL + "/drain")
+		Expect(err).NotTo(HaveOccurred())
+		defer res.Body.Close()
+
+		var state signal.DrainState
+		Expect(json.NewDecoder(res.Body).Decode(&state)).To(Succeed())
+		return state
+	}
+
+	setPeriod := func(ms string) *http.Response {
+		req, err := http.NewRequest(http.MethodPut, server.URL+"/drain/"+ms, nil)
+		Expect(err).NotTo(HaveOccurred())
+		res, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		res.Body.Close()
+		return res
+	}
+
+	Describe("the drain endpoints", func() {
+		It("reports and sets the drain period", func() {
+			Expect(getState().PeriodMs).To(Equal(0))
+
+			Expect(setPeriod("5000").StatusCode).To(Equal(http.StatusOK))
+			Expect(getState().PeriodMs).To(Equal(5000))
+		})
+
+		It("counts the request asking as in flight", func() {
+			Expect(getState().InFlight).To(Equal(1))
+		})
+
+		It("rejects invalid periods", func() {
+			Expect(setPeriod("-1").StatusCode).To(Equal(http.StatusBadRequest))
+			Expect(setPeriod("soon").StatusCode).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("Drain", func() {
+		It("keeps serving for the drain period before shutting down", func() {
+			setPeriod("5000")
+			fakeServer := &fakeServer{shutdowns: make(chan struct{})}
+
+			done := make(chan error)
+			go func() {
+				done <- drainer.Drain(fakeServer)
+			}()
+
+			Eventually(out).Should(gbytes.Say(`drain: 2019-01-02T03:04:05Z received SIGTERM, serving for 5s with 0 requests in flight`))
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			res, err := http.Get(server.URL + "/id")
+			Expect(err).NotTo(HaveOccurred())
+			ioutil.ReadAll(res.Body)
+			res.Body.Close()
+			Consistently(fakeServer.shutdowns).ShouldNot(BeClosed())
+
+			fakeClock.Increment(5 * time.Second)
+			Eventually(fakeServer.shutdowns).Should(BeClosed())
+			Eventually(done).Should(Receive(BeNil()))
+
+			lines := strings.Split(strings.TrimSpace(string(out.Contents())), "\n")
+			Expect(lines[len(lines)-2]).To(Equal("drain: 2019-01-02T03:04:10Z shutting down with 0 requests in flight"))
+			Expect(lines[len(lines)-1]).To(Equal("drain: 2019-01-02T03:04:10Z finished"))
+		})
+	})
+})
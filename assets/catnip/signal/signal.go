@@ -3,9 +3,16 @@ package signal
 import (
 	"net/http"
 	"os"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/httperr"
 )
 
 func KillHandler(res http.ResponseWriter, req *http.Request) {
-	currentProcess, _ := os.FindProcess(os.Getpid())
-	currentProcess.Kill()
+	currentProcess, err := os.FindProcess(os.Getpid())
+	if err == nil {
+		err = currentProcess.Kill()
+	}
+	if err != nil {
+		httperr.Write(res, http.StatusInternalServerError, "Failed to kill catnip: %s", err)
+	}
 }
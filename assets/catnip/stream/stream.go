@@ -0,0 +1,76 @@
+package stream
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/httperr"
+)
+
+// The gorouter forwards the Origin of whichever client connected, so any
+// origin is accepted.
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// WebSocketEchoHandler sends every message it receives straight back, with the
+// same message type, until the client goes away.
+func WebSocketEchoHandler(res http.ResponseWriter, req *http.Request) {
+	conn, err := upgrader.Upgrade(res, req, nil)
+	if err != nil {
+		// Upgrade has already written an error response.
+		return
+	}
+	defer conn.Close()
+
+	for {
+		messageType, message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		if err := conn.WriteMessage(messageType, message); err != nil {
+			return
+		}
+	}
+}
+
+// MakeEventsHandler streams count server-sent events, waiting intervalms
+// milliseconds between each one.
+func MakeEventsHandler(clock clock.Clock) func(http.ResponseWriter, *http.Request) {
+	return func(res http.ResponseWriter, req *http.Request) {
+		count, countErr := strconv.Atoi(mux.Vars(req)["count"])
+		interval, intervalErr := strconv.Atoi(mux.Vars(req)["intervalms"])
+		if countErr != nil || intervalErr != nil || count < 0 || interval < 0 {
+			httperr.Write(res, http.StatusBadRequest, "count and intervalms must be non-negative integers")
+			return
+		}
+
+		res.Header().Set("Content-Type", "text/event-stream")
+		res.Header().Set("Cache-Control", "no-cache")
+		res.WriteHeader(http.StatusOK)
+
+		for i := 1; i <= count; i++ {
+			if i > 1 {
+				timer := clock.NewTimer(time.Duration(interval) * time.Millisecond)
+				select {
+				case <-timer.C():
+				case <-req.Context().Done():
+					timer.Stop()
+					return
+				}
+			}
+
+			fmt.Fprintf(res, "id: %d\ndata: Event %d of %d\n\n", i, i, count)
+			if flusher, ok := res.(http.Flusher); ok {
+				flusher.Flush()
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package stream_test
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/router"
+	"github.com/gorilla/websocket"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stream", func() {
+	var (
+		fakeClock *fakeclock.FakeClock
+		server    *httptest.Server
+	)
+
+	BeforeEach(func() {
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		server = httptest.NewServer(router.New(os.Stdout, fakeClock))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("WebSocketEchoHandler", func() {
+		var conn *websocket.Conn
+
+		BeforeEach(func() {
+			var err error
+			conn, _, err = websocket.DefaultDialer.Dial(strings.Replace(server.URL, "http", "ws", 1)+"/websocket", nil)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			conn.Close()
+		})
+
+		It("echoes text and binary messages", func() {
+			Expect(conn.WriteMessage(websocket.TextMessage, []byte("hello catnip"))).To(Succeed())
+			messageType, message, err := conn.ReadMessage()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(messageType).To(Equal(websocket.TextMessage))
+			Expect(string(message)).To(Equal("hello catnip"))
+
+			Expect(conn.WriteMessage(websocket.BinaryMessage, []byte{0, 1, 2})).To(Succeed())
+			messageType, message, err = conn.ReadMessage()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(messageType).To(Equal(websocket.BinaryMessage))
+			Expect(message).To(Equal([]byte{0, 1, 2}))
+		})
+
+		It("closes the connection when the client closes it", func() {
+			closeMessage := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "bye")
+			Expect(conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(time.Second))).To(Succeed())
+
+			_, _, err := conn.ReadMessage()
+			Expect(websocket.IsCloseError(err, websocket.CloseNormalClosure)).To(BeTrue(), fmt.Sprintf("%v", err))
+		})
+	})
+
+	Describe("EventsHandler", func() {
+		It("streams the requested number of events at the given interval", func() {
+			res, err := http.Get(server.URL + "/events/3/1000")
+			Expect(err).NotTo(HaveOccurred())
+			defer res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+			Expect(res.Header.Get("Content-Type")).To(Equal("text/event-stream"))
+
+			events := make(chan string, 3)
+			go func() {
+				scanner := bufio.NewScanner(res.Body)
+				for scanner.Scan() {
+					if strings.HasPrefix(scanner.Text(), "data: ") {
+						events <- strings.TrimPrefix(scanner.Text(), "data: ")
+					}
+				}
+				close(events)
+			}()
+
+			Eventually(events).Should(Receive(Equal("Event 1 of 3")))
+			Consistently(events).ShouldNot(Receive())
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			fakeClock.Increment(time.Second)
+			Eventually(events).Should(Receive(Equal("Event 2 of 3")))
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			fakeClock.Increment(time.Second)
+			Eventually(events).Should(Receive(Equal("Event 3 of 3")))
+
+			Eventually(events).Should(BeClosed())
+		})
+
+		It("rejects a negative count", func() {
+			res, err := http.Get(server.URL + "/events/-1/1000")
+			Expect(err).NotTo(HaveOccurred())
+			res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+	})
+})
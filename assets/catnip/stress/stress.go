@@ -0,0 +1,169 @@
+package stress
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/gorilla/mux"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/httperr"
+)
+
+const mb = 1024 * 1024
+
+// Stresser consumes memory, CPU and disk on demand. Memory and disk stay
+// allocated until released so that limits and metrics can be observed.
+// Progress is streamed as it is made so that callers can tell how far it got
+// if the kernel or a quota steps in.
+type Stresser struct {
+	clock clock.Clock
+	dir   string
+
+	mutex     sync.Mutex
+	memory    [][]byte
+	diskFiles []string
+}
+
+func NewStresser(clock clock.Clock, dir string) *Stresser {
+	return &Stresser{
+		clock: clock,
+		dir:   dir,
+	}
+}
+
+func (s *Stresser) MemoryHandler(res http.ResponseWriter, req *http.Request) {
+	megabytes, err := strconv.Atoi(mux.Vars(req)["mb"])
+	if err != nil || megabytes < 0 {
+		httperr.Write(res, http.StatusBadRequest, "mb must be a non-negative integer")
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i := 1; i <= megabytes; i++ {
+		chunk := make([]byte, mb)
+		// Touch every page so that the memory is resident, not just reserved.
+		for j := 0; j < len(chunk); j += os.Getpagesize() {
+			chunk[j] = 1
+		}
+		s.memory = append(s.memory, chunk)
+
+		fmt.Fprintf(res, "Allocated %d MB\n", i)
+		flush(res)
+	}
+
+	fmt.Fprintf(res, "Holding %d MB", len(s.memory))
+}
+
+func (s *Stresser) ReleaseMemoryHandler(res http.ResponseWriter, req *http.Request) {
+	s.mutex.Lock()
+	released := len(s.memory)
+	s.memory = nil
+	s.mutex.Unlock()
+
+	io.WriteString(res, fmt.Sprintf("Released %d MB", released))
+}
+
+func (s *Stresser) CPUHandler(res http.ResponseWriter, req *http.Request) {
+	cores, coresErr := strconv.Atoi(mux.Vars(req)["cores"])
+	seconds, secondsErr := strconv.Atoi(mux.Vars(req)["seconds"])
+	if coresErr != nil || secondsErr != nil || cores < 1 || seconds < 0 {
+		httperr.Write(res, http.StatusBadRequest, "cores must be positive and seconds must be a non-negative integer")
+		return
+	}
+
+	done := make(chan struct{})
+	iterations := make(chan int, cores)
+	for i := 0; i < cores; i++ {
+		go burn(done, iterations)
+	}
+
+	<-s.clock.NewTimer(time.Duration(seconds) * time.Second).C()
+	close(done)
+
+	total := 0
+	for i := 0; i < cores; i++ {
+		total += <-iterations
+	}
+
+	io.WriteString(res, fmt.Sprintf("Burned %d cores for %d seconds (%d iterations)", cores, seconds, total))
+}
+
+func (s *Stresser) DiskHandler(res http.ResponseWriter, req *http.Request) {
+	megabytes, err := strconv.Atoi(mux.Vars(req)["mb"])
+	if err != nil || megabytes < 0 {
+		httperr.Write(res, http.StatusBadRequest, "mb must be a non-negative integer")
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	file, err := ioutil.TempFile(s.dir, "catnip-disk-stress")
+	if err != nil {
+		httperr.Write(res, http.StatusInternalServerError, "Failed to create file: %s", err)
+		return
+	}
+	defer file.Close()
+	s.diskFiles = append(s.diskFiles, file.Name())
+
+	chunk := make([]byte, mb)
+	for i := range chunk {
+		chunk[i] = '1'
+	}
+
+	written := 0
+	for written < megabytes {
+		_, err := file.Write(chunk)
+		if err == nil {
+			err = file.Sync()
+		}
+		if err != nil {
+			httperr.Write(res, http.StatusInsufficientStorage, "Wrote %d MB to %s before error: %s", written, file.Name(), err)
+			return
+		}
+		written++
+	}
+
+	io.WriteString(res, fmt.Sprintf("Wrote %d MB to %s", written, file.Name()))
+}
+
+func (s *Stresser) ReleaseDiskHandler(res http.ResponseWriter, req *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, name := range s.diskFiles {
+		os.Remove(name)
+	}
+	released := len(s.diskFiles)
+	s.diskFiles = nil
+
+	io.WriteString(res, fmt.Sprintf("Removed %d files", released))
+}
+
+func burn(done <-chan struct{}, iterations chan<- int) {
+	count := 0
+	for {
+		select {
+		case <-done:
+			iterations <- count
+			return
+		default:
+			count++
+		}
+	}
+}
+
+func flush(res http.ResponseWriter) {
+	if flusher, ok := res.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
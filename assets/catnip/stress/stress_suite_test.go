@@ -0,0 +1,13 @@
+package stress_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestStress(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Stress Suite")
+}
@@ -0,0 +1,134 @@
+package stress_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/router"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stress", func() {
+	var (
+		fakeClock   *fakeclock.FakeClock
+		server      *httptest.Server
+		tmpDir      string
+		originalTmp string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "catnip-stress")
+		Expect(err).NotTo(HaveOccurred())
+		originalTmp = os.Getenv("TMPDIR")
+		os.Setenv("TMPDIR", tmpDir)
+
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		server = httptest.NewServer(router.New(os.Stdout, fakeClock))
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.Setenv("TMPDIR", originalTmp)
+		os.RemoveAll(tmpDir)
+	})
+
+	Describe("MemoryHandler", func() {
+		It("allocates and reports each megabyte", func() {
+			status, body := request(http.MethodGet, fmt.Sprintf("%s/stress/memory/3", server.URL))
+			Expect(status).To(Equal(http.StatusOK))
+			Expect(body).To(Equal("Allocated 1 MB\nAllocated 2 MB\nAllocated 3 MB\nHolding 3 MB"))
+		})
+
+		It("holds memory across requests until released", func() {
+			request(http.MethodGet, fmt.Sprintf("%s/stress/memory/2", server.URL))
+			_, body := request(http.MethodGet, fmt.Sprintf("%s/stress/memory/1", server.URL))
+			Expect(body).To(HaveSuffix("Holding 3 MB"))
+
+			_, body = request(http.MethodDelete, fmt.Sprintf("%s/stress/memory", server.URL))
+			Expect(body).To(Equal("Released 3 MB"))
+		})
+
+		It("rejects invalid sizes", func() {
+			status, _ := request(http.MethodGet, fmt.Sprintf("%s/stress/memory/lots", server.URL))
+			Expect(status).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("CPUHandler", func() {
+		It("burns the requested cores for the requested duration", func() {
+			done := make(chan string)
+			go func() {
+				defer GinkgoRecover()
+				_, body := request(http.MethodGet, fmt.Sprintf("%s/stress/cpu/2/5", server.URL))
+				done <- body
+			}()
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			Consistently(done).ShouldNot(Receive())
+
+			fakeClock.Increment(5 * time.Second)
+
+			var body string
+			Eventually(done).Should(Receive(&body))
+			Expect(body).To(MatchRegexp(`^Burned 2 cores for 5 seconds \(\d+ iterations\)$`))
+		})
+
+		It("rejects invalid core counts", func() {
+			status, _ := request(http.MethodGet, fmt.Sprintf("%s/stress/cpu/0/5", server.URL))
+			Expect(status).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("DiskHandler", func() {
+		It("fills the ephemeral disk and reports what was written", func() {
+			status, body := request(http.MethodGet, fmt.Sprintf("%s/stress/disk/2", server.URL))
+			Expect(status).To(Equal(http.StatusOK))
+			Expect(body).To(HavePrefix("Wrote 2 MB to " + tmpDir))
+
+			files, err := filepath.Glob(filepath.Join(tmpDir, "catnip-disk-stress*"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(HaveLen(1))
+
+			info, err := os.Stat(files[0])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Size()).To(Equal(int64(2 * 1024 * 1024)))
+		})
+
+		It("removes the files when released", func() {
+			request(http.MethodGet, fmt.Sprintf("%s/stress/disk/1", server.URL))
+			request(http.MethodGet, fmt.Sprintf("%s/stress/disk/1", server.URL))
+
+			_, body := request(http.MethodDelete, fmt.Sprintf("%s/stress/disk", server.URL))
+			Expect(body).To(Equal("Removed 2 files"))
+
+			files, err := filepath.Glob(filepath.Join(tmpDir, "catnip-disk-stress*"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(BeEmpty())
+		})
+	})
+})
+
+func request(method, url string) (int, string) {
+	req, err := http.NewRequest(method, url, nil)
+	Expect(err).NotTo(HaveOccurred())
+
+	res, err := http.DefaultClient.Do(req)
+	Expect(err).NotTo(HaveOccurred())
+	defer res.Body.Close()
+
+	bodyBuf := bytes.NewBuffer([]byte{})
+	_, err = bodyBuf.ReadFrom(res.Body)
+	Expect(err).NotTo(HaveOccurred())
+
+	return res.StatusCode, bodyBuf.String()
+}
@@ -6,10 +6,16 @@ import (
 	"strconv"
 
 	"github.com/gorilla/mux"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/httperr"
 )
 
 func LargeHandler(res http.ResponseWriter, req *http.Request) {
-	kbytes, _ := strconv.Atoi(mux.Vars(req)["kbytes"])
+	kbytes, err := strconv.Atoi(mux.Vars(req)["kbytes"])
+	if err != nil || kbytes < 0 {
+		httperr.Write(res, http.StatusBadRequest, "kbytes must be a non-negative integer")
+		return
+	}
 
 	k := make([]byte, 1024)
 	for i := range k {
@@ -38,5 +38,13 @@ var _ = Describe("Text", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(bodyBuf.Len()).To(Equal(4096))
 		})
+
+		It("rejects negative sizes", func() {
+			res, err := http.Get(fmt.Sprintf("%s/largetext/-1", server.URL))
+			Expect(err).NotTo(HaveOccurred())
+			res.Body.Close()
+
+			Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+		})
 	})
 })
@@ -0,0 +1,147 @@
+package transfer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/gorilla/mux"
+
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/httperr"
+)
+
+// trickleInterval is how often a trickled response sends its next slice.
+const trickleInterval = 100 * time.Millisecond
+
+// Upload is what /upload reports about the body it received.
+type Upload struct {
+	Bytes  int64  `json:"bytes"`
+	SHA256 string `json:"sha256"`
+}
+
+// MakeDelayHandler waits ms milliseconds before sending anything, so that
+// the router sees no response headers until then.
+func MakeDelayHandler(clock clock.Clock) func(http.ResponseWriter, *http.Request) {
+	return func(res http.ResponseWriter, req *http.Request) {
+		ms, err := strconv.Atoi(mux.Vars(req)["ms"])
+		if err != nil || ms < 0 {
+			httperr.Write(res, http.StatusBadRequest, "ms must be a non-negative integer")
+			return
+		}
+
+		if !wait(clock, req, time.Duration(ms)*time.Millisecond) {
+			return
+		}
+
+		io.WriteString(res, fmt.Sprintf("Waited %d ms", ms))
+	}
+}
+
+// MakeTrickleHandler sends a body of the given size at roughly rate bytes
+// per second. The length is announced up front so that clients can tell a
+// slow response from a truncated one.
+func MakeTrickleHandler(clock clock.Clock) func(http.ResponseWriter, *http.Request) {
+	return func(res http.ResponseWriter, req *http.Request) {
+		size, sizeErr := strconv.Atoi(mux.Vars(req)["bytes"])
+		rate, rateErr := strconv.Atoi(mux.Vars(req)["rate"])
+		if sizeErr != nil || rateErr != nil || size < 0 || rate < 1 {
+			httperr.Write(res, http.StatusBadRequest, "bytes must be a non-negative integer and rate must be positive")
+			return
+		}
+
+		slice := rate * int(trickleInterval) / int(time.Second)
+		if slice < 1 {
+			slice = 1
+		}
+
+		res.Header().Set("Content-Length", strconv.Itoa(size))
+		res.WriteHeader(http.StatusOK)
+
+		for sent := 0; sent < size; sent += slice {
+			if sent > 0 && !wait(clock, req, trickleInterval) {
+				return
+			}
+
+			n := slice
+			if size-sent < n {
+				n = size - sent
+			}
+			io.WriteString(res, strings.Repeat("1", n))
+			flush(res)
+		}
+	}
+}
+
+// MakeChunkedHandler streams count chunks of size bytes without a
+// Content-Length, so the body is sent with chunked transfer encoding. The
+// interval_ms query parameter spaces the chunks out.
+func MakeChunkedHandler(clock clock.Clock) func(http.ResponseWriter, *http.Request) {
+	return func(res http.ResponseWriter, req *http.Request) {
+		count, countErr := strconv.Atoi(mux.Vars(req)["count"])
+		size, sizeErr := strconv.Atoi(mux.Vars(req)["size"])
+		interval := 0
+		var intervalErr error
+		if value := req.URL.Query().Get("interval_ms"); value != "" {
+			interval, intervalErr = strconv.Atoi(value)
+		}
+		if countErr != nil || sizeErr != nil || intervalErr != nil || count < 0 || size < 1 || interval < 0 {
+			httperr.Write(res, http.StatusBadRequest, "count and interval_ms must be non-negative integers and size must be positive")
+			return
+		}
+
+		chunk := strings.Repeat("1", size)
+		res.WriteHeader(http.StatusOK)
+
+		for i := 0; i < count; i++ {
+			if i > 0 && interval > 0 && !wait(clock, req, time.Duration(interval)*time.Millisecond) {
+				return
+			}
+
+			io.WriteString(res, chunk)
+			flush(res)
+		}
+	}
+}
+
+// UploadHandler reads the whole request body and reports how many bytes
+// arrived and their SHA-256, so callers can check nothing was lost or
+// altered on the way.
+func UploadHandler(res http.ResponseWriter, req *http.Request) {
+	hash := sha256.New()
+	n, err := io.Copy(hash, req.Body)
+	if err != nil {
+		httperr.Write(res, http.StatusBadRequest, "Failed to read body after %d bytes: %s", n, err)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(res).Encode(Upload{
+		Bytes:  n,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+	})
+}
+
+// wait reports whether the full duration passed before the client went away.
+func wait(clock clock.Clock, req *http.Request, d time.Duration) bool {
+	timer := clock.NewTimer(d)
+	select {
+	case <-timer.C():
+		return true
+	case <-req.Context().Done():
+		timer.Stop()
+		return false
+	}
+}
+
+func flush(res http.ResponseWriter) {
+	if flusher, ok := res.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,184 @@
+package transfer_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/router"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/transfer"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Transfer", func() {
+	var (
+		fakeClock *fakeclock.FakeClock
+		server    *httptest.Server
+	)
+
+	BeforeEach(func() {
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		server = httptest.NewServer(router.New(os.Stdout, fakeClock))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("DelayHandler", func() {
+		It("sends nothing until the delay has passed", func() {
+			responses := make(chan *http.Response, 1)
+			go func() {
+				defer GinkgoRecover()
+				res, err := http.Get(server.URL + "/slow/1500")
+				Expect(err).NotTo(HaveOccurred())
+				responses <- res
+			}()
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			fakeClock.Increment(time.Second)
+			Consistently(responses).ShouldNot(Receive())
+
+			fakeClock.Increment(500 * time.Millisecond)
+			var res *http.Response
+			Eventually(responses).Should(Receive(&res))
+			defer res.Body.Close()
+
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+			Expect(ioutil.ReadAll(res.Body)).To(Equal([]byte("Waited 1500 ms")))
+		})
+
+		It("rejects a negative delay", func() {
+			res, err := http.Get(server.URL + "/slow/-1")
+			Expect(err).NotTo(HaveOccurred())
+			res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("TrickleHandler", func() {
+		It("sends the body a slice at a time at the requested rate", func() {
+			res, err := http.Get(server.URL + "/trickle/25/100")
+			Expect(err).NotTo(HaveOccurred())
+			defer res.Body.Close()
+			Expect(res.ContentLength).To(Equal(int64(25)))
+
+			received := make(chan int, 3)
+			go func() {
+				buf := make([]byte, 100)
+				for {
+					n, err := res.Body.Read(buf)
+					if n > 0 {
+						received <- n
+					}
+					if err != nil {
+						close(received)
+						return
+					}
+				}
+			}()
+
+			Eventually(received).Should(Receive(Equal(10)))
+			Consistently(received).ShouldNot(Receive())
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			fakeClock.Increment(100 * time.Millisecond)
+			Eventually(received).Should(Receive(Equal(10)))
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			fakeClock.Increment(100 * time.Millisecond)
+			Eventually(received).Should(Receive(Equal(5)))
+			Eventually(received).Should(BeClosed())
+		})
+
+		It("rejects a rate of zero", func() {
+			res, err := http.Get(server.URL + "/trickle/25/0")
+			Expect(err).NotTo(HaveOccurred())
+			res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("ChunkedHandler", func() {
+		It("streams the chunks without a content length", func() {
+			res, err := http.Get(server.URL + "/chunked/4/1024")
+			Expect(err).NotTo(HaveOccurred())
+			defer res.Body.Close()
+
+			Expect(res.TransferEncoding).To(Equal([]string{"chunked"}))
+			Expect(res.ContentLength).To(Equal(int64(-1)))
+			Expect(ioutil.ReadAll(res.Body)).To(HaveLen(4 * 1024))
+		})
+
+		It("waits between chunks when asked to", func() {
+			res, err := http.Get(server.URL + "/chunked/2/3?interval_ms=1000")
+			Expect(err).NotTo(HaveOccurred())
+			defer res.Body.Close()
+
+			body := make(chan []byte, 1)
+			go func() {
+				contents, _ := ioutil.ReadAll(res.Body)
+				body <- contents
+			}()
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			Consistently(body).ShouldNot(Receive())
+
+			fakeClock.Increment(time.Second)
+			Eventually(body).Should(Receive(Equal([]byte("111111"))))
+		})
+
+		It("rejects an empty chunk size", func() {
+			res, err := http.Get(server.URL + "/chunked/4/0")
+			Expect(err).NotTo(HaveOccurred())
+			res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("UploadHandler", func() {
+		It("reports the size and hash of the body it received", func() {
+			body := strings.Repeat("catnip", 100000)
+			sum := sha256.Sum256([]byte(body))
+
+			res, err := http.Post(server.URL+"/upload", "application/octet-stream", strings.NewReader(body))
+			Expect(err).NotTo(HaveOccurred())
+			defer res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+			Expect(res.Header.Get("Content-Type")).To(Equal("application/json"))
+
+			var upload transfer.Upload
+			Expect(json.NewDecoder(res.Body).Decode(&upload)).To(Succeed())
+			Expect(upload).To(Equal(transfer.Upload{
+				Bytes:  int64(len(body)),
+				SHA256: hex.EncodeToString(sum[:]),
+			}))
+		})
+
+		It("accepts bodies sent with chunked transfer encoding", func() {
+			reader, writer := io.Pipe()
+			go func() {
+				io.WriteString(writer, "hello ")
+				io.WriteString(writer, "world")
+				writer.Close()
+			}()
+
+			res, err := http.Post(server.URL+"/upload", "application/octet-stream", reader)
+			Expect(err).NotTo(HaveOccurred())
+			defer res.Body.Close()
+
+			var upload transfer.Upload
+			Expect(json.NewDecoder(res.Body).Decode(&upload)).To(Succeed())
+			Expect(upload.Bytes).To(Equal(int64(11)))
+		})
+	})
+})
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/credhub-cli/credhub"
+	"github.com/cloudfoundry-incubator/credhub-cli/credhub/credentials/values"
+	"github.com/cloudfoundry-incubator/credhub-cli/credhub/permissions"
+	"github.com/gorilla/mux"
+)
+
+const planName = "credhub-read-plan"
+
+// Catalog is the single service and plan the broker offers.
+type Catalog struct {
+	ServiceName string
+	ServiceID   string
+	PlanID      string
+}
+
+type ServiceInstance struct {
+	ServiceID string `json:"service_id"`
+	PlanID    string `json:"plan_id"`
+}
+
+// Binding is either an app binding or, when it has no AppGuid, a service
+// key.
+type Binding struct {
+	InstanceGuid   string
+	AppGuid        string
+	CredentialName string
+}
+
+type ErrorResponse struct {
+	Error       string `json:"error,omitempty"`
+	Description string `json:"description"`
+}
+
+type provisionRequest struct {
+	ServiceID string `json:"service_id"`
+	PlanID    string `json:"plan_id"`
+}
+
+type bindRequest struct {
+	AppGuid      string `json:"app_guid"`
+	BindResource struct {
+		CredentialClientId string `json:"credential_client_id"`
+	} `json:"bind_resource"`
+}
+
+type ServiceBroker struct {
+	catalog    Catalog
+	newCredHub func() (*credhub.CredHub, error)
+
+	mutex     sync.Mutex
+	instances map[string]ServiceInstance
+	bindings  map[string]Binding
+	// creating holds the GUIDs of bindings whose credential is still being
+	// stored, so that a second request for one cannot store it again.
+	creating map[string]bool
+}
+
+// NewServiceBroker returns a broker that stores binding credentials in the
+// CredHub returned by newCredHub.
+func NewServiceBroker(catalog Catalog, newCredHub func() (*credhub.CredHub, error)) *ServiceBroker {
+	return &ServiceBroker{
+		catalog:    catalog,
+		newCredHub: newCredHub,
+		instances:  make(map[string]ServiceInstance),
+		bindings:   make(map[string]Binding),
+		creating:   make(map[string]bool),
+	}
+}
+
+func (s *ServiceBroker) Router() *mux.Router {
+	router := mux.NewRouter()
+
+	router.HandleFunc("/v2/catalog", s.Catalog).Methods("GET")
+	router.HandleFunc("/v2/service_instances/{service_instance_guid}", s.CreateServiceInstance).Methods("PUT")
+	router.HandleFunc("/v2/service_instances/{service_instance_guid}", s.GetServiceInstance).Methods("GET")
+	router.HandleFunc("/v2/service_instances/{service_instance_guid}", s.RemoveServiceInstance).Methods("DELETE")
+	router.HandleFunc("/v2/service_instances/{service_instance_guid}/service_bindings/{service_binding_guid}", s.Bind).Methods("PUT")
+	router.HandleFunc("/v2/service_instances/{service_instance_guid}/service_bindings/{service_binding_guid}", s.GetBinding).Methods("GET")
+	router.HandleFunc("/v2/service_instances/{service_instance_guid}/service_bindings/{service_binding_guid}", s.UnBind).Methods("DELETE")
+
+	return router
+}
+
+func WriteResponse(w http.ResponseWriter, code int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+// WriteError responds with an Open Service Broker error body.
+func WriteError(w http.ResponseWriter, code int, description string) {
+	WriteResponse(w, code, ErrorResponse{Description: description})
+}
+
+// WriteConcurrencyError tells the platform that another request for the same
+// binding is in progress and that it should retry later.
+func WriteConcurrencyError(w http.ResponseWriter, description string) {
+	WriteResponse(w, http.StatusUnprocessableEntity, ErrorResponse{Error: "ConcurrencyError", Description: description})
+}
+
+func (s *ServiceBroker) Catalog(w http.ResponseWriter, r *http.Request) {
+	WriteResponse(w, http.StatusOK, map[string]interface{}{
+		"services": []map[string]interface{}{{
+			"name":                  s.catalog.ServiceName,
+			"id":                    s.catalog.ServiceID,
+			"description":           "credhub read service for tests",
+			"bindable":              true,
+			"instances_retrievable": true,
+			"bindings_retrievable":  true,
+			"plans": []map[string]interface{}{{
+				"name":        planName,
+				"id":          s.catalog.PlanID,
+				"description": "credhub read service for tests",
+			}},
+		}},
+	})
+}
+
+func (s *ServiceBroker) CreateServiceInstance(w http.ResponseWriter, r *http.Request) {
+	body := provisionRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		WriteError(w, http.StatusBadRequest, "invalid provision request: "+err.Error())
+		return
+	}
+	if body.ServiceID != s.catalog.ServiceID || body.PlanID != s.catalog.PlanID {
+		WriteError(w, http.StatusBadRequest, fmt.Sprintf("unknown service %q or plan %q", body.ServiceID, body.PlanID))
+		return
+	}
+
+	guid := mux.Vars(r)["service_instance_guid"]
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// There is only the one plan, so an existing instance is always
+	// identical to the one requested.
+	if _, ok := s.instances[guid]; ok {
+		WriteResponse(w, http.StatusOK, struct{}{})
+		return
+	}
+
+	s.instances[guid] = ServiceInstance{ServiceID: body.ServiceID, PlanID: body.PlanID}
+	WriteResponse(w, http.StatusCreated, struct{}{})
+}
+
+func (s *ServiceBroker) GetServiceInstance(w http.ResponseWriter, r *http.Request) {
+	guid := mux.Vars(r)["service_instance_guid"]
+
+	s.mutex.Lock()
+	instance, ok := s.instances[guid]
+	s.mutex.Unlock()
+
+	if !ok {
+		WriteError(w, http.StatusNotFound, "service instance "+guid+" does not exist")
+		return
+	}
+	WriteResponse(w, http.StatusOK, instance)
+}
+
+func (s *ServiceBroker) RemoveServiceInstance(w http.ResponseWriter, r *http.Request) {
+	guid := mux.Vars(r)["service_instance_guid"]
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.instances[guid]; !ok {
+		WriteResponse(w, http.StatusGone, struct{}{})
+		return
+	}
+
+	delete(s.instances, guid)
+	WriteResponse(w, http.StatusOK, struct{}{})
+}
+
+// Bind stores a credential in CredHub and responds with a reference to it.
+// App bindings grant the app's instance identity read access to it, and
+// service keys grant it to the UAA client Cloud Controller reads it with.
+func (s *ServiceBroker) Bind(w http.ResponseWriter, r *http.Request) {
+	pathVariables := mux.Vars(r)
+	instanceGuid := pathVariables["service_instance_guid"]
+	bindingGuid := pathVariables["service_binding_guid"]
+
+	body := bindRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		WriteError(w, http.StatusBadRequest, "invalid bind request: "+err.Error())
+		return
+	}
+
+	s.mutex.Lock()
+	existing, ok := s.bindings[bindingGuid]
+	creating := s.creating[bindingGuid]
+	if !ok && !creating {
+		s.creating[bindingGuid] = true
+	}
+	s.mutex.Unlock()
+
+	if ok {
+		if existing.InstanceGuid != instanceGuid || existing.AppGuid != body.AppGuid {
+			WriteError(w, http.StatusConflict, "service binding "+bindingGuid+" already exists with different attributes")
+			return
+		}
+		WriteResponse(w, http.StatusOK, credentialsResponse(existing))
+		return
+	}
+	if creating {
+		WriteConcurrencyError(w, "service binding "+bindingGuid+" is being created")
+		return
+	}
+
+	// Whether or not the credential is stored, the binding is no longer
+	// being created once Bind returns.
+	defer func() {
+		s.mutex.Lock()
+		delete(s.creating, bindingGuid)
+		s.mutex.Unlock()
+	}()
+
+	ch, err := s.newCredHub()
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "credhub client configuration failed: "+err.Error())
+		return
+	}
+
+	storedJson := values.JSON{}
+	storedJson["user-name"] = "pinkyPie"
+	storedJson["password"] = "rainbowDash"
+
+	cred, err := ch.SetJSON(fmt.Sprintf("/credhub-service-broker/%s/%s", instanceGuid, bindingGuid), storedJson, credhub.Overwrite)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "failed to store the credential in CredHub: "+err.Error())
+		return
+	}
+
+	actor := ""
+	if body.AppGuid != "" {
+		actor = "mtls-app:" + body.AppGuid
+	} else if body.BindResource.CredentialClientId != "" {
+		actor = "uaa-client:" + body.BindResource.CredentialClientId
+	}
+
+	if actor != "" {
+		_, err = ch.AddPermissions(cred.Name, []permissions.Permission{{
+			Actor:      actor,
+			Operations: []string{"read"},
+		}})
+		if err != nil {
+			ch.Delete(cred.Name)
+			WriteError(w, http.StatusInternalServerError, "failed to grant "+actor+" access to the credential in CredHub: "+err.Error())
+			return
+		}
+	}
+
+	binding := Binding{InstanceGuid: instanceGuid, AppGuid: body.AppGuid, CredentialName: cred.Name}
+
+	s.mutex.Lock()
+	s.bindings[bindingGuid] = binding
+	s.mutex.Unlock()
+
+	WriteResponse(w, http.StatusCreated, credentialsResponse(binding))
+}
+
+func (s *ServiceBroker) GetBinding(w http.ResponseWriter, r *http.Request) {
+	pathVariables := mux.Vars(r)
+	bindingGuid := pathVariables["service_binding_guid"]
+
+	s.mutex.Lock()
+	binding, ok := s.bindings[bindingGuid]
+	s.mutex.Unlock()
+
+	if !ok || binding.InstanceGuid != pathVariables["service_instance_guid"] {
+		WriteError(w, http.StatusNotFound, "service binding "+bindingGuid+" does not exist")
+		return
+	}
+	WriteResponse(w, http.StatusOK, credentialsResponse(binding))
+}
+
+func (s *ServiceBroker) UnBind(w http.ResponseWriter, r *http.Request) {
+	bindingGuid := mux.Vars(r)["service_binding_guid"]
+
+	s.mutex.Lock()
+	binding, ok := s.bindings[bindingGuid]
+	creating := s.creating[bindingGuid]
+	s.mutex.Unlock()
+
+	if creating {
+		WriteConcurrencyError(w, "service binding "+bindingGuid+" is being created")
+		return
+	}
+	if !ok {
+		WriteResponse(w, http.StatusGone, struct{}{})
+		return
+	}
+
+	ch, err := s.newCredHub()
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "credhub client configuration failed: "+err.Error())
+		return
+	}
+
+	// The binding is kept until its credential is gone, so that Cloud
+	// Controller can retry the unbind.
+	if err := ch.Delete(binding.CredentialName); err != nil {
+		WriteError(w, http.StatusInternalServerError, "failed to delete the credential from CredHub: "+err.Error())
+		return
+	}
+
+	s.mutex.Lock()
+	delete(s.bindings, bindingGuid)
+	s.mutex.Unlock()
+
+	WriteResponse(w, http.StatusOK, struct{}{})
+}
+
+func credentialsResponse(binding Binding) interface{} {
+	return map[string]interface{}{
+		"credentials": map[string]string{
+			"credhub-ref": binding.CredentialName,
+		},
+	}
+}
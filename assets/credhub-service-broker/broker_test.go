@@ -0,0 +1,370 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/credhub-cli/credhub"
+	"github.com/cloudfoundry-incubator/credhub-cli/credhub/permissions"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeCredHub serves the parts of the CredHub API the broker uses.
+type fakeCredHub struct {
+	mutex       sync.Mutex
+	credentials map[string]map[string]interface{}
+	permissions map[string][]permissions.Permission
+	requests    int
+	failWith    int
+
+	// When release is set, requests wait for it to be closed, after telling
+	// arrived that they have.
+	release chan struct{}
+	arrived chan struct{}
+}
+
+func newFakeCredHub() *fakeCredHub {
+	return &fakeCredHub{
+		credentials: make(map[string]map[string]interface{}),
+		permissions: make(map[string][]permissions.Permission),
+	}
+}
+
+func (f *fakeCredHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer GinkgoRecover()
+	if f.release != nil {
+		select {
+		case f.arrived <- struct{}{}:
+		default:
+		}
+		<-f.release
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.requests++
+	if f.failWith != 0 {
+		w.WriteHeader(f.failWith)
+		fmt.Fprint(w, `{"error":"CredHub is unavailable"}`)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPut && r.URL.Path == "/api/v1/data":
+		var body struct {
+			Name  string                 `json:"name"`
+			Type  string                 `json:"type"`
+			Value map[string]interface{} `json:"value"`
+		}
+		Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+		Expect(body.Type).To(Equal("json"))
+
+		name := "/" + strings.TrimPrefix(body.Name, "/")
+		f.credentials[name] = body.Value
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": "1", "name": name, "type": body.Type, "value": body.Value})
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v1/permissions":
+		var body struct {
+			CredentialName string                   `json:"credential_name"`
+			Permissions    []permissions.Permission `json:"permissions"`
+		}
+		Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+
+		f.permissions[body.CredentialName] = append(f.permissions[body.CredentialName], body.Permissions...)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, "{}")
+	case r.Method == http.MethodDelete && r.URL.Path == "/api/v1/data":
+		name := r.URL.Query().Get("name")
+		if _, ok := f.credentials[name]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":"The request could not be completed because the credential does not exist or you do not have sufficient authorization."}`)
+			return
+		}
+		delete(f.credentials, name)
+		delete(f.permissions, name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":"not found"}`)
+	}
+}
+
+var _ = Describe("ServiceBroker", func() {
+	const (
+		instanceGuid = "instance-guid"
+		bindingGuid  = "binding-guid"
+		appGuid      = "app-guid"
+	)
+
+	var (
+		fake        *fakeCredHub
+		credhubSrv  *httptest.Server
+		brokerSrv   *httptest.Server
+		catalog     Catalog
+		bindingPath = "/v2/service_instances/" + instanceGuid + "/service_bindings/" + bindingGuid
+	)
+
+	request := func(method, path, body string, response interface{}) int {
+		req, err := http.NewRequest(method, brokerSrv.URL+path, strings.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		res, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer res.Body.Close()
+
+		Expect(res.Header.Get("Content-Type")).To(Equal("application/json"))
+		if response != nil {
+			Expect(json.NewDecoder(res.Body).Decode(response)).To(Succeed())
+		}
+		return res.StatusCode
+	}
+
+	provision := func() int {
+		body := fmt.Sprintf(`{"service_id":%q,"plan_id":%q}`, catalog.ServiceID, catalog.PlanID)
+		return request("PUT", "/v2/service_instances/"+instanceGuid, body, nil)
+	}
+
+	credhubRef := func(response map[string]map[string]string) string {
+		return response["credentials"]["credhub-ref"]
+	}
+
+	BeforeEach(func() {
+		fake = newFakeCredHub()
+		credhubSrv = httptest.NewServer(fake)
+
+		catalog = Catalog{ServiceName: "credhub-read", ServiceID: "service-id", PlanID: "plan-id"}
+		broker := NewServiceBroker(catalog, func() (*credhub.CredHub, error) {
+			return credhub.New(credhubSrv.URL)
+		})
+		brokerSrv = httptest.NewServer(broker.Router())
+	})
+
+	AfterEach(func() {
+		brokerSrv.Close()
+		credhubSrv.Close()
+	})
+
+	Describe("the catalog", func() {
+		It("offers a retrievable service with the configured IDs", func() {
+			var response struct {
+				Services []struct {
+					Name                 string `json:"name"`
+					ID                   string `json:"id"`
+					InstancesRetrievable bool   `json:"instances_retrievable"`
+					BindingsRetrievable  bool   `json:"bindings_retrievable"`
+					Plans                []struct {
+						Name string `json:"name"`
+						ID   string `json:"id"`
+					} `json:"plans"`
+				} `json:"services"`
+			}
+			Expect(request("GET", "/v2/catalog", "", &response)).To(Equal(http.StatusOK))
+
+			Expect(response.Services).To(HaveLen(1))
+			service := response.Services[0]
+			Expect(service.Name).To(Equal("credhub-read"))
+			Expect(service.ID).To(Equal("service-id"))
+			Expect(service.InstancesRetrievable).To(BeTrue())
+			Expect(service.BindingsRetrievable).To(BeTrue())
+			Expect(service.Plans).To(HaveLen(1))
+			Expect(service.Plans[0].Name).To(Equal("credhub-read-plan"))
+			Expect(service.Plans[0].ID).To(Equal("plan-id"))
+		})
+
+		Describe("CatalogFromEnv", func() {
+			AfterEach(func() {
+				os.Unsetenv("SERVICE_NAME")
+				os.Unsetenv("SERVICE_ID")
+				os.Unsetenv("PLAN_ID")
+			})
+
+			It("derives the same IDs from the service name every time", func() {
+				os.Setenv("SERVICE_NAME", "a-service")
+				first := CatalogFromEnv()
+				Expect(CatalogFromEnv()).To(Equal(first))
+				Expect(first.ServiceName).To(Equal("a-service"))
+				Expect(first.ServiceID).NotTo(Equal(first.PlanID))
+
+				os.Setenv("SERVICE_NAME", "another-service")
+				second := CatalogFromEnv()
+				Expect(second.ServiceID).NotTo(Equal(first.ServiceID))
+				Expect(second.PlanID).NotTo(Equal(first.PlanID))
+			})
+
+			It("uses the IDs it is given", func() {
+				os.Setenv("SERVICE_ID", "a-service-id")
+				os.Setenv("PLAN_ID", "a-plan-id")
+				Expect(CatalogFromEnv()).To(Equal(Catalog{ServiceName: "credhub-read", ServiceID: "a-service-id", PlanID: "a-plan-id"}))
+			})
+		})
+	})
+
+	Describe("service instances", func() {
+		It("provisions, fetches and deprovisions an instance", func() {
+			Expect(provision()).To(Equal(http.StatusCreated))
+			Expect(provision()).To(Equal(http.StatusOK))
+
+			var instance ServiceInstance
+			Expect(request("GET", "/v2/service_instances/"+instanceGuid, "", &instance)).To(Equal(http.StatusOK))
+			Expect(instance).To(Equal(ServiceInstance{ServiceID: "service-id", PlanID: "plan-id"}))
+
+			Expect(request("DELETE", "/v2/service_instances/"+instanceGuid, "", nil)).To(Equal(http.StatusOK))
+			Expect(request("DELETE", "/v2/service_instances/"+instanceGuid, "", nil)).To(Equal(http.StatusGone))
+
+			var errorResponse ErrorResponse
+			Expect(request("GET", "/v2/service_instances/"+instanceGuid, "", &errorResponse)).To(Equal(http.StatusNotFound))
+			Expect(errorResponse.Description).To(ContainSubstring("does not exist"))
+		})
+
+		It("rejects plans that are not in the catalog", func() {
+			var errorResponse ErrorResponse
+			Expect(request("PUT", "/v2/service_instances/"+instanceGuid, `{"service_id":"service-id","plan_id":"other"}`, &errorResponse)).To(Equal(http.StatusBadRequest))
+			Expect(errorResponse.Description).To(ContainSubstring(`unknown service "service-id" or plan "other"`))
+		})
+	})
+
+	Describe("binding", func() {
+		BeforeEach(func() {
+			Expect(provision()).To(Equal(http.StatusCreated))
+		})
+
+		It("stores a credential that only the bound app can read", func() {
+			var response map[string]map[string]string
+			Expect(request("PUT", bindingPath, `{"app_guid":"`+appGuid+`"}`, &response)).To(Equal(http.StatusCreated))
+
+			name := credhubRef(response)
+			Expect(name).To(Equal("/credhub-service-broker/" + instanceGuid + "/" + bindingGuid))
+			Expect(fake.credentials).To(HaveKeyWithValue(name, map[string]interface{}{"user-name": "pinkyPie", "password": "rainbowDash"}))
+			Expect(fake.permissions[name]).To(ConsistOf(permissions.Permission{Actor: "mtls-app:" + appGuid, Operations: []string{"read"}}))
+
+			var fetched map[string]map[string]string
+			Expect(request("GET", bindingPath, "", &fetched)).To(Equal(http.StatusOK))
+			Expect(fetched).To(Equal(response))
+		})
+
+		It("returns the existing binding when asked for it again", func() {
+			var first, second map[string]map[string]string
+			Expect(request("PUT", bindingPath, `{"app_guid":"`+appGuid+`"}`, &first)).To(Equal(http.StatusCreated))
+			requests := fake.requests
+
+			Expect(request("PUT", bindingPath, `{"app_guid":"`+appGuid+`"}`, &second)).To(Equal(http.StatusOK))
+			Expect(second).To(Equal(first))
+			Expect(fake.requests).To(Equal(requests))
+
+			Expect(request("PUT", bindingPath, `{"app_guid":"another-app"}`, nil)).To(Equal(http.StatusConflict))
+		})
+
+		It("creates service keys that Cloud Controller's client can read", func() {
+			var response map[string]map[string]string
+			Expect(request("PUT", bindingPath, `{"bind_resource":{"credential_client_id":"cc_service_key_client"}}`, &response)).To(Equal(http.StatusCreated))
+
+			name := credhubRef(response)
+			Expect(fake.credentials).To(HaveKey(name))
+			Expect(fake.permissions[name]).To(ConsistOf(permissions.Permission{Actor: "uaa-client:cc_service_key_client", Operations: []string{"read"}}))
+		})
+
+		It("creates service keys without any permissions when there is no client to grant them to", func() {
+			var response map[string]map[string]string
+			Expect(request("PUT", bindingPath, `{}`, &response)).To(Equal(http.StatusCreated))
+
+			Expect(fake.credentials).To(HaveKey(credhubRef(response)))
+			Expect(fake.permissions).To(BeEmpty())
+		})
+
+		It("responds with an error when CredHub fails, and keeps serving", func() {
+			fake.failWith = http.StatusInternalServerError
+
+			var errorResponse ErrorResponse
+			Expect(request("PUT", bindingPath, `{"app_guid":"`+appGuid+`"}`, &errorResponse)).To(Equal(http.StatusInternalServerError))
+			Expect(errorResponse.Description).To(Equal("failed to store the credential in CredHub: CredHub is unavailable"))
+			Expect(request("GET", bindingPath, "", nil)).To(Equal(http.StatusNotFound))
+
+			fake.failWith = 0
+			Expect(request("PUT", bindingPath, `{"app_guid":"`+appGuid+`"}`, nil)).To(Equal(http.StatusCreated))
+		})
+
+		It("rejects requests it cannot decode", func() {
+			var errorResponse ErrorResponse
+			Expect(request("PUT", bindingPath, `not json`, &errorResponse)).To(Equal(http.StatusBadRequest))
+			Expect(errorResponse.Description).To(HavePrefix("invalid bind request"))
+		})
+
+		It("handles concurrent binds", func() {
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+
+					path := fmt.Sprintf("/v2/service_instances/%s/service_bindings/binding-%d", instanceGuid, i)
+					Expect(request("PUT", path, `{"app_guid":"`+appGuid+`"}`, nil)).To(Equal(http.StatusCreated))
+				}(i)
+			}
+			wg.Wait()
+
+			Expect(fake.credentials).To(HaveLen(20))
+		})
+
+		It("tells requests for a binding that is still being created to retry", func() {
+			fake.arrived = make(chan struct{}, 1)
+			fake.release = make(chan struct{})
+
+			first := make(chan int, 1)
+			go func() {
+				defer GinkgoRecover()
+				first <- request("PUT", bindingPath, `{"app_guid":"`+appGuid+`"}`, nil)
+			}()
+			Eventually(fake.arrived).Should(Receive())
+
+			var errorResponse ErrorResponse
+			Expect(request("PUT", bindingPath, `{"app_guid":"`+appGuid+`"}`, &errorResponse)).To(Equal(http.StatusUnprocessableEntity))
+			Expect(errorResponse.Error).To(Equal("ConcurrencyError"))
+			Expect(request("DELETE", bindingPath, "", nil)).To(Equal(http.StatusUnprocessableEntity))
+			Expect(request("GET", bindingPath, "", nil)).To(Equal(http.StatusNotFound))
+
+			close(fake.release)
+			Eventually(first).Should(Receive(Equal(http.StatusCreated)))
+			Expect(fake.credentials).To(HaveLen(1))
+			Expect(request("PUT", bindingPath, `{"app_guid":"`+appGuid+`"}`, nil)).To(Equal(http.StatusOK))
+		})
+	})
+
+	Describe("unbinding", func() {
+		var name string
+
+		BeforeEach(func() {
+			Expect(provision()).To(Equal(http.StatusCreated))
+
+			var response map[string]map[string]string
+			Expect(request("PUT", bindingPath, `{"app_guid":"`+appGuid+`"}`, &response)).To(Equal(http.StatusCreated))
+			name = credhubRef(response)
+		})
+
+		It("deletes the credential from CredHub", func() {
+			Expect(request("DELETE", bindingPath, "", nil)).To(Equal(http.StatusOK))
+			Expect(fake.credentials).NotTo(HaveKey(name))
+
+			Expect(request("DELETE", bindingPath, "", nil)).To(Equal(http.StatusGone))
+			Expect(request("GET", bindingPath, "", nil)).To(Equal(http.StatusNotFound))
+		})
+
+		It("keeps the binding when CredHub fails so that the unbind can be retried", func() {
+			fake.failWith = http.StatusServiceUnavailable
+
+			var errorResponse ErrorResponse
+			Expect(request("DELETE", bindingPath, "", &errorResponse)).To(Equal(http.StatusInternalServerError))
+			Expect(errorResponse.Description).To(HavePrefix("failed to delete the credential from CredHub"))
+			Expect(request("GET", bindingPath, "", nil)).To(Equal(http.StatusOK))
+
+			fake.failWith = 0
+			Expect(request("DELETE", bindingPath, "", nil)).To(Equal(http.StatusOK))
+			Expect(fake.credentials).NotTo(HaveKey(name))
+		})
+	})
+})
@@ -1,23 +1,21 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
-	"strconv"
-	"time"
 
 	"github.com/cloudfoundry-incubator/credhub-cli/credhub"
 	"github.com/cloudfoundry-incubator/credhub-cli/credhub/auth"
-	"github.com/cloudfoundry-incubator/credhub-cli/credhub/credentials/values"
-	"github.com/cloudfoundry-incubator/credhub-cli/credhub/permissions"
 	"github.com/cloudfoundry-incubator/credhub-cli/util"
-	"github.com/gorilla/mux"
 	"github.com/satori/go.uuid"
 )
 
+// catalogNamespace seeds the service and plan IDs the broker derives from
+// the service name, so that they stay the same across catalog requests and
+// restarts.
+var catalogNamespace = uuid.NewV5(uuid.NamespaceURL, "credhub-service-broker")
+
 func main() {
 	var server Server
 
@@ -28,27 +26,10 @@ type Server struct {
 	sb *ServiceBroker
 }
 
-type bindRequest struct {
-	AppGuid      string `json:"app_guid"`
-	BindResource struct {
-		CredentialClientId string `json:"credential_client_id"`
-	} `json:"bind_resource"`
-}
-
 func (s *Server) Start() {
-	router := mux.NewRouter()
-
-	s.sb = &ServiceBroker{
-		NameMap: make(map[string]string),
-	}
+	s.sb = NewServiceBroker(CatalogFromEnv(), CredHubFromEnv)
 
-	router.HandleFunc("/v2/catalog", s.sb.Catalog).Methods("GET")
-	router.HandleFunc("/v2/service_instances/{service_instance_guid}", s.sb.CreateServiceInstance).Methods("PUT")
-	router.HandleFunc("/v2/service_instances/{service_instance_guid}", s.sb.RemoveServiceInstance).Methods("DELETE")
-	router.HandleFunc("/v2/service_instances/{service_instance_guid}/service_bindings/{service_binding_guid}", s.sb.Bind).Methods("PUT")
-	router.HandleFunc("/v2/service_instances/{service_instance_guid}/service_bindings/{service_binding_guid}", s.sb.UnBind).Methods("DELETE")
-
-	http.Handle("/", router)
+	http.Handle("/", s.sb.Router())
 
 	cfPort := os.Getenv("PORT")
 
@@ -57,119 +38,34 @@ func (s *Server) Start() {
 	fmt.Println(http.ListenAndServe(":"+cfPort, nil))
 }
 
-type ServiceBroker struct {
-	NameMap map[string]string
-}
-
-func WriteResponse(w http.ResponseWriter, code int, response string) {
-	w.WriteHeader(code)
-	fmt.Fprintf(w, string(response))
-}
-
-func (s *ServiceBroker) Catalog(w http.ResponseWriter, r *http.Request) {
-	serviceUUID := uuid.NewV4().String()
-	planUUID := uuid.NewV4().String()
-
+// CatalogFromEnv reads the service from SERVICE_NAME, SERVICE_ID and
+// PLAN_ID. When the IDs are not set they are derived from the service name.
+func CatalogFromEnv() Catalog {
 	serviceName := "credhub-read"
 	if os.Getenv("SERVICE_NAME") != "" {
 		serviceName = os.Getenv("SERVICE_NAME")
 	}
 
-	catalog := `{
-	"services": [{
-		"name": "` + serviceName + `",
-		"id": "` + serviceUUID + `",
-		"description": "credhub read service for tests",
-		"bindable": true,
-		"plans": [{
-			"name": "credhub-read-plan",
-			"id": "` + planUUID + `",
-			"description": "credhub read service for tests"
-		}]
-	}]
-}`
-
-	WriteResponse(w, http.StatusOK, catalog)
-}
-
-func (s *ServiceBroker) CreateServiceInstance(w http.ResponseWriter, r *http.Request) {
-	WriteResponse(w, http.StatusCreated, "{}")
-}
-
-func (s *ServiceBroker) RemoveServiceInstance(w http.ResponseWriter, r *http.Request) {
-	WriteResponse(w, http.StatusOK, "{}")
-}
-
-func (s *ServiceBroker) Bind(w http.ResponseWriter, r *http.Request) {
-	ch, err := credhub.New(
-		util.AddDefaultSchemeIfNecessary(os.Getenv("CREDHUB_API")),
-		credhub.SkipTLSValidation(true),
-		credhub.Auth(auth.UaaClientCredentials(os.Getenv("CREDHUB_CLIENT"), os.Getenv("CREDHUB_SECRET"))),
-	)
-
-	if err != nil {
-		fmt.Println("credhub client configuration failed: " + err.Error())
-	}
-
-	body := bindRequest{}
-	err = json.NewDecoder(r.Body).Decode(&body)
-
-	if err != nil {
-		fmt.Println(err)
+	serviceID := os.Getenv("SERVICE_ID")
+	if serviceID == "" {
+		serviceID = uuid.NewV5(catalogNamespace, serviceName).String()
 	}
 
-	name := strconv.FormatInt(time.Now().UnixNano(), 10)
-	storedJson := values.JSON{}
-	storedJson["user-name"] = "pinkyPie"
-	storedJson["password"] = "rainbowDash"
-
-	cred, err := ch.SetJSON(name, storedJson, credhub.Overwrite)
-	handleError(err)
-
-	pathVariables := mux.Vars(r)
-	s.NameMap[pathVariables["service_binding_guid"]] = name
-
-	if body.AppGuid != "" {
-		_, err = ch.AddPermissions(cred.Name, []permissions.Permission{{
-			Actor:      "mtls-app:" + body.AppGuid,
-			Operations: []string{"read"},
-		}})
-		handleError(err)
+	planID := os.Getenv("PLAN_ID")
+	if planID == "" {
+		planID = uuid.NewV5(catalogNamespace, serviceName+"/"+planName).String()
 	}
 
-	credentials := `{
-  "credentials": {
-    "credhub-ref": "` + cred.Name + `"
-  }
-}`
-
-	WriteResponse(w, http.StatusCreated, credentials)
+	return Catalog{ServiceName: serviceName, ServiceID: serviceID, PlanID: planID}
 }
 
-func (s *ServiceBroker) UnBind(w http.ResponseWriter, r *http.Request) {
-	ch, err := credhub.New(
+// CredHubFromEnv builds a client for the CredHub at CREDHUB_API that
+// authenticates as CREDHUB_CLIENT. It is called for each request, since the
+// broker is pushed before those are set.
+func CredHubFromEnv() (*credhub.CredHub, error) {
+	return credhub.New(
 		util.AddDefaultSchemeIfNecessary(os.Getenv("CREDHUB_API")),
 		credhub.SkipTLSValidation(true),
 		credhub.Auth(auth.UaaClientCredentials(os.Getenv("CREDHUB_CLIENT"), os.Getenv("CREDHUB_SECRET"))),
 	)
-
-	if err != nil {
-		fmt.Println("credhub client configuration failed: " + err.Error())
-	}
-
-	pathVariables := mux.Vars(r)
-	name := s.NameMap[pathVariables["service_binding_guid"]]
-
-	err = ch.Delete(name)
-
-	handleError(err)
-
-	WriteResponse(w, http.StatusOK, "{}")
-}
-
-func handleError(err error) {
-	if err != nil {
-		fmt.Println(err)
-		log.Fatal("Fatal", err)
-	}
 }
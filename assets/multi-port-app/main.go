@@ -3,33 +3,42 @@ package main
 import (
 	"flag"
 	"log"
-	"net/http"
 	"strings"
 	"sync"
+	"time"
 )
 
 var portsFlag = flag.String(
 	"ports",
 	"8080",
-	"Comma delimited list of ports, where the app will be listening to",
+	"Comma delimited list of ports, where the app will be listening to. "+
+		"Each port may be followed by :http, :tcp, :tls or :slow to choose what it serves",
+)
+
+var slowDelayFlag = flag.Duration(
+	"slow-delay",
+	5*time.Second,
+	"How long ports in slow mode wait before answering a request",
 )
 
 func main() {
 	flag.Parse()
-	ports := strings.Split(*portsFlag, ",")
+	ports, err := parsePorts(*portsFlag, *slowDelayFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	wg := sync.WaitGroup{}
+	names := []string{}
 	for _, port := range ports {
 		wg.Add(1)
-		go func(wg *sync.WaitGroup, port string) {
+		names = append(names, port.String())
+		go func(wg *sync.WaitGroup, port *Port) {
 			defer wg.Done()
 
-			log.Fatal(http.ListenAndServe(":"+port, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-				w.Header().Set("Content-Type", "text/plain")
-				w.Write([]byte(port + "\n"))
-			})))
+			log.Fatal(port.ListenAndServe())
 		}(&wg, port)
 	}
-	println("Listening on ports ", strings.Join(ports, ", "))
+	println("Listening on ports ", strings.Join(names, ", "))
 	wg.Wait()
 }
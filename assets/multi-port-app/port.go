@@ -0,0 +1,209 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ModeHTTP answers every request with the port number, apart from /id
+	// and /health.
+	ModeHTTP = "http"
+	// ModeTCP greets each connection with the port's identity and then
+	// echoes back whatever it is sent.
+	ModeTCP = "tcp"
+	// ModeTLS serves the same as ModeHTTP over TLS, with a self-signed
+	// certificate.
+	ModeTLS = "tls"
+	// ModeSlow serves the same as ModeHTTP, but waits before answering.
+	ModeSlow = "slow"
+)
+
+// Identity tells callers which port of which instance answered.
+type Identity struct {
+	Port          int    `json:"port"`
+	Mode          string `json:"mode"`
+	InstanceGuid  string `json:"instance_guid"`
+	InstanceIndex int    `json:"instance_index"`
+}
+
+type Health struct {
+	Identity
+	Healthy bool `json:"healthy"`
+}
+
+type Port struct {
+	number    int
+	mode      string
+	slowDelay time.Duration
+
+	mutex   sync.Mutex
+	healthy bool
+}
+
+// parsePorts reads a list like 8080,7777:tcp,9999:tls.
+func parsePorts(list string, slowDelay time.Duration) ([]*Port, error) {
+	ports := []*Port{}
+	for _, spec := range strings.Split(list, ",") {
+		parts := strings.SplitN(strings.TrimSpace(spec), ":", 2)
+		number, err := strconv.Atoi(parts[0])
+		if err != nil || number < 1 || number > 65535 {
+			return nil, fmt.Errorf("invalid port %q", parts[0])
+		}
+
+		mode := ModeHTTP
+		if len(parts) == 2 {
+			mode = parts[1]
+		}
+		switch mode {
+		case ModeHTTP, ModeTCP, ModeTLS, ModeSlow:
+		default:
+			return nil, fmt.Errorf("unknown mode %q for port %d", mode, number)
+		}
+
+		ports = append(ports, &Port{number: number, mode: mode, slowDelay: slowDelay, healthy: true})
+	}
+	return ports, nil
+}
+
+func (p *Port) String() string {
+	if p.mode == ModeHTTP {
+		return strconv.Itoa(p.number)
+	}
+	return fmt.Sprintf("%d (%s)", p.number, p.mode)
+}
+
+func (p *Port) Identity() Identity {
+	index, _ := strconv.Atoi(os.Getenv("CF_INSTANCE_INDEX"))
+	return Identity{
+		Port:          p.number,
+		Mode:          p.mode,
+		InstanceGuid:  os.Getenv("CF_INSTANCE_GUID"),
+		InstanceIndex: index,
+	}
+}
+
+func (p *Port) ListenAndServe() error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", p.number))
+	if err != nil {
+		return err
+	}
+
+	switch p.mode {
+	case ModeTCP:
+		return p.echo(listener)
+	case ModeTLS:
+		cert, err := generateCertificate()
+		if err != nil {
+			return err
+		}
+		listener = tls.NewListener(listener, &tls.Config{Certificates: []tls.Certificate{cert}})
+	}
+	return http.Serve(listener, p)
+}
+
+func (p *Port) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if p.mode == ModeSlow {
+		time.Sleep(p.slowDelay)
+	}
+
+	switch req.URL.Path {
+	case "/id":
+		writeJSON(w, http.StatusOK, p.Identity())
+	case "/health":
+		p.healthHandler(w, req)
+	case "/health/healthy", "/health/unhealthy":
+		if req.Method != http.MethodPut {
+			http.Error(w, "Use PUT to change the port's health", http.StatusMethodNotAllowed)
+			return
+		}
+		p.mutex.Lock()
+		p.healthy = req.URL.Path == "/health/healthy"
+		p.mutex.Unlock()
+		p.healthHandler(w, req)
+	default:
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(strconv.Itoa(p.number) + "\n"))
+	}
+}
+
+// healthHandler answers 503 once the port has been made unhealthy, so that
+// HTTP health checks against it fail.
+func (p *Port) healthHandler(w http.ResponseWriter, req *http.Request) {
+	p.mutex.Lock()
+	health := Health{Identity: p.Identity(), Healthy: p.healthy}
+	p.mutex.Unlock()
+
+	status := http.StatusOK
+	if !health.Healthy {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, health)
+}
+
+func (p *Port) echo(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+
+		go func(conn net.Conn) {
+			defer conn.Close()
+
+			id := p.Identity()
+			fmt.Fprintf(conn, "multi-port-app port=%d mode=%s instance_index=%d instance_guid=%s\n", id.Port, id.Mode, id.InstanceIndex, id.InstanceGuid)
+			io.Copy(conn, conn)
+		}(conn)
+	}
+}
+
+func generateCertificate() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	template := x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "multi-port-app"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(365 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if ip := net.ParseIP(os.Getenv("CF_INSTANCE_INTERNAL_IP")); ip != nil {
+		template.IPAddresses = append(template.IPAddresses, ip)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
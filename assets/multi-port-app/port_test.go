@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Port", func() {
+	// freePort finds a port that nothing is listening on, for ListenAndServe.
+	freePort := func() int {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		defer listener.Close()
+		return listener.Addr().(*net.TCPAddr).Port
+	}
+
+	serve := func(port *Port, method, path string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		port.ServeHTTP(res, httptest.NewRequest(method, path, nil))
+		return res
+	}
+
+	getHealth := func(port *Port, method, path string) (int, Health) {
+		res := serve(port, method, path)
+		var health Health
+		Expect(json.Unmarshal(res.Body.Bytes(), &health)).To(Succeed(), res.Body.String())
+		return res.Code, health
+	}
+
+	Describe("parsePorts", func() {
+		It("reads each port and its mode, defaulting to http", func() {
+			ports, err := parsePorts("8080, 7777:tcp,9999:tls,6666:slow", time.Second)
+			Expect(err).NotTo(HaveOccurred())
+
+			names := []string{}
+			for _, port := range ports {
+				names = append(names, port.String())
+			}
+			Expect(names).To(Equal([]string{"8080", "7777 (tcp)", "9999 (tls)", "6666 (slow)"}))
+		})
+
+		It("rejects ports that are out of range or not numbers", func() {
+			for _, list := range []string{"0", "65536", "http", "8080,"} {
+				_, err := parsePorts(list, time.Second)
+				Expect(err).To(MatchError(ContainSubstring("invalid port")), list)
+			}
+		})
+
+		It("rejects unknown modes", func() {
+			_, err := parsePorts("8080:udp", time.Second)
+			Expect(err).To(MatchError(`unknown mode "udp" for port 8080`))
+		})
+	})
+
+	Describe("ServeHTTP", func() {
+		var port *Port
+
+		BeforeEach(func() {
+			ports, err := parsePorts("8080", time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			port = ports[0]
+		})
+
+		It("answers with the port number", func() {
+			res := serve(port, http.MethodGet, "/anything")
+			Expect(res.Code).To(Equal(http.StatusOK))
+			Expect(res.Body.String()).To(Equal("8080\n"))
+		})
+
+		It("identifies the instance", func() {
+			os.Setenv("CF_INSTANCE_GUID", "instance-guid")
+			os.Setenv("CF_INSTANCE_INDEX", "3")
+			defer os.Unsetenv("CF_INSTANCE_GUID")
+			defer os.Unsetenv("CF_INSTANCE_INDEX")
+
+			var id Identity
+			res := serve(port, http.MethodGet, "/id")
+			Expect(json.Unmarshal(res.Body.Bytes(), &id)).To(Succeed())
+			Expect(id).To(Equal(Identity{Port: 8080, Mode: ModeHTTP, InstanceGuid: "instance-guid", InstanceIndex: 3}))
+		})
+
+		It("fails its health check while it is unhealthy", func() {
+			code, health := getHealth(port, http.MethodGet, "/health")
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(health.Healthy).To(BeTrue())
+
+			code, health = getHealth(port, http.MethodPut, "/health/unhealthy")
+			Expect(code).To(Equal(http.StatusServiceUnavailable))
+			Expect(health.Healthy).To(BeFalse())
+
+			code, health = getHealth(port, http.MethodGet, "/health")
+			Expect(code).To(Equal(http.StatusServiceUnavailable))
+			Expect(health.Healthy).To(BeFalse())
+
+			code, health = getHealth(port, http.MethodPut, "/health/healthy")
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(health.Healthy).To(BeTrue())
+		})
+
+		It("keeps the health of each port separate", func() {
+			ports, err := parsePorts("8080,7777", time.Second)
+			Expect(err).NotTo(HaveOccurred())
+
+			serve(ports[0], http.MethodPut, "/health/unhealthy")
+
+			code, _ := getHealth(ports[1], http.MethodGet, "/health")
+			Expect(code).To(Equal(http.StatusOK))
+		})
+
+		It("only changes its health on PUT", func() {
+			res := serve(port, http.MethodGet, "/health/unhealthy")
+			Expect(res.Code).To(Equal(http.StatusMethodNotAllowed))
+
+			code, _ := getHealth(port, http.MethodGet, "/health")
+			Expect(code).To(Equal(http.StatusOK))
+		})
+
+		It("waits before answering in slow mode", func() {
+			ports, err := parsePorts("8080:slow", 100*time.Millisecond)
+			Expect(err).NotTo(HaveOccurred())
+
+			start := time.Now()
+			res := serve(ports[0], http.MethodGet, "/")
+			Expect(time.Since(start)).To(BeNumerically(">=", 100*time.Millisecond))
+			Expect(res.Body.String()).To(Equal("8080\n"))
+		})
+	})
+
+	Describe("ListenAndServe", func() {
+		listen := func(mode string) int {
+			number := freePort()
+			ports, err := parsePorts(fmt.Sprintf("%d:%s", number, mode), time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			go ports[0].ListenAndServe()
+			return number
+		}
+
+		It("greets tcp connections and echoes what they send", func() {
+			number := listen(ModeTCP)
+
+			var conn net.Conn
+			Eventually(func() error {
+				var err error
+				conn, err = net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", number))
+				return err
+			}).Should(Succeed())
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+			reader := bufio.NewReader(conn)
+			Expect(reader.ReadString('\n')).To(HavePrefix(fmt.Sprintf("multi-port-app port=%d mode=tcp ", number)))
+
+			fmt.Fprint(conn, "hello\n")
+			Expect(reader.ReadString('\n')).To(Equal("hello\n"))
+		})
+
+		It("serves https with a self-signed certificate in tls mode", func() {
+			number := listen(ModeTLS)
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+
+			var res *http.Response
+			Eventually(func() error {
+				var err error
+				res, err = client.Get(fmt.Sprintf("https://127.0.0.1:%d/id", number))
+				return err
+			}).Should(Succeed())
+			defer res.Body.Close()
+
+			Expect(res.TLS).NotTo(BeNil())
+			Expect(res.TLS.PeerCertificates[0].Subject.CommonName).To(Equal("multi-port-app"))
+
+			var id Identity
+			Expect(json.NewDecoder(res.Body).Decode(&id)).To(Succeed())
+			Expect(id.Port).To(Equal(number))
+			Expect(id.Mode).To(Equal(ModeTLS))
+		})
+	})
+})
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	nilValue       = '-'
+	maxFrameLength = 1024 * 1024
+)
+
+// Message is a parsed RFC 5424 syslog message. Header fields that were sent
+// as the nil value are left empty, as is Timestamp.
+type Message struct {
+	Facility       int         `json:"facility"`
+	Severity       int         `json:"severity"`
+	Version        int         `json:"version"`
+	Timestamp      time.Time   `json:"timestamp"`
+	Hostname       string      `json:"hostname"`
+	AppName        string      `json:"app_name"`
+	ProcID         string      `json:"proc_id"`
+	MsgID          string      `json:"msg_id"`
+	StructuredData []SDElement `json:"structured_data"`
+	Message        string      `json:"message"`
+}
+
+// SDElement is one element of a message's structured data. Should a
+// parameter name repeat, the last value wins.
+type SDElement struct {
+	ID     string            `json:"id"`
+	Params map[string]string `json:"params"`
+}
+
+// ReadFrame reads one octet-counted frame (RFC 6587 section 3.4.1): the
+// length of the message in decimal, a space and then the message itself. It
+// returns io.EOF only when the stream ends between frames.
+func ReadFrame(reader *bufio.Reader) ([]byte, error) {
+	length := 0
+	for digits := 0; ; digits++ {
+		b, err := reader.ReadByte()
+		if err == io.EOF && digits == 0 {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading frame length: %s", err)
+		}
+		if b == ' ' && digits > 0 {
+			break
+		}
+		if b < '0' || b > '9' || (digits == 0 && b == '0') {
+			return nil, fmt.Errorf("frame does not start with an octet count: unexpected %q", b)
+		}
+		length = length*10 + int(b-'0')
+		if length > maxFrameLength {
+			return nil, fmt.Errorf("frame is longer than %d bytes", maxFrameLength)
+		}
+	}
+
+	frame := make([]byte, length)
+	if _, err := io.ReadFull(reader, frame); err != nil {
+		return nil, fmt.Errorf("reading %d byte frame: %s", length, err)
+	}
+	return frame, nil
+}
+
+// ParseMessage parses a single RFC 5424 message, without any framing. The
+// newline Loggregator appends to every message is dropped.
+func ParseMessage(data []byte) (Message, error) {
+	p := &parser{data: data}
+	var m Message
+
+	priority, err := p.priority()
+	if err != nil {
+		return Message{}, err
+	}
+	m.Facility, m.Severity = priority/8, priority%8
+
+	if m.Version, err = p.number("version", 2); err != nil {
+		return Message{}, err
+	}
+	if m.Version == 0 {
+		return Message{}, errors.New("version must not be zero")
+	}
+
+	if err := p.space(); err != nil {
+		return Message{}, err
+	}
+	timestamp, err := p.field("timestamp", 64)
+	if err != nil {
+		return Message{}, err
+	}
+	if timestamp != "" {
+		if m.Timestamp, err = time.Parse(time.RFC3339Nano, timestamp); err != nil {
+			return Message{}, fmt.Errorf("invalid timestamp: %s", err)
+		}
+	}
+
+	for _, header := range []struct {
+		name  string
+		max   int
+		value *string
+	}{
+		{"hostname", 255, &m.Hostname},
+		{"app name", 48, &m.AppName},
+		{"proc id", 128, &m.ProcID},
+		{"msg id", 32, &m.MsgID},
+	} {
+		if err := p.space(); err != nil {
+			return Message{}, err
+		}
+		if *header.value, err = p.field(header.name, header.max); err != nil {
+			return Message{}, err
+		}
+	}
+
+	if err := p.space(); err != nil {
+		return Message{}, err
+	}
+	if m.StructuredData, err = p.structuredData(); err != nil {
+		return Message{}, err
+	}
+
+	if !p.done() {
+		if err := p.space(); err != nil {
+			return Message{}, err
+		}
+		msg := bytes.TrimPrefix(p.data[p.off:], []byte("\xef\xbb\xbf"))
+		m.Message = string(bytes.TrimSuffix(msg, []byte("\n")))
+	}
+	return m, nil
+}
+
+type parser struct {
+	data []byte
+	off  int
+}
+
+func (p *parser) done() bool {
+	return p.off >= len(p.data)
+}
+
+func (p *parser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.data[p.off]
+}
+
+func (p *parser) expect(b byte, what string) error {
+	if p.peek() != b || p.done() {
+		return p.unexpected(what)
+	}
+	p.off++
+	return nil
+}
+
+func (p *parser) unexpected(what string) error {
+	if p.done() {
+		return fmt.Errorf("message ends where %s was expected", what)
+	}
+	return fmt.Errorf("expected %s at offset %d, found %q", what, p.off, p.data[p.off])
+}
+
+func (p *parser) space() error {
+	return p.expect(' ', "a space")
+}
+
+func (p *parser) priority() (int, error) {
+	if err := p.expect('<', "'<' opening the priority"); err != nil {
+		return 0, err
+	}
+	priority, err := p.number("priority", 3)
+	if err != nil {
+		return 0, err
+	}
+	if priority > 191 {
+		return 0, fmt.Errorf("priority %d is out of range", priority)
+	}
+	return priority, p.expect('>', "'>' closing the priority")
+}
+
+func (p *parser) number(what string, maxDigits int) (int, error) {
+	n, digits := 0, 0
+	for ; digits < maxDigits && p.peek() >= '0' && p.peek() <= '9'; digits++ {
+		n = n*10 + int(p.data[p.off]-'0')
+		p.off++
+	}
+	if digits == 0 {
+		return 0, p.unexpected(what)
+	}
+	return n, nil
+}
+
+// field reads a header field up to the next space, returning "" for the nil
+// value.
+func (p *parser) field(what string, max int) (string, error) {
+	start := p.off
+	for !p.done() && p.peek() != ' ' {
+		if b := p.peek(); b < 33 || b > 126 {
+			return "", fmt.Errorf("%s contains %q", what, b)
+		}
+		p.off++
+	}
+
+	value := string(p.data[start:p.off])
+	switch {
+	case value == "":
+		return "", p.unexpected(what)
+	case len(value) > max:
+		return "", fmt.Errorf("%s is longer than %d characters", what, max)
+	case value == string(nilValue):
+		return "", nil
+	}
+	return value, nil
+}
+
+func (p *parser) structuredData() ([]SDElement, error) {
+	if p.peek() == nilValue {
+		p.off++
+		return nil, nil
+	}
+	if p.peek() != '[' {
+		return nil, p.unexpected("structured data")
+	}
+
+	var elements []SDElement
+	for p.peek() == '[' {
+		p.off++
+		id, err := p.sdName("SD-ID")
+		if err != nil {
+			return nil, err
+		}
+
+		element := SDElement{ID: id, Params: map[string]string{}}
+		for p.peek() == ' ' {
+			p.off++
+			name, err := p.sdName("param name")
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect('=', "'=' after param name"); err != nil {
+				return nil, err
+			}
+			if element.Params[name], err = p.sdValue(); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := p.expect(']', "']' closing the SD element"); err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+	}
+	return elements, nil
+}
+
+func (p *parser) sdName(what string) (string, error) {
+	start := p.off
+	for b := p.peek(); b > 32 && b < 127 && b != '=' && b != ']' && b != '"'; b = p.peek() {
+		p.off++
+	}
+
+	name := string(p.data[start:p.off])
+	if name == "" {
+		return "", p.unexpected(what)
+	}
+	if len(name) > 32 {
+		return "", fmt.Errorf("%s %q is longer than 32 characters", what, name)
+	}
+	return name, nil
+}
+
+// sdValue reads a quoted param value, in which '"', '\' and ']' are escaped
+// with a backslash. A backslash before any other character is kept.
+func (p *parser) sdValue() (string, error) {
+	if err := p.expect('"', "'\"' opening the param value"); err != nil {
+		return "", err
+	}
+
+	var value []byte
+	for {
+		if p.done() {
+			return "", p.unexpected("'\"' closing the param value")
+		}
+		b := p.data[p.off]
+		p.off++
+		switch {
+		case b == '"':
+			return string(value), nil
+		case b == '\\' && !p.done() && (p.peek() == '"' || p.peek() == '\\' || p.peek() == ']'):
+			value = append(value, p.data[p.off])
+			p.off++
+		default:
+			value = append(value, b)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RFC 5424", func() {
+	Describe("ParseMessage", func() {
+		It("parses the messages Loggregator sends", func() {
+			m, err := ParseMessage([]byte(`<14>1 2019-03-04T05:06:07.123456+00:00 org.space.app 2f1a8c5e-0000-4000-8000-000000000001 [APP/PROC/WEB/0] - [tags@47450 source_type="APP/PROC/WEB" instance_id="0"] Hello, world` + "\n"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(m.Facility).To(Equal(1))
+			Expect(m.Severity).To(Equal(6))
+			Expect(m.Version).To(Equal(1))
+			Expect(m.Timestamp.Equal(time.Date(2019, 3, 4, 5, 6, 7, 123456000, time.UTC))).To(BeTrue())
+			Expect(m.Hostname).To(Equal("org.space.app"))
+			Expect(m.AppName).To(Equal("2f1a8c5e-0000-4000-8000-000000000001"))
+			Expect(m.ProcID).To(Equal("[APP/PROC/WEB/0]"))
+			Expect(m.MsgID).To(BeEmpty())
+			Expect(m.StructuredData).To(Equal([]SDElement{
+				{ID: "tags@47450", Params: map[string]string{"source_type": "APP/PROC/WEB", "instance_id": "0"}},
+			}))
+			Expect(m.Message).To(Equal("Hello, world"))
+		})
+
+		It("accepts nil values and a missing message", func() {
+			m, err := ParseMessage([]byte("<0>1 - - - - - -"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m).To(Equal(Message{Version: 1}))
+		})
+
+		It("parses several SD elements and escaped param values", func() {
+			m, err := ParseMessage([]byte(`<165>1 - host app - ID47 [a@1 x="say \"hi\" \\ [ok\]" y="\n"][b@1] msg`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.MsgID).To(Equal("ID47"))
+			Expect(m.StructuredData).To(Equal([]SDElement{
+				{ID: "a@1", Params: map[string]string{"x": `say "hi" \ [ok]`, "y": `\n`}},
+				{ID: "b@1", Params: map[string]string{}},
+			}))
+			Expect(m.Message).To(Equal("msg"))
+		})
+
+		It("drops a byte order mark from the message", func() {
+			m, err := ParseMessage([]byte("<14>1 - host app - - - \xef\xbb\xbfhello"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.Message).To(Equal("hello"))
+		})
+
+		DescribeTable("rejects malformed messages",
+			func(message, reason string) {
+				_, err := ParseMessage([]byte(message))
+				Expect(err).To(MatchError(ContainSubstring(reason)))
+			},
+			Entry("no priority", "14>1 - - - - - -", "'<' opening the priority"),
+			Entry("priority out of range", "<192>1 - - - - - -", "priority 192 is out of range"),
+			Entry("version zero", "<14>0 - - - - - -", "version must not be zero"),
+			Entry("RFC 3164 header", "<14>Mar  4 05:06:07 host app: msg", "version"),
+			Entry("bad timestamp", "<14>1 yesterday - - - - -", "invalid timestamp"),
+			Entry("missing header fields", "<14>1 - host app", "message ends where a space was expected"),
+			Entry("two spaces", "<14>1 -  app - - -", "expected hostname"),
+			Entry("long app name", "<14>1 - host "+strings.Repeat("a", 49)+" - - -", "app name is longer than 48 characters"),
+			Entry("bad structured data", "<14>1 - host app - - hello", "expected structured data"),
+			Entry("unterminated SD element", `<14>1 - host app - - [a@1 x="y"`, "']' closing the SD element"),
+			Entry("unquoted param value", `<14>1 - host app - - [a@1 x=y]`, `'"' opening the param value`),
+			Entry("unterminated param value", `<14>1 - host app - - [a@1 x="y]`, `'"' closing the param value`),
+			Entry("no space before the message", "<14>1 - host app - - -msg", "expected a space"),
+		)
+	})
+
+	Describe("ReadFrame", func() {
+		It("reads consecutive octet-counted frames", func() {
+			reader := bufio.NewReader(strings.NewReader("5 hello11 hello world"))
+
+			Expect(ReadFrame(reader)).To(Equal([]byte("hello")))
+			Expect(ReadFrame(reader)).To(Equal([]byte("hello world")))
+			_, err := ReadFrame(reader)
+			Expect(err).To(Equal(io.EOF))
+		})
+
+		It("keeps newlines and spaces inside a frame", func() {
+			reader := bufio.NewReader(strings.NewReader("8 a b\nc d\n"))
+			Expect(ReadFrame(reader)).To(Equal([]byte("a b\nc d\n")))
+		})
+
+		DescribeTable("rejects bad framing",
+			func(stream, reason string) {
+				_, err := ReadFrame(bufio.NewReader(strings.NewReader(stream)))
+				Expect(err).To(MatchError(ContainSubstring(reason)))
+				Expect(err).NotTo(Equal(io.EOF))
+			},
+			Entry("non-transparent framing", "<14>1 - - - - - -\n", "does not start with an octet count"),
+			Entry("leading zero", "05 hello", "does not start with an octet count"),
+			Entry("no length", " hello", "does not start with an octet count"),
+			Entry("too long", "99999999 x", "longer than"),
+			Entry("cut off length", "12", "reading frame length"),
+			Entry("cut off frame", "10 hello", "reading 10 byte frame"),
+		)
+	})
+})
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const tlsHandshakeRecord = 0x16
+
+// Server accepts every kind of drain on one port, since that is the only one
+// Diego exposes for the listener. It tells them apart by the first byte of
+// each connection: a TLS handshake is unwrapped and looked at again, an
+// upper case letter starts an HTTP request and anything else is taken to be
+// octet-counted syslog.
+type Server struct {
+	store     *Store
+	tlsConfig *tls.Config
+	out       io.Writer
+	http      *http.Server
+	httpConns *connListener
+}
+
+func NewServer(store *Store, cert tls.Certificate, out io.Writer) *Server {
+	s := &Server{
+		store:     store,
+		tlsConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+		out:       out,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/drain", s.DrainHandler)
+	mux.HandleFunc("/apps", store.AppsHandler)
+	mux.HandleFunc("/apps/", store.AppHandler)
+	s.http = &http.Server{Handler: mux}
+	return s
+}
+
+func (s *Server) Serve(listener net.Listener) error {
+	s.httpConns = newConnListener(listener.Addr())
+	defer s.httpConns.Close()
+	go s.http.Serve(s.httpConns)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handle(conn, DrainSyslog)
+	}
+}
+
+// DrainHandler receives https:// drains, which post one message per request
+// or, when batching, a body of octet-counted frames.
+func (s *Server) DrainHandler(res http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(res, "Drains must POST messages", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, maxFrameLength+1))
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxFrameLength {
+		http.Error(res, fmt.Sprintf("Bodies must not be longer than %d bytes", maxFrameLength), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if len(body) > 0 && body[0] >= '1' && body[0] <= '9' {
+		reader := bufio.NewReader(bytes.NewReader(body))
+		for {
+			frame, err := ReadFrame(reader)
+			if err == io.EOF {
+				break
+			}
+			if err == nil {
+				err = s.receive(frame, DrainHTTPS)
+			}
+			if err != nil {
+				http.Error(res, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+	} else if err := s.receive(body, DrainHTTPS); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+}
+
+func (s *Server) handle(conn net.Conn, drain string) {
+	reader := bufio.NewReader(conn)
+	first, err := reader.Peek(1)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	conn = &bufferedConn{Conn: conn, reader: reader}
+
+	switch {
+	case first[0] == tlsHandshakeRecord && drain == DrainSyslog:
+		s.handle(tls.Server(conn, s.tlsConfig), DrainSyslogTLS)
+	case first[0] >= 'A' && first[0] <= 'Z':
+		s.httpConns.hand(conn)
+	default:
+		s.readFrames(conn, reader, drain)
+	}
+}
+
+func (s *Server) readFrames(conn net.Conn, reader *bufio.Reader, drain string) {
+	defer conn.Close()
+	for {
+		frame, err := ReadFrame(reader)
+		if err == io.EOF {
+			fmt.Fprintln(s.out, "connection closed")
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(s.out, "Closing %s connection from %s: %s\n", drain, conn.RemoteAddr(), err)
+			return
+		}
+		s.receive(frame, drain)
+	}
+}
+
+// receive prints every message, as the listener always has, before storing
+// it.
+func (s *Server) receive(frame []byte, drain string) error {
+	fmt.Fprintln(s.out, string(frame))
+
+	message, err := ParseMessage(frame)
+	if err != nil {
+		fmt.Fprintf(s.out, "Invalid %s message: %s\n", drain, err)
+		return err
+	}
+	s.store.Add(message, drain, time.Now())
+	return nil
+}
+
+// GenerateCertificate makes a self-signed certificate for syslog-tls drains.
+// Loggregator has no way to trust it, so the drains have to be configured to
+// skip verification.
+func GenerateCertificate(hosts ...string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	template := x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "syslog-drain-listener"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(365 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else if host != "" {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+// bufferedConn reads through the reader that peeked at the connection's
+// first byte.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// connListener hands connections that turned out to be HTTP to the HTTP
+// server.
+type connListener struct {
+	addr      net.Addr
+	conns     chan net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newConnListener(addr net.Addr) *connListener {
+	return &connListener{
+		addr:   addr,
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+}
+
+func (l *connListener) hand(conn net.Conn) {
+	select {
+	case l.conns <- conn:
+	case <-l.closed:
+		conn.Close()
+	}
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, errors.New("listener closed")
+	}
+}
+
+func (l *connListener) Close() error {
+	l.closeOnce.Do(func() { close(l.closed) })
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return l.addr
+}
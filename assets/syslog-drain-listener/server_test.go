@@ -0,0 +1,213 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Server", func() {
+	var (
+		listener net.Listener
+		out      *gbytes.Buffer
+		baseURL  string
+	)
+
+	const appGuid = "2f1a8c5e-0000-4000-8000-000000000001"
+
+	message := func(procID, msg string) string {
+		return fmt.Sprintf(`<14>1 2019-03-04T05:06:07.123456+00:00 org.space.app %s %s - [tags@47450 source_type="APP/PROC/WEB"] %s`+"\n", appGuid, procID, msg)
+	}
+
+	frame := func(msg string) string {
+		return fmt.Sprintf("%d %s", len(msg), msg)
+	}
+
+	getJSON := func(path string, value interface{}) int {
+		res, err := http.Get(baseURL + path)
+		Expect(err).NotTo(HaveOccurred())
+		defer res.Body.Close()
+		if res.StatusCode == http.StatusOK {
+			Expect(res.Header.Get("Content-Type")).To(Equal("application/json"))
+			Expect(json.NewDecoder(res.Body).Decode(value)).To(Succeed())
+		}
+		return res.StatusCode
+	}
+
+	app := func() App {
+		var app App
+		getJSON("/apps/"+appGuid, &app)
+		return app
+	}
+
+	count := func() int {
+		return app().Count
+	}
+
+	BeforeEach(func() {
+		var err error
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		baseURL = "http://" + listener.Addr().String()
+
+		cert, err := GenerateCertificate("127.0.0.1")
+		Expect(err).NotTo(HaveOccurred())
+
+		out = gbytes.NewBuffer()
+		go NewServer(NewStore(3), cert, out).Serve(listener)
+	})
+
+	AfterEach(func() {
+		listener.Close()
+	})
+
+	It("stores octet-counted syslog messages by app GUID and prints them", func() {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+
+		_, err = conn.Write([]byte(frame(message("[APP/PROC/WEB/0]", "first")) + frame(message("[APP/PROC/WEB/0]", "second"))))
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(count).Should(Equal(2))
+		Expect(out).To(gbytes.Say("first"))
+		Expect(app().Drains).To(Equal(map[string]int{DrainSyslog: 2}))
+
+		var counts map[string]int
+		Expect(getJSON("/apps", &counts)).To(Equal(http.StatusOK))
+		Expect(counts).To(Equal(map[string]int{appGuid: 2}))
+
+		var messages []Received
+		Expect(getJSON("/apps/"+appGuid+"/messages", &messages)).To(Equal(http.StatusOK))
+		Expect(messages).To(HaveLen(2))
+		Expect(messages[1].Message.Message).To(Equal("second"))
+		Expect(messages[1].ProcID).To(Equal("[APP/PROC/WEB/0]"))
+		Expect(messages[1].Drain).To(Equal(DrainSyslog))
+		Expect(messages[1].ReceivedAt).NotTo(BeZero())
+	})
+
+	It("serves syslog-tls drains with its generated certificate", func() {
+		conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+		Expect(conn.ConnectionState().PeerCertificates[0].Subject.CommonName).To(Equal("syslog-drain-listener"))
+
+		_, err = conn.Write([]byte(frame(message("[APP/PROC/WEB/0]", "secret"))))
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(count).Should(Equal(1))
+		Expect(app().Drains).To(Equal(map[string]int{DrainSyslogTLS: 1}))
+	})
+
+	Describe("https drains", func() {
+		post := func(body string) *http.Response {
+			res, err := http.Post(baseURL+"/drain", "text/plain", strings.NewReader(body))
+			Expect(err).NotTo(HaveOccurred())
+			res.Body.Close()
+			return res
+		}
+
+		It("accepts a message per request", func() {
+			Expect(post(message("[APP/PROC/WEB/0]", "posted")).StatusCode).To(Equal(http.StatusOK))
+			Expect(app().Count).To(Equal(1))
+			Expect(app().Drains).To(Equal(map[string]int{DrainHTTPS: 1}))
+		})
+
+		It("accepts batches of octet-counted frames", func() {
+			Expect(post(frame(message("[APP/PROC/WEB/0]", "a")) + frame(message("[APP/PROC/WEB/0]", "b"))).StatusCode).To(Equal(http.StatusOK))
+			Expect(app().Count).To(Equal(2))
+		})
+
+		It("rejects messages it cannot parse", func() {
+			Expect(post("hello").StatusCode).To(Equal(http.StatusBadRequest))
+			Expect(getJSON("/apps/"+appGuid, &App{})).To(Equal(http.StatusNotFound))
+		})
+
+		It("rejects bodies longer than a frame", func() {
+			Expect(post(message("[APP/PROC/WEB/0]", strings.Repeat("a", maxFrameLength))).StatusCode).To(Equal(http.StatusRequestEntityTooLarge))
+			Expect(getJSON("/apps/"+appGuid, &App{})).To(Equal(http.StatusNotFound))
+		})
+
+		It("serves the drain over TLS as well", func() {
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+			res, err := client.Post("https://"+listener.Addr().String()+"/drain", "text/plain", strings.NewReader(message("[APP/PROC/WEB/0]", "posted")))
+			Expect(err).NotTo(HaveOccurred())
+			res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+			Expect(app().Count).To(Equal(1))
+		})
+	})
+
+	It("tracks catnip log generator sequences per instance and run", func() {
+		var body strings.Builder
+		for _, m := range []string{
+			message("[APP/PROC/WEB/0]", "catnip-log run=1 seq=1"),
+			message("[APP/PROC/WEB/0]", "catnip-log run=1 seq=2 line=1/2"),
+			message("[APP/PROC/WEB/0]", "catnip-log run=1 seq=2 line=2/2"),
+			message("[APP/PROC/WEB/0]", "catnip-log run=1 seq=5"),
+			message("[APP/PROC/WEB/0]", "catnip-log run=1 seq=5"),
+			message("[APP/PROC/WEB/0]", "catnip-log run=1 seq=7"),
+			message("[APP/PROC/WEB/0]", "catnip-log run=1 seq=4"),
+			message("[APP/PROC/WEB/1]", "catnip-log run=1 seq=1"),
+			message("[APP/PROC/WEB/1]", "Not sequenced"),
+		} {
+			body.WriteString(frame(m))
+		}
+		res, err := http.Post(baseURL+"/drain", "text/plain", strings.NewReader(body.String()))
+		Expect(err).NotTo(HaveOccurred())
+		res.Body.Close()
+
+		summary := app()
+		Expect(summary.Count).To(Equal(9))
+		Expect(summary.Sequences).To(HaveLen(2))
+		for i := range summary.Sequences {
+			Expect(summary.Sequences[i].LatencyMs).NotTo(BeNil())
+			summary.Sequences[i].LatencyMs = nil
+		}
+		Expect(summary.Sequences).To(Equal([]Sequence{
+			{Instance: "[APP/PROC/WEB/0]", Run: "1", Received: 5, Highest: 7, Duplicates: 1, OutOfOrder: 1, Gaps: []Gap{{From: 3, To: 3}, {From: 6, To: 6}}},
+			{Instance: "[APP/PROC/WEB/1]", Run: "1", Received: 1, Highest: 1, Gaps: []Gap{}},
+		}))
+
+		var messages []Received
+		getJSON("/apps/"+appGuid+"/messages", &messages)
+		Expect(messages).To(HaveLen(3), "only the most recent messages are kept")
+		Expect(messages[2].Message.Message).To(Equal("Not sequenced"))
+	})
+
+	It("forgets an app's messages when asked", func() {
+		res, err := http.Post(baseURL+"/drain", "text/plain", strings.NewReader(message("[APP/PROC/WEB/0]", "posted")))
+		Expect(err).NotTo(HaveOccurred())
+		res.Body.Close()
+
+		req, err := http.NewRequest(http.MethodDelete, baseURL+"/apps/"+appGuid, nil)
+		Expect(err).NotTo(HaveOccurred())
+		res, err = http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusNoContent))
+
+		Expect(getJSON("/apps/"+appGuid, &App{})).To(Equal(http.StatusNotFound))
+	})
+
+	It("closes connections that are not octet-counted", func() {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+
+		_, err = conn.Write([]byte(message("[APP/PROC/WEB/0]", "newline framed")))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = ioutil.ReadAll(conn)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(gbytes.Say("Closing syslog connection from .*: frame does not start with an octet count"))
+	})
+})
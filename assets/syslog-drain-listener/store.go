@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DrainSyslog    = "syslog"
+	DrainSyslogTLS = "syslog-tls"
+	DrainHTTPS     = "https"
+)
+
+// sequenced matches the lines written by catnip's log generator. Only the
+// first line of a multi-line entry counts towards its sequence.
+var sequenced = regexp.MustCompile(`catnip-log run=(\S+) seq=(\d+)(?: line=(\d+)/\d+)?`)
+
+type Received struct {
+	Message
+	Drain      string    `json:"drain"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// Gap is a run of sequence numbers, From to To inclusive, that has not been
+// received.
+type Gap struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// Latency is how long messages took from the timestamp Loggregator gave
+// them to reaching the listener, in milliseconds.
+type Latency struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// Sequence tracks the catnip log generator run of one app instance.
+// OutOfOrder counts the entries that arrived after a later one.
+type Sequence struct {
+	Instance   string   `json:"instance"`
+	Run        string   `json:"run"`
+	Received   int      `json:"received"`
+	Highest    int      `json:"highest"`
+	Duplicates int      `json:"duplicates"`
+	OutOfOrder int      `json:"out_of_order"`
+	Gaps       []Gap    `json:"gaps"`
+	LatencyMs  *Latency `json:"latency_ms"`
+
+	seen      map[int]bool
+	latencies []time.Duration
+}
+
+type App struct {
+	AppGuid   string         `json:"app_guid"`
+	Count     int            `json:"count"`
+	Drains    map[string]int `json:"drains"`
+	Sequences []Sequence     `json:"sequences"`
+}
+
+type appLog struct {
+	count     int
+	drains    map[string]int
+	messages  []Received
+	sequences map[string]*Sequence
+}
+
+// Store keeps the messages received for each app, keyed by the app GUID
+// Loggregator sends as the APP-NAME. Only the most recent messages of each
+// app are kept, but counts and sequences cover everything received.
+type Store struct {
+	maxMessages int
+
+	mutex sync.Mutex
+	apps  map[string]*appLog
+}
+
+func NewStore(maxMessages int) *Store {
+	return &Store{
+		maxMessages: maxMessages,
+		apps:        map[string]*appLog{},
+	}
+}
+
+func (s *Store) Add(m Message, drain string, receivedAt time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	app, ok := s.apps[m.AppName]
+	if !ok {
+		app = &appLog{drains: map[string]int{}, sequences: map[string]*Sequence{}}
+		s.apps[m.AppName] = app
+	}
+
+	app.count++
+	app.drains[drain]++
+	app.messages = append(app.messages, Received{Message: m, Drain: drain, ReceivedAt: receivedAt})
+	if len(app.messages) > s.maxMessages {
+		app.messages = app.messages[len(app.messages)-s.maxMessages:]
+	}
+
+	match := sequenced.FindStringSubmatch(m.Message)
+	if match == nil || (match[3] != "" && match[3] != "1") {
+		return
+	}
+	seq, err := strconv.Atoi(match[2])
+	if err != nil {
+		return
+	}
+
+	key := m.ProcID + " " + match[1]
+	sequence, ok := app.sequences[key]
+	if !ok {
+		sequence = &Sequence{Instance: m.ProcID, Run: match[1], seen: map[int]bool{}}
+		app.sequences[key] = sequence
+	}
+	if sequence.seen[seq] {
+		sequence.Duplicates++
+		return
+	}
+	sequence.seen[seq] = true
+	sequence.Received++
+	if seq < sequence.Highest {
+		sequence.OutOfOrder++
+	} else {
+		sequence.Highest = seq
+	}
+	if !m.Timestamp.IsZero() {
+		sequence.latencies = append(sequence.latencies, receivedAt.Sub(m.Timestamp))
+	}
+}
+
+// Counts returns the number of messages received for each app.
+func (s *Store) Counts() map[string]int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	counts := map[string]int{}
+	for guid, app := range s.apps {
+		counts[guid] = app.count
+	}
+	return counts
+}
+
+func (s *Store) App(guid string) (App, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	app, ok := s.apps[guid]
+	if !ok {
+		return App{}, false
+	}
+
+	summary := App{AppGuid: guid, Count: app.count, Drains: map[string]int{}, Sequences: []Sequence{}}
+	for drain, count := range app.drains {
+		summary.Drains[drain] = count
+	}
+	for _, sequence := range app.sequences {
+		summary.Sequences = append(summary.Sequences, sequence.summary())
+	}
+	sort.Slice(summary.Sequences, func(i, j int) bool {
+		if summary.Sequences[i].Instance != summary.Sequences[j].Instance {
+			return summary.Sequences[i].Instance < summary.Sequences[j].Instance
+		}
+		return summary.Sequences[i].Run < summary.Sequences[j].Run
+	})
+	return summary, true
+}
+
+func (s *Store) Messages(guid string) ([]Received, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	app, ok := s.apps[guid]
+	if !ok {
+		return nil, false
+	}
+	return append([]Received{}, app.messages...), true
+}
+
+func (s *Store) Forget(guid string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.apps, guid)
+}
+
+// AppsHandler serves GET /apps, the number of messages received per app.
+func (s *Store) AppsHandler(res http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(res, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(res, s.Counts())
+}
+
+// AppHandler serves GET and DELETE /apps/:guid and GET /apps/:guid/messages.
+func (s *Store) AppHandler(res http.ResponseWriter, req *http.Request) {
+	path := strings.Split(strings.TrimPrefix(req.URL.Path, "/apps/"), "/")
+	guid := path[0]
+
+	switch {
+	case len(path) == 1 && req.Method == http.MethodGet:
+		app, ok := s.App(guid)
+		if !ok {
+			http.Error(res, "No messages received for "+guid, http.StatusNotFound)
+			return
+		}
+		writeJSON(res, app)
+	case len(path) == 1 && req.Method == http.MethodDelete:
+		s.Forget(guid)
+		res.WriteHeader(http.StatusNoContent)
+	case len(path) == 2 && path[1] == "messages" && req.Method == http.MethodGet:
+		messages, ok := s.Messages(guid)
+		if !ok {
+			http.Error(res, "No messages received for "+guid, http.StatusNotFound)
+			return
+		}
+		writeJSON(res, messages)
+	default:
+		http.NotFound(res, req)
+	}
+}
+
+func (s *Sequence) summary() Sequence {
+	sequence := *s
+	sequence.seen = nil
+	sequence.latencies = nil
+	sequence.LatencyMs = latency(s.latencies)
+	sequence.Gaps = []Gap{}
+	for seq := 1; seq <= s.Highest; seq++ {
+		if s.seen[seq] {
+			continue
+		}
+		if n := len(sequence.Gaps); n > 0 && sequence.Gaps[n-1].To == seq-1 {
+			sequence.Gaps[n-1].To = seq
+		} else {
+			sequence.Gaps = append(sequence.Gaps, Gap{From: seq, To: seq})
+		}
+	}
+	return sequence
+}
+
+// latency uses the nearest-rank method, so every percentile is one of the
+// measured latencies.
+func latency(latencies []time.Duration) *Latency {
+	if len(latencies) == 0 {
+		return nil
+	}
+
+	sorted := append([]time.Duration{}, latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	percentile := func(p float64) float64 {
+		rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+		if rank < 1 {
+			rank = 1
+		}
+		return float64(sorted[rank-1]) / float64(time.Millisecond)
+	}
+
+	return &Latency{
+		P50: percentile(50),
+		P90: percentile(90),
+		P99: percentile(99),
+		Max: percentile(100),
+	}
+}
+
+func writeJSON(res http.ResponseWriter, value interface{}) {
+	res.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(res).Encode(value)
+}
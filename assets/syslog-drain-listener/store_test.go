@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Store", func() {
+	var (
+		store *Store
+		sent  time.Time
+	)
+
+	add := func(seq int, latency time.Duration) {
+		store.Add(Message{
+			AppName:   "app-guid",
+			ProcID:    "[APP/PROC/WEB/0]",
+			Timestamp: sent,
+			Message:   fmt.Sprintf("catnip-log run=3 seq=%d", seq),
+		}, DrainSyslog, sent.Add(latency))
+	}
+
+	sequence := func() Sequence {
+		app, ok := store.App("app-guid")
+		Expect(ok).To(BeTrue())
+		Expect(app.Sequences).To(HaveLen(1))
+		return app.Sequences[0]
+	}
+
+	BeforeEach(func() {
+		store = NewStore(10)
+		sent = time.Date(2019, 3, 4, 5, 6, 7, 0, time.UTC)
+	})
+
+	It("reports latency percentiles by nearest rank", func() {
+		for seq := 1; seq <= 100; seq++ {
+			add(seq, time.Duration(seq)*time.Millisecond)
+		}
+
+		Expect(sequence().LatencyMs).To(Equal(&Latency{P50: 50, P90: 90, P99: 99, Max: 100}))
+	})
+
+	It("counts entries that arrive after a later one as out of order", func() {
+		add(2, time.Millisecond)
+		add(1, time.Millisecond)
+		add(4, time.Millisecond)
+		add(3, time.Millisecond)
+
+		Expect(sequence().OutOfOrder).To(Equal(2))
+		Expect(sequence().Gaps).To(BeEmpty())
+	})
+
+	It("only measures latency for messages with a timestamp", func() {
+		sent = time.Time{}
+		add(1, time.Millisecond)
+
+		Expect(sequence().LatencyMs).To(BeNil())
+	})
+
+	It("keeps only the most recent messages but counts them all", func() {
+		for seq := 1; seq <= 15; seq++ {
+			add(seq, time.Millisecond)
+		}
+
+		messages, ok := store.Messages("app-guid")
+		Expect(ok).To(BeTrue())
+		Expect(messages).To(HaveLen(10))
+		Expect(messages[0].Message.Message).To(Equal("catnip-log run=3 seq=6"))
+		Expect(store.Counts()).To(Equal(map[string]int{"app-guid": 15}))
+		Expect(sequence().Received).To(Equal(15))
+	})
+})
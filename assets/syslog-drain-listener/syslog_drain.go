@@ -2,15 +2,21 @@ package main
 
 import (
 	"fmt"
-	"io"
 	"net"
 	"os"
 	"time"
 )
 
+const maxMessages = 10000
+
 func main() {
 	go logIP()
 
+	cert, err := GenerateCertificate(os.Getenv("CF_INSTANCE_IP"), "localhost")
+	if err != nil {
+		panic(err)
+	}
+
 	listenAddress := fmt.Sprintf(":%s", os.Getenv("PORT"))
 	listener, err := net.Listen("tcp", listenAddress)
 	if err != nil {
@@ -18,31 +24,7 @@ func main() {
 	}
 
 	fmt.Println("Listening for new connections")
-	for {
-		conn, err := listener.Accept()
-		if err != nil {
-			panic(err)
-		}
-		go handleConnection(conn)
-	}
-}
-
-func handleConnection(conn net.Conn) {
-	buffer := make([]byte, 65536)
-
-	for {
-		n, err := conn.Read(buffer)
-
-		if err == io.EOF {
-			fmt.Println("connection closed")
-			return
-		} else if err != nil {
-			panic(err)
-		}
-
-		message := string(buffer[0:n])
-		fmt.Println(message)
-	}
+	panic(NewServer(NewStore(maxMessages), cert, os.Stdout).Serve(listener))
 }
 
 func logIP() {
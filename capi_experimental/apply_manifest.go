@@ -88,14 +88,7 @@ var _ = CapiExperimentalDescribe("apply_manifest", func() {
 	})
 
 	Describe("Applying manifest to existing app", func() {
-		var (
-			manifest string
-			endpoint string
-		)
-
-		BeforeEach(func() {
-			endpoint = fmt.Sprintf("/v3/apps/%s/actions/apply_manifest", appGUID)
-		})
+		var manifest string
 
 		Describe("routing", func() {
 			Context("when routes are specified", func() {
@@ -120,18 +113,13 @@ applications:
 				})
 
 				It("successfully completes the job", func() {
-					session := cf.Cf("curl", endpoint, "-X", "POST", "-H", "Content-Type: application/x-yaml", "-d", manifest, "-i")
-					Expect(session.Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
-					response := session.Out.Contents()
-					Expect(string(response)).To(ContainSubstring("202 Accepted"))
-
-					PollJob(GetJobPath(response))
+					ApplyManifest(appGUID, manifest)
 
 					workflowhelpers.AsUser(TestSetup.AdminUserContext(), Config.DefaultTimeoutDuration(), func() {
 						target := cf.Cf("target", "-o", orgName, "-s", spaceName).Wait(Config.DefaultTimeoutDuration())
 						Expect(target).To(Exit(0), "failed targeting")
 
-						session = cf.Cf("app", appName).Wait(Config.DefaultTimeoutDuration())
+						session := cf.Cf("app", appName).Wait(Config.DefaultTimeoutDuration())
 						Eventually(session).Should(Say("Showing health"))
 						Eventually(session).Should(Say("instances:\\s+.*?\\d+/2"))
 						Eventually(session).Should(Say("routes:\\s+(?:%s.%s,\\s+)?%s", appName, Config.GetAppsDomain(), route))
@@ -171,18 +159,13 @@ applications:
 				})
 
 				It("removes existing routes from the app", func() {
-					session := cf.Cf("curl", endpoint, "-X", "POST", "-H", "Content-Type: application/x-yaml", "-d", manifest, "-i")
-					Expect(session.Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
-					response := session.Out.Contents()
-					Expect(string(response)).To(ContainSubstring("202 Accepted"))
-
-					PollJob(GetJobPath(response))
+					ApplyManifest(appGUID, manifest)
 
 					workflowhelpers.AsUser(TestSetup.AdminUserContext(), Config.DefaultTimeoutDuration(), func() {
 						target := cf.Cf("target", "-o", orgName, "-s", spaceName).Wait(Config.DefaultTimeoutDuration())
 						Expect(target).To(Exit(0), "failed targeting")
 
-						session = cf.Cf("app", appName).Wait(Config.DefaultTimeoutDuration())
+						session := cf.Cf("app", appName).Wait(Config.DefaultTimeoutDuration())
 						Eventually(session).Should(Say("Showing health"))
 						Eventually(session).Should(Say("routes:\\s*\\n"))
 						Eventually(session).Should(Exit(0))
@@ -202,18 +185,13 @@ applications:
 				})
 
 				It("successfully adds a random-route", func() {
-					session := cf.Cf("curl", endpoint, "-X", "POST", "-H", "Content-Type: application/x-yaml", "-d", manifest, "-i")
-					Expect(session.Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
-					response := session.Out.Contents()
-					Expect(string(response)).To(ContainSubstring("202 Accepted"))
-
-					PollJob(GetJobPath(response))
+					ApplyManifest(appGUID, manifest)
 
 					workflowhelpers.AsUser(TestSetup.AdminUserContext(), Config.DefaultTimeoutDuration(), func() {
 						target := cf.Cf("target", "-o", orgName, "-s", spaceName).Wait(Config.DefaultTimeoutDuration())
 						Expect(target).To(Exit(0), "failed targeting")
 
-						session = cf.Cf("app", appName).Wait(Config.DefaultTimeoutDuration())
+						session := cf.Cf("app", appName).Wait(Config.DefaultTimeoutDuration())
 						Eventually(session).Should(Say("routes:\\s+%s-\\w+-\\w+.%s", appName, Config.GetAppsDomain()))
 					})
 				})
@@ -237,18 +215,13 @@ applications:
 			})
 
 			It("successfully completes the job", func() {
-				session := cf.Cf("curl", endpoint, "-X", "POST", "-H", "Content-Type: application/x-yaml", "-d", manifest, "-i")
-				Expect(session.Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
-				response := session.Out.Contents()
-				Expect(string(response)).To(ContainSubstring("202 Accepted"))
-
-				PollJob(GetJobPath(response))
+				ApplyManifest(appGUID, manifest)
 
 				workflowhelpers.AsUser(TestSetup.AdminUserContext(), Config.DefaultTimeoutDuration(), func() {
 					target := cf.Cf("target", "-o", orgName, "-s", spaceName).Wait(Config.DefaultTimeoutDuration())
 					Expect(target).To(Exit(0), "failed targeting")
 
-					session = cf.Cf("app", appName).Wait(Config.DefaultTimeoutDuration())
+					session := cf.Cf("app", appName).Wait(Config.DefaultTimeoutDuration())
 					Eventually(session).Should(Say("Showing health"))
 					Eventually(session).Should(Say("instances:\\s+.*?\\d+/2"))
 					Eventually(session).Should(Exit(0))
@@ -281,18 +254,13 @@ applications:
 `, appName)
 				})
 				It("creates the process and completes the job", func() {
-					session := cf.Cf("curl", endpoint, "-X", "POST", "-H", "Content-Type: application/x-yaml", "-d", manifest, "-i")
-					Expect(session.Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
-					response := session.Out.Contents()
-					Expect(string(response)).To(ContainSubstring("202 Accepted"))
-
-					PollJob(GetJobPath(response))
+					ApplyManifest(appGUID, manifest)
 
 					workflowhelpers.AsUser(TestSetup.AdminUserContext(), Config.DefaultTimeoutDuration(), func() {
 						target := cf.Cf("target", "-o", orgName, "-s", spaceName).Wait(Config.DefaultTimeoutDuration())
 						Expect(target).To(Exit(0), "failed targeting")
 
-						session = cf.Cf("v3-app", appName).Wait(Config.DefaultTimeoutDuration())
+						session := cf.Cf("v3-app", appName).Wait(Config.DefaultTimeoutDuration())
 						Eventually(session).Should(Say("potato:0/2"))
 						Eventually(session).Should(Exit(0))
 
@@ -308,5 +276,44 @@ applications:
 				})
 			})
 		})
+
+		Describe("sidecars", func() {
+			BeforeEach(func() {
+				manifest = fmt.Sprintf(`
+applications:
+- name: "%s"
+  sidecars:
+  - name: my-sidecar
+    process_types: [web]
+    command: sleep 100000
+`, appName)
+			})
+
+			It("creates the sidecar and completes the job", func() {
+				ApplyManifest(appGUID, manifest)
+
+				sidecar := GetSidecarByName(GetSidecars(appGUID), "my-sidecar")
+				Expect(sidecar.Guid).NotTo(BeEmpty())
+				Expect(sidecar.Command).To(Equal("sleep 100000"))
+				Expect(sidecar.ProcessTypes).To(ConsistOf("web"))
+
+				webProcess := GetProcessByType(GetProcesses(appGUID, appName), "web")
+				Expect(GetSidecarByName(GetSidecarsForProcess(webProcess.Guid), "my-sidecar").Guid).To(Equal(sidecar.Guid))
+			})
+
+			Context("when the sidecar already exists", func() {
+				BeforeEach(func() {
+					CreateSidecar(appGUID, "my-sidecar", "sleep 1", "web")
+				})
+
+				It("updates the existing sidecar", func() {
+					ApplyManifest(appGUID, manifest)
+
+					sidecars := GetSidecars(appGUID)
+					Expect(sidecars).To(HaveLen(1))
+					Expect(sidecars[0].Command).To(Equal("sleep 100000"))
+				})
+			})
+		})
 	})
 })
@@ -190,6 +190,17 @@ func ServiceInstanceSharingDescribe(description string, callback func()) bool {
 	})
 }
 
+func VolumeServicesDescribe(description string, callback func()) bool {
+	return Describe("[volume_services]", func() {
+		BeforeEach(func() {
+			if !Config.GetIncludeVolumeServices() {
+				Skip(`Skipping this test because Config.IncludeVolumeServices is set to 'false'.`)
+			}
+		})
+		Describe(description, callback)
+	})
+}
+
 func SshDescribe(description string, callback func()) bool {
 	return Describe("[ssh]", func() {
 		BeforeEach(func() {
@@ -213,6 +224,36 @@ func V3Describe(description string, callback func()) bool {
 	})
 }
 
+func DeploymentsDescribe(description string, callback func()) bool {
+	return Describe("[deployments]", func() {
+		BeforeEach(func() {
+			if !Config.GetIncludeV3() {
+				Skip(`Skipping this test because Config.IncludeV3 is set to 'false'.`)
+			}
+
+			if !Config.GetIncludeDeployments() {
+				Skip(`Skipping this test because Config.IncludeDeployments is set to 'false'.`)
+			}
+		})
+		Describe(description, callback)
+	})
+}
+
+func MetadataDescribe(description string, callback func()) bool {
+	return Describe("[metadata]", func() {
+		BeforeEach(func() {
+			if !Config.GetIncludeV3() {
+				Skip(`Skipping this test because Config.IncludeV3 is set to 'false'.`)
+			}
+
+			if !Config.GetIncludeMetadata() {
+				Skip(`Skipping this test because Config.IncludeMetadata is set to 'false'.`)
+			}
+		})
+		Describe(description, callback)
+	})
+}
+
 func CapiExperimentalDescribe(description string, callback func()) bool {
 	return Describe("[capi_experimental]", func() {
 		BeforeEach(func() {
@@ -29,6 +29,7 @@ import (
 	_ "github.com/cloudfoundry/cf-acceptance-tests/ssh"
 	_ "github.com/cloudfoundry/cf-acceptance-tests/tasks"
 	_ "github.com/cloudfoundry/cf-acceptance-tests/v3"
+	_ "github.com/cloudfoundry/cf-acceptance-tests/volume_services"
 	_ "github.com/cloudfoundry/cf-acceptance-tests/windows"
 
 	"github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
@@ -88,6 +89,9 @@ func TestCATS(t *testing.T) {
 		err = buildCmd.Run()
 		Expect(err).NotTo(HaveOccurred())
 
+		err = archiver.Zip.Make(assets.NewAssets().CatnipZip, []string{assets.NewAssets().Catnip + "/catnip"})
+		Expect(err).NotTo(HaveOccurred())
+
 		doraFiles, err := ioutil.ReadDir(assets.NewAssets().Dora)
 		Expect(err).NotTo(HaveOccurred())
 
@@ -123,6 +127,7 @@ func TestCATS(t *testing.T) {
 		}
 	}, func() {
 		os.Remove(assets.NewAssets().DoraZip)
+		os.Remove(assets.NewAssets().CatnipZip)
 	})
 
 	rs := []Reporter{}
@@ -0,0 +1,126 @@
+package credhub
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/app_helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
+)
+
+type instanceIdentity struct {
+	CommonName   string    `json:"common_name"`
+	AppGuid      string    `json:"app_guid"`
+	SpaceGuid    string    `json:"space_guid"`
+	OrgGuid      string    `json:"organization_guid"`
+	DNSNames     []string  `json:"dns_names"`
+	IPAddresses  []string  `json:"ip_addresses"`
+	SerialNumber string    `json:"serial_number"`
+	NotBefore    time.Time `json:"not_before"`
+	NotAfter     time.Time `json:"not_after"`
+	Chain        []struct {
+		Subject string `json:"subject"`
+	} `json:"chain"`
+	KeyMatches bool `json:"key_matches"`
+}
+
+type identityRotation struct {
+	At                   time.Time `json:"at"`
+	PreviousSerialNumber string    `json:"previous_serial_number"`
+	PreviousNotAfter     time.Time `json:"previous_not_after"`
+	SerialNumber         string    `json:"serial_number"`
+	NotAfter             time.Time `json:"not_after"`
+}
+
+var _ = CredhubDescribe("instance identity credentials", func() {
+	var appName string
+
+	BeforeEach(func() {
+		appName = random_name.CATSRandomName("APP")
+		Expect(cf.Cf("push",
+			appName,
+			"-b", Config.GetBinaryBuildpackName(),
+			"-p", assets.NewAssets().Catnip,
+			"-c", "./catnip",
+			"-m", DEFAULT_MEMORY_LIMIT,
+			"-d", Config.GetAppsDomain(),
+		).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+	})
+
+	AfterEach(func() {
+		app_helpers.AppReport(appName, Config.DefaultTimeoutDuration())
+
+		Expect(cf.Cf("delete", appName, "-f", "-r").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+	})
+
+	It("identifies the app, space, organization and instance", func() {
+		var identity instanceIdentity
+		response := helpers.CurlApp(Config, appName, "/identity")
+		Expect(json.Unmarshal([]byte(response), &identity)).To(Succeed(), response)
+
+		spaceGuid := strings.TrimSpace(string(cf.Cf("space", TestSetup.RegularUserContext().Space, "--guid").Wait(Config.DefaultTimeoutDuration()).Out.Contents()))
+		orgGuid := strings.TrimSpace(string(cf.Cf("org", TestSetup.RegularUserContext().Org, "--guid").Wait(Config.DefaultTimeoutDuration()).Out.Contents()))
+		instanceGuid := helpers.CurlApp(Config, appName, "/id")
+		instanceIP := helpers.CurlApp(Config, appName, "/env/CF_INSTANCE_INTERNAL_IP")
+
+		Expect(identity.AppGuid).To(Equal(GuidForAppName(appName)))
+		Expect(identity.SpaceGuid).To(Equal(spaceGuid))
+		Expect(identity.OrgGuid).To(Equal(orgGuid))
+		Expect(identity.CommonName).To(Equal(instanceGuid))
+		Expect(identity.DNSNames).To(ContainElement(instanceGuid))
+		Expect(identity.IPAddresses).To(ContainElement(instanceIP))
+		Expect(identity.Chain).NotTo(BeEmpty())
+		Expect(identity.KeyMatches).To(BeTrue())
+	})
+
+	It("is currently valid and short-lived", func() {
+		var identity instanceIdentity
+		response := helpers.CurlApp(Config, appName, "/identity")
+		Expect(json.Unmarshal([]byte(response), &identity)).To(Succeed(), response)
+
+		now := time.Now()
+		Expect(identity.NotBefore).To(BeTemporally("<=", now.Add(time.Minute)))
+		Expect(identity.NotAfter).To(BeTemporally(">", now))
+		// Diego issues instance credentials for a day at most by default.
+		Expect(identity.NotAfter.Sub(identity.NotBefore)).To(BeNumerically("<=", 25*time.Hour))
+	})
+
+	It("is rotated before it expires", func() {
+		// Diego only replaces the credentials shortly before they expire,
+		// which with the default validity is hours after the push.
+		if !Config.GetIncludeInstanceIdentityRotation() {
+			Skip("Skipping this test because Config.IncludeInstanceIdentityRotation is set to 'false'.")
+		}
+
+		var identity instanceIdentity
+		response := helpers.CurlApp(Config, appName, "/identity")
+		Expect(json.Unmarshal([]byte(response), &identity)).To(Succeed(), response)
+
+		// A failed request has no rotations, so polling it retries.
+		rotations := func() []identityRotation {
+			var rotations []identityRotation
+			curl := helpers.Curl(Config, helpers.AppUri(appName, "/identity/rotations", Config)).Wait(Config.DefaultTimeoutDuration())
+			if curl.ExitCode() != 0 || json.Unmarshal(curl.Out.Contents(), &rotations) != nil {
+				return nil
+			}
+			return rotations
+		}
+		Eventually(rotations, time.Until(identity.NotAfter)+Config.DefaultTimeoutDuration(), 30*time.Second).ShouldNot(BeEmpty())
+
+		rotation := rotations()[0]
+		Expect(rotation.PreviousSerialNumber).To(Equal(identity.SerialNumber))
+		Expect(rotation.PreviousNotAfter).To(BeTemporally("==", identity.NotAfter))
+		Expect(rotation.At).To(BeTemporally("<", rotation.PreviousNotAfter))
+		Expect(rotation.SerialNumber).NotTo(Equal(identity.SerialNumber))
+		Expect(rotation.NotAfter).To(BeTemporally(">", rotation.PreviousNotAfter))
+	})
+})
@@ -310,12 +310,19 @@ EOF
 				})
 
 				It("the bound app gets CredHub refs in VCAP_SERVICES interpolated", func() {
-					curlCmd := helpers.CurlSkipSSL(true, appURL+"/env/VCAP_SERVICES").Wait(Config.DefaultTimeoutDuration())
+					curlCmd := helpers.CurlSkipSSL(true, appURL+"/vcap/services/instance/"+instanceName).Wait(Config.DefaultTimeoutDuration())
 					Expect(curlCmd).To(Exit(0))
 
-					bytes := curlCmd.Out.Contents()
-					Expect(string(bytes)).To(ContainSubstring(`"rainbowDash"`))
-					Expect(string(bytes)).To(ContainSubstring(`"pinkyPie"`))
+					var binding struct {
+						Credentials         map[string]interface{} `json:"credentials"`
+						CredentialsResolved bool                   `json:"credentials_resolved"`
+					}
+					Expect(json.Unmarshal(curlCmd.Out.Contents(), &binding)).To(Succeed(), string(curlCmd.Out.Contents()))
+					Expect(binding.CredentialsResolved).To(BeTrue())
+					Expect(binding.Credentials).To(Equal(map[string]interface{}{
+						"user-name": "pinkyPie",
+						"password":  "rainbowDash",
+					}))
 				})
 			})
 		})
@@ -10,11 +10,15 @@ type CatsConfig interface {
 	GetIncludeCapiExperimental() bool
 	GetIncludeCapiNoBridge() bool
 	GetIncludeContainerNetworking() bool
+	GetIncludeDeployments() bool
 	GetIncludeCredhubAssisted() bool
 	GetIncludeCredhubNonAssisted() bool
 	GetIncludeDetect() bool
 	GetIncludeDocker() bool
 	GetIncludeInternetDependent() bool
+	GetIncludeInstanceIdentityRotation() bool
+	GetIncludeMetadata() bool
+	GetIncludeMetricRegistrar() bool
 	GetIncludePrivateDockerRegistry() bool
 	GetIncludePersistentApp() bool
 	GetIncludePrivilegedContainerSupport() bool
@@ -28,8 +32,10 @@ type CatsConfig interface {
 	GetIncludeSsh() bool
 	GetIncludeTasks() bool
 	GetIncludeV3() bool
+	GetIncludeVolumeServices() bool
 	GetIncludeIsolationSegments() bool
 	GetIncludeRoutingIsolationSegments() bool
+	GetIncludeTCPRouting() bool
 	GetIncludeServiceInstanceSharing() bool
 	GetIncludeWindows() bool
 	GetUseLogCache() bool
@@ -57,6 +63,10 @@ type CatsConfig interface {
 	GetHwcBuildpackName() string
 	GetIsolationSegmentName() string
 	GetIsolationSegmentDomain() string
+	GetTCPDomain() string
+	GetVolumeServiceName() string
+	GetVolumeServicePlanName() string
+	GetVolumeServiceCreateConfig() string
 	GetJavaBuildpackName() string
 	GetNamePrefix() string
 	GetNodejsBuildpackName() string
@@ -69,6 +79,7 @@ type CatsConfig interface {
 	GetPersistentAppSpace() string
 	GetRubyBuildpackName() string
 	GetUnallocatedIPForSecurityGroup() string
+	GetSyslogDrainMaxLossPercent() float64
 	Protocol() string
 
 	GetNumWindowsCells() int
@@ -86,6 +97,7 @@ type CatsConfig interface {
 	GetScaledTimeout(time.Duration) time.Duration
 	LongCurlTimeoutDuration() time.Duration
 	LongTimeoutDuration() time.Duration
+	RouterRequestTimeoutDuration() time.Duration
 	SleepTimeoutDuration() time.Duration
 
 	GetPublicDockerAppImage() string
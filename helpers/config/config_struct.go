@@ -43,6 +43,12 @@ type config struct {
 	IsolationSegmentName   *string `json:"isolation_segment_name"`
 	IsolationSegmentDomain *string `json:"isolation_segment_domain"`
 
+	TCPDomain *string `json:"tcp_domain"`
+
+	VolumeServiceName         *string `json:"volume_service_name"`
+	VolumeServicePlanName     *string `json:"volume_service_plan_name"`
+	VolumeServiceCreateConfig *string `json:"volume_service_create_config"`
+
 	SkipSSLValidation *bool `json:"skip_ssl_validation"`
 
 	ArtifactsDirectory *string `json:"artifacts_directory"`
@@ -53,6 +59,7 @@ type config struct {
 	DefaultTimeout               *int `json:"default_timeout"`
 	DetectTimeout                *int `json:"detect_timeout"`
 	LongCurlTimeout              *int `json:"long_curl_timeout"`
+	RouterRequestTimeout         *int `json:"router_request_timeout"`
 	SleepTimeout                 *int `json:"sleep_timeout"`
 
 	TimeoutScale *float64 `json:"timeout_scale"`
@@ -72,9 +79,13 @@ type config struct {
 	IncludeCapiExperimental           *bool `json:"include_capi_experimental"`
 	IncludeCapiNoBridge               *bool `json:"include_capi_no_bridge"`
 	IncludeContainerNetworking        *bool `json:"include_container_networking"`
+	IncludeDeployments                *bool `json:"include_deployments"`
 	IncludeDetect                     *bool `json:"include_detect"`
 	IncludeDocker                     *bool `json:"include_docker"`
 	IncludeInternetDependent          *bool `json:"include_internet_dependent"`
+	IncludeInstanceIdentityRotation   *bool `json:"include_instance_identity_rotation"`
+	IncludeMetadata                   *bool `json:"include_metadata"`
+	IncludeMetricRegistrar            *bool `json:"include_metric_registrar"`
 	IncludePersistentApp              *bool `json:"include_persistent_app"`
 	IncludePrivateDockerRegistry      *bool `json:"include_private_docker_registry"`
 	IncludePrivilegedContainerSupport *bool `json:"include_privileged_container_support"`
@@ -87,7 +98,9 @@ type config struct {
 	IncludeServiceInstanceSharing     *bool `json:"include_service_instance_sharing"`
 	IncludeSsh                        *bool `json:"include_ssh"`
 	IncludeTasks                      *bool `json:"include_tasks"`
+	IncludeTCPRouting                 *bool `json:"include_tcp_routing"`
 	IncludeV3                         *bool `json:"include_v3"`
+	IncludeVolumeServices             *bool `json:"include_volume_services"`
 	IncludeZipkin                     *bool `json:"include_zipkin"`
 	IncludeIsolationSegments          *bool `json:"include_isolation_segments"`
 	IncludeRoutingIsolationSegments   *bool `json:"include_routing_isolation_segments"`
@@ -112,6 +125,8 @@ type config struct {
 
 	UnallocatedIPForSecurityGroup *string `json:"unallocated_ip_for_security_group"`
 
+	SyslogDrainMaxLossPercent *float64 `json:"syslog_drain_max_loss_percent"`
+
 	NamePrefix *string `json:"name_prefix"`
 
 	ReporterConfig *reporterConfig `json:"reporter_config"`
@@ -150,6 +165,12 @@ func getDefaults() config {
 	defaults.IsolationSegmentName = ptrToString("")
 	defaults.IsolationSegmentDomain = ptrToString("")
 
+	defaults.TCPDomain = ptrToString("")
+
+	defaults.VolumeServiceName = ptrToString("")
+	defaults.VolumeServicePlanName = ptrToString("")
+	defaults.VolumeServiceCreateConfig = ptrToString("")
+
 	defaults.BinaryBuildpackName = ptrToString("binary_buildpack")
 	defaults.GoBuildpackName = ptrToString("go_buildpack")
 	defaults.HwcBuildpackName = ptrToString("hwc_buildpack")
@@ -165,18 +186,23 @@ func getDefaults() config {
 	defaults.IncludePersistentApp = ptrToBool(true)
 	defaults.IncludeRouting = ptrToBool(true)
 	defaults.IncludeV3 = ptrToBool(true)
+	defaults.IncludeVolumeServices = ptrToBool(false)
 
 	defaults.IncludeBackendCompatiblity = ptrToBool(false)
 	defaults.IncludeCapiExperimental = ptrToBool(false)
 	defaults.IncludeCapiNoBridge = ptrToBool(true)
 	defaults.IncludeContainerNetworking = ptrToBool(false)
+	defaults.IncludeDeployments = ptrToBool(false)
 	defaults.CredhubMode = ptrToString("")
 	defaults.CredhubLocation = ptrToString("https://credhub.service.cf.internal:8844")
 	defaults.CredhubClientName = ptrToString("cc_service_key_client")
 	defaults.CredhubClientSecret = ptrToString("")
 	defaults.IncludeDocker = ptrToBool(false)
 	defaults.IncludeInternetDependent = ptrToBool(false)
+	defaults.IncludeInstanceIdentityRotation = ptrToBool(false)
 	defaults.IncludeIsolationSegments = ptrToBool(false)
+	defaults.IncludeMetadata = ptrToBool(false)
+	defaults.IncludeMetricRegistrar = ptrToBool(false)
 	defaults.IncludePrivilegedContainerSupport = ptrToBool(false)
 	defaults.IncludePrivateDockerRegistry = ptrToBool(false)
 	defaults.IncludeRouteServices = ptrToBool(false)
@@ -187,6 +213,7 @@ func getDefaults() config {
 	defaults.IncludeServices = ptrToBool(false)
 	defaults.IncludeSsh = ptrToBool(false)
 	defaults.IncludeTasks = ptrToBool(false)
+	defaults.IncludeTCPRouting = ptrToBool(false)
 	defaults.IncludeZipkin = ptrToBool(false)
 	defaults.IncludeServiceInstanceSharing = ptrToBool(false)
 
@@ -213,6 +240,7 @@ func getDefaults() config {
 	defaults.DefaultTimeout = ptrToInt(30)
 	defaults.DetectTimeout = ptrToInt(300)
 	defaults.LongCurlTimeout = ptrToInt(120)
+	defaults.RouterRequestTimeout = ptrToInt(0)
 	defaults.SleepTimeout = ptrToInt(30)
 
 	defaults.ConfigurableTestPassword = ptrToString("")
@@ -228,6 +256,8 @@ func getDefaults() config {
 
 	defaults.UnallocatedIPForSecurityGroup = ptrToString("10.0.244.255")
 
+	defaults.SyslogDrainMaxLossPercent = ptrToFloat(1.0)
+
 	defaults.NamePrefix = ptrToString("CATS")
 	return defaults
 }
@@ -286,15 +316,30 @@ func validateConfig(config *config) Errors {
 		errs.Add(err)
 	}
 
+	err = validateTCPRouting(config)
+	if err != nil {
+		errs.Add(err)
+	}
+
 	err = validateCredHubSettings(config)
 	if err != nil {
 		errs.Add(err)
 	}
 
+	err = validateVolumeServices(config)
+	if err != nil {
+		errs.Add(err)
+	}
+
 	err = validateWindows(config)
 	if err != nil {
 		errs.Add(err)
 	}
+
+	err = validateSyslogDrainMaxLossPercent(config)
+	if err != nil {
+		errs.Add(err)
+	}
 	if config.UseHttp == nil {
 		errs.Add(fmt.Errorf("* 'use_http' must not be null"))
 	}
@@ -325,6 +370,15 @@ func validateConfig(config *config) Errors {
 	if config.IsolationSegmentDomain == nil {
 		errs.Add(fmt.Errorf("* 'isolation_segment_domain' must not be null"))
 	}
+	if config.VolumeServiceName == nil {
+		errs.Add(fmt.Errorf("* 'volume_service_name' must not be null"))
+	}
+	if config.VolumeServicePlanName == nil {
+		errs.Add(fmt.Errorf("* 'volume_service_plan_name' must not be null"))
+	}
+	if config.VolumeServiceCreateConfig == nil {
+		errs.Add(fmt.Errorf("* 'volume_service_create_config' must not be null"))
+	}
 	if config.SkipSSLValidation == nil {
 		errs.Add(fmt.Errorf("* 'skip_ssl_validation' must not be null"))
 	}
@@ -349,6 +403,9 @@ func validateConfig(config *config) Errors {
 	if config.LongCurlTimeout == nil {
 		errs.Add(fmt.Errorf("* 'long_curl_timeout' must not be null"))
 	}
+	if config.RouterRequestTimeout == nil {
+		errs.Add(fmt.Errorf("* 'router_request_timeout' must not be null"))
+	}
 	if config.SleepTimeout == nil {
 		errs.Add(fmt.Errorf("* 'sleep_timeout' must not be null"))
 	}
@@ -400,6 +457,9 @@ func validateConfig(config *config) Errors {
 	if config.IncludeContainerNetworking == nil {
 		errs.Add(fmt.Errorf("* 'include_container_networking' must not be null"))
 	}
+	if config.IncludeDeployments == nil {
+		errs.Add(fmt.Errorf("* 'include_deployments' must not be null"))
+	}
 	if config.IncludeDetect == nil {
 		errs.Add(fmt.Errorf("* 'include_detect' must not be null"))
 	}
@@ -409,6 +469,15 @@ func validateConfig(config *config) Errors {
 	if config.IncludeInternetDependent == nil {
 		errs.Add(fmt.Errorf("* 'include_internet_dependent' must not be null"))
 	}
+	if config.IncludeInstanceIdentityRotation == nil {
+		errs.Add(fmt.Errorf("* 'include_instance_identity_rotation' must not be null"))
+	}
+	if config.IncludeMetadata == nil {
+		errs.Add(fmt.Errorf("* 'include_metadata' must not be null"))
+	}
+	if config.IncludeMetricRegistrar == nil {
+		errs.Add(fmt.Errorf("* 'include_metric_registrar' must not be null"))
+	}
 	if config.IncludePrivateDockerRegistry == nil {
 		errs.Add(fmt.Errorf("* 'include_private_docker_registry' must not be null"))
 	}
@@ -448,6 +517,9 @@ func validateConfig(config *config) Errors {
 	if config.IncludeV3 == nil {
 		errs.Add(fmt.Errorf("* 'include_v3' must not be null"))
 	}
+	if config.IncludeVolumeServices == nil {
+		errs.Add(fmt.Errorf("* 'include_volume_services' must not be null"))
+	}
 	if config.IncludeZipkin == nil {
 		errs.Add(fmt.Errorf("* 'include_zipkin' must not be null"))
 	}
@@ -604,6 +676,27 @@ func validateIsolationSegments(config *config) error {
 	return nil
 }
 
+func validateVolumeServices(config *config) error {
+	if config.IncludeVolumeServices == nil {
+		return fmt.Errorf("* 'include_volume_services' must not be null")
+	}
+	if config.VolumeServiceName == nil {
+		return fmt.Errorf("* 'volume_service_name' must not be null")
+	}
+	if config.VolumeServicePlanName == nil {
+		return fmt.Errorf("* 'volume_service_plan_name' must not be null")
+	}
+
+	if !config.GetIncludeVolumeServices() {
+		return nil
+	}
+
+	if config.GetVolumeServiceName() == "" || config.GetVolumeServicePlanName() == "" {
+		return fmt.Errorf("* Invalid configuration: 'volume_service_name' and 'volume_service_plan_name' must be provided if 'include_volume_services' is true")
+	}
+	return nil
+}
+
 func validateRoutingIsolationSegments(config *config) error {
 	if config.IncludeRoutingIsolationSegments == nil {
 		return fmt.Errorf("* 'include_routing_isolation_segments' must not be null")
@@ -628,6 +721,24 @@ func validateRoutingIsolationSegments(config *config) error {
 	return nil
 }
 
+func validateTCPRouting(config *config) error {
+	if config.IncludeTCPRouting == nil {
+		return fmt.Errorf("* 'include_tcp_routing' must not be null")
+	}
+	if config.TCPDomain == nil {
+		return fmt.Errorf("* 'tcp_domain' must not be null")
+	}
+
+	if !config.GetIncludeTCPRouting() {
+		return nil
+	}
+
+	if config.GetTCPDomain() == "" {
+		return fmt.Errorf("* Invalid configuration: 'tcp_domain' must be provided if 'include_tcp_routing' is true")
+	}
+	return nil
+}
+
 func validateCredHubSettings(config *config) error {
 	if config.GetIncludeCredhubAssisted() || config.GetIncludeCredhubNonAssisted() {
 		if config.GetCredHubBrokerClientSecret() == "" || config.GetCredHubBrokerClientSecret() == "" {
@@ -659,6 +770,18 @@ func validateWindows(config *config) error {
 	return nil
 }
 
+func validateSyslogDrainMaxLossPercent(config *config) error {
+	if config.SyslogDrainMaxLossPercent == nil {
+		return fmt.Errorf("* 'syslog_drain_max_loss_percent' must not be null")
+	}
+
+	if config.GetSyslogDrainMaxLossPercent() < 0 || config.GetSyslogDrainMaxLossPercent() > 100 {
+		return fmt.Errorf("* Invalid configuration: 'syslog_drain_max_loss_percent' must be between 0 and 100")
+	}
+
+	return nil
+}
+
 func load(path string, config *config) Errors {
 	errs := Errors{}
 	err := loadConfigFromPath(path, config)
@@ -706,6 +829,13 @@ func (c *config) LongCurlTimeoutDuration() time.Duration {
 	return c.GetScaledTimeout(time.Duration(*c.LongCurlTimeout) * time.Second)
 }
 
+// RouterRequestTimeoutDuration is the platform's gorouter request timeout.
+// It is not scaled, since it describes the deployment rather than how long
+// the tests should wait. Zero means it is unknown.
+func (c *config) RouterRequestTimeoutDuration() time.Duration {
+	return time.Duration(*c.RouterRequestTimeout) * time.Second
+}
+
 func (c *config) SleepTimeoutDuration() time.Duration {
 	return c.GetScaledTimeout(time.Duration(*c.SleepTimeout) * time.Second)
 }
@@ -840,6 +970,10 @@ func (c *config) GetIncludeContainerNetworking() bool {
 	return *c.IncludeContainerNetworking
 }
 
+func (c *config) GetIncludeDeployments() bool {
+	return *c.IncludeDeployments
+}
+
 func (c *config) GetIncludeDetect() bool {
 	return *c.IncludeDetect
 }
@@ -852,6 +986,18 @@ func (c *config) GetIncludeInternetDependent() bool {
 	return *c.IncludeInternetDependent
 }
 
+func (c *config) GetIncludeInstanceIdentityRotation() bool {
+	return *c.IncludeInstanceIdentityRotation
+}
+
+func (c *config) GetIncludeMetadata() bool {
+	return *c.IncludeMetadata
+}
+
+func (c *config) GetIncludeMetricRegistrar() bool {
+	return *c.IncludeMetricRegistrar
+}
+
 func (c *config) GetIncludeRouteServices() bool {
 	return *c.IncludeRouteServices
 }
@@ -892,6 +1038,22 @@ func (c *config) GetIncludeV3() bool {
 	return *c.IncludeV3
 }
 
+func (c *config) GetIncludeVolumeServices() bool {
+	return *c.IncludeVolumeServices
+}
+
+func (c *config) GetVolumeServiceName() string {
+	return *c.VolumeServiceName
+}
+
+func (c *config) GetVolumeServicePlanName() string {
+	return *c.VolumeServicePlanName
+}
+
+func (c *config) GetVolumeServiceCreateConfig() string {
+	return *c.VolumeServiceCreateConfig
+}
+
 func (c *config) GetIncludeIsolationSegments() bool {
 	return *c.IncludeIsolationSegments
 }
@@ -900,6 +1062,14 @@ func (c *config) GetIncludeRoutingIsolationSegments() bool {
 	return *c.IncludeRoutingIsolationSegments
 }
 
+func (c *config) GetIncludeTCPRouting() bool {
+	return *c.IncludeTCPRouting
+}
+
+func (c *config) GetTCPDomain() string {
+	return *c.TCPDomain
+}
+
 func (c *config) GetIncludeCapiExperimental() bool {
 	return *c.IncludeCapiExperimental
 }
@@ -988,6 +1158,10 @@ func (c *config) GetUnallocatedIPForSecurityGroup() string {
 	return *c.UnallocatedIPForSecurityGroup
 }
 
+func (c *config) GetSyslogDrainMaxLossPercent() float64 {
+	return *c.SyslogDrainMaxLossPercent
+}
+
 func (c *config) GetNumWindowsCells() int {
 	return *c.NumWindowsCells
 }
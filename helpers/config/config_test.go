@@ -37,6 +37,7 @@ type testConfig struct {
 	DefaultTimeout               *int `json:"default_timeout,omitempty"`
 	CfPushTimeout                *int `json:"cf_push_timeout,omitempty"`
 	LongCurlTimeout              *int `json:"long_curl_timeout,omitempty"`
+	RouterRequestTimeout         *int `json:"router_request_timeout,omitempty"`
 	BrokerStartTimeout           *int `json:"broker_start_timeout,omitempty"`
 	AsyncServiceOperationTimeout *int `json:"async_service_operation_timeout,omitempty"`
 	DetectTimeout                *int `json:"detect_timeout,omitempty"`
@@ -57,6 +58,15 @@ type testConfig struct {
 	IsolationSegmentDomain          *string `json:"isolation_segment_domain,omitempty"`
 	UnallocatedIPForSecurityGroup   *string `json:"unallocated_ip_for_security_group"`
 
+	IncludeTCPRouting *bool   `json:"include_tcp_routing,omitempty"`
+	TCPDomain         *string `json:"tcp_domain,omitempty"`
+
+	IncludeVolumeServices *bool   `json:"include_volume_services,omitempty"`
+	VolumeServiceName     *string `json:"volume_service_name,omitempty"`
+	VolumeServicePlanName *string `json:"volume_service_plan_name,omitempty"`
+
+	SyslogDrainMaxLossPercent *float64 `json:"syslog_drain_max_loss_percent,omitempty"`
+
 	IncludeWindows        *bool   `json:"include_windows,omitempty"`
 	NumWindowsCells       *int    `json:"num_windows_cells,omitempty"`
 	UseWindowsTestTask    *bool   `json:"use_windows_test_task,omitempty"`
@@ -92,6 +102,12 @@ type allConfig struct {
 	IsolationSegmentName   *string `json:"isolation_segment_name"`
 	IsolationSegmentDomain *string `json:"isolation_segment_domain"`
 
+	TCPDomain *string `json:"tcp_domain"`
+
+	VolumeServiceName         *string `json:"volume_service_name"`
+	VolumeServicePlanName     *string `json:"volume_service_plan_name"`
+	VolumeServiceCreateConfig *string `json:"volume_service_create_config"`
+
 	SkipSSLValidation *bool `json:"skip_ssl_validation"`
 
 	ArtifactsDirectory *string `json:"artifacts_directory"`
@@ -102,6 +118,7 @@ type allConfig struct {
 	DefaultTimeout               *int `json:"default_timeout"`
 	DetectTimeout                *int `json:"detect_timeout"`
 	LongCurlTimeout              *int `json:"long_curl_timeout"`
+	RouterRequestTimeout         *int `json:"router_request_timeout"`
 	SleepTimeout                 *int `json:"sleep_timeout"`
 
 	TimeoutScale *float64 `json:"timeout_scale"`
@@ -124,9 +141,13 @@ type allConfig struct {
 	IncludeCapiNoBridge               *bool `json:"include_capi_no_bridge"`
 	IncludeContainerNetworking        *bool `json:"include_container_networking"`
 	IncludeCredHub                    *bool `json:"include_credhub"`
+	IncludeDeployments                *bool `json:"include_deployments"`
 	IncludeDetect                     *bool `json:"include_detect"`
 	IncludeDocker                     *bool `json:"include_docker"`
 	IncludeInternetDependent          *bool `json:"include_internet_dependent"`
+	IncludeInstanceIdentityRotation   *bool `json:"include_instance_identity_rotation"`
+	IncludeMetadata                   *bool `json:"include_metadata"`
+	IncludeMetricRegistrar            *bool `json:"include_metric_registrar"`
 	IncludePrivateDockerRegistry      *bool `json:"include_private_docker_registry"`
 	IncludePersistentApp              *bool `json:"include_persistent_app"`
 	IncludePrivilegedContainerSupport *bool `json:"include_privileged_container_support"`
@@ -138,16 +159,20 @@ type allConfig struct {
 	IncludeServiceInstanceSharing     *bool `json:"include_service_instance_sharing"`
 	IncludeSsh                        *bool `json:"include_ssh"`
 	IncludeTasks                      *bool `json:"include_tasks"`
+	IncludeTCPRouting                 *bool `json:"include_tcp_routing"`
 	IncludeV3                         *bool `json:"include_v3"`
 	IncludeWindows                    *bool `json:"include_windows"`
 	IncludeZipkin                     *bool `json:"include_zipkin"`
 	IncludeIsolationSegments          *bool `json:"include_isolation_segments"`
+	IncludeVolumeServices             *bool `json:"include_volume_services"`
 
 	PrivateDockerRegistryImage    *string `json:"private_docker_registry_image"`
 	PrivateDockerRegistryUsername *string `json:"private_docker_registry_username"`
 	PrivateDockerRegistryPassword *string `json:"private_docker_registry_password"`
 	PublicDockerAppImage          *string `json:"public_docker_app_image"`
 
+	SyslogDrainMaxLossPercent *float64 `json:"syslog_drain_max_loss_percent"`
+
 	NamePrefix *string `json:"name_prefix"`
 }
 
@@ -236,6 +261,12 @@ var _ = Describe("Config", func() {
 		Expect(config.GetIsolationSegmentName()).To(Equal(""))
 		Expect(config.GetIsolationSegmentDomain()).To(Equal(""))
 
+		Expect(config.GetTCPDomain()).To(Equal(""))
+
+		Expect(config.GetVolumeServiceName()).To(Equal(""))
+		Expect(config.GetVolumeServicePlanName()).To(Equal(""))
+		Expect(config.GetVolumeServiceCreateConfig()).To(Equal(""))
+
 		Expect(config.GetIncludeApps()).To(BeTrue())
 		Expect(config.GetIncludeDetect()).To(BeTrue())
 		Expect(config.GetIncludeRouting()).To(BeTrue())
@@ -246,18 +277,24 @@ var _ = Describe("Config", func() {
 		Expect(config.GetIncludeCapiNoBridge()).To(BeTrue())
 		Expect(config.GetIncludeDocker()).To(BeFalse())
 		Expect(config.GetIncludeInternetDependent()).To(BeFalse())
+		Expect(config.GetIncludeInstanceIdentityRotation()).To(BeFalse())
+		Expect(config.GetIncludeMetadata()).To(BeFalse())
+		Expect(config.GetIncludeMetricRegistrar()).To(BeFalse())
 		Expect(config.GetIncludeRouteServices()).To(BeFalse())
 		Expect(config.GetIncludeContainerNetworking()).To(BeFalse())
+		Expect(config.GetIncludeDeployments()).To(BeFalse())
 		Expect(config.GetIncludeSecurityGroups()).To(BeFalse())
 		Expect(config.GetIncludeServiceDiscovery()).To(BeFalse())
 		Expect(config.GetIncludeServices()).To(BeFalse())
 		Expect(config.GetIncludeSsh()).To(BeFalse())
 		Expect(config.GetIncludeIsolationSegments()).To(BeFalse())
+		Expect(config.GetIncludeVolumeServices()).To(BeFalse())
 		Expect(config.GetIncludePrivateDockerRegistry()).To(BeFalse())
 		Expect(config.GetIncludePrivilegedContainerSupport()).To(BeFalse())
 		Expect(config.GetIncludeZipkin()).To(BeFalse())
 		Expect(config.GetIncludeSSO()).To(BeFalse())
 		Expect(config.GetIncludeTasks()).To(BeFalse())
+		Expect(config.GetIncludeTCPRouting()).To(BeFalse())
 		Expect(config.GetIncludeCredhubAssisted()).To(BeFalse())
 		Expect(config.GetIncludeCredhubNonAssisted()).To(BeFalse())
 		Expect(config.GetIncludeServiceInstanceSharing()).To(BeFalse())
@@ -299,9 +336,11 @@ var _ = Describe("Config", func() {
 		// undocumented
 		Expect(config.DetectTimeoutDuration()).To(Equal(10 * time.Minute))
 		Expect(config.SleepTimeoutDuration()).To(Equal(60 * time.Second))
+		Expect(config.RouterRequestTimeoutDuration()).To(Equal(time.Duration(0)))
 
 		Expect(config.GetPublicDockerAppImage()).To(Equal("cloudfoundry/diego-docker-app-custom:latest"))
 		Expect(config.GetUnallocatedIPForSecurityGroup()).To(Equal("10.0.244.255"))
+		Expect(config.GetSyslogDrainMaxLossPercent()).To(Equal(1.0))
 	})
 
 	Context("when all values are null", func() {
@@ -341,6 +380,7 @@ var _ = Describe("Config", func() {
 			Expect(err.Error()).To(ContainSubstring("'default_timeout' must not be null"))
 			Expect(err.Error()).To(ContainSubstring("'detect_timeout' must not be null"))
 			Expect(err.Error()).To(ContainSubstring("'long_curl_timeout' must not be null"))
+			Expect(err.Error()).To(ContainSubstring("'router_request_timeout' must not be null"))
 			Expect(err.Error()).To(ContainSubstring("'sleep_timeout' must not be null"))
 
 			Expect(err.Error()).To(ContainSubstring("'timeout_scale' must not be null"))
@@ -358,9 +398,13 @@ var _ = Describe("Config", func() {
 			Expect(err.Error()).To(ContainSubstring("'include_backend_compatibility' must not be null"))
 			Expect(err.Error()).To(ContainSubstring("'include_capi_experimental' must not be null"))
 			Expect(err.Error()).To(ContainSubstring("'include_capi_no_bridge' must not be null"))
+			Expect(err.Error()).To(ContainSubstring("'include_deployments' must not be null"))
 			Expect(err.Error()).To(ContainSubstring("'include_detect' must not be null"))
 			Expect(err.Error()).To(ContainSubstring("'include_docker' must not be null"))
 			Expect(err.Error()).To(ContainSubstring("'include_internet_dependent' must not be null"))
+			Expect(err.Error()).To(ContainSubstring("'include_instance_identity_rotation' must not be null"))
+			Expect(err.Error()).To(ContainSubstring("'include_metadata' must not be null"))
+			Expect(err.Error()).To(ContainSubstring("'include_metric_registrar' must not be null"))
 			Expect(err.Error()).To(ContainSubstring("'include_persistent_app' must not be null"))
 			Expect(err.Error()).To(ContainSubstring("'include_private_docker_registry' must not be null"))
 			Expect(err.Error()).To(ContainSubstring("'include_privileged_container_support' must not be null"))
@@ -373,14 +417,21 @@ var _ = Describe("Config", func() {
 			Expect(err.Error()).To(ContainSubstring("'include_service_instance_sharing' must not be null"))
 			Expect(err.Error()).To(ContainSubstring("'include_ssh' must not be null"))
 			Expect(err.Error()).To(ContainSubstring("'include_tasks' must not be null"))
+			Expect(err.Error()).To(ContainSubstring("'include_tcp_routing' must not be null"))
 			Expect(err.Error()).To(ContainSubstring("'include_v3' must not be null"))
 			Expect(err.Error()).To(ContainSubstring("'include_zipkin' must not be null"))
 			Expect(err.Error()).To(ContainSubstring("'include_isolation_segments' must not be null"))
+			Expect(err.Error()).To(ContainSubstring("'include_volume_services' must not be null"))
+			Expect(err.Error()).To(ContainSubstring("'volume_service_name' must not be null"))
+			Expect(err.Error()).To(ContainSubstring("'volume_service_plan_name' must not be null"))
+			Expect(err.Error()).To(ContainSubstring("'volume_service_create_config' must not be null"))
 			Expect(err.Error()).To(ContainSubstring("'include_windows' must not be null"))
 			Expect(err.Error()).To(ContainSubstring("'private_docker_registry_image' must not be null"))
 			Expect(err.Error()).To(ContainSubstring("'private_docker_registry_username' must not be null"))
 			Expect(err.Error()).To(ContainSubstring("'private_docker_registry_password' must not be null"))
 
+			Expect(err.Error()).To(ContainSubstring("'syslog_drain_max_loss_percent' must not be null"))
+
 			Expect(err.Error()).To(ContainSubstring("'name_prefix' must not be null"))
 		})
 	})
@@ -394,8 +445,10 @@ var _ = Describe("Config", func() {
 			testCfg.AsyncServiceOperationTimeout = ptrToInt(90)
 			testCfg.DetectTimeout = ptrToInt(100)
 			testCfg.SleepTimeout = ptrToInt(101)
+			testCfg.RouterRequestTimeout = ptrToInt(15)
 			testCfg.TimeoutScale = ptrToFloat(1.0)
 			testCfg.UnallocatedIPForSecurityGroup = ptrToString("192.168.0.1")
+			testCfg.SyslogDrainMaxLossPercent = ptrToFloat(0.5)
 		})
 
 		It("respects the overriden values", func() {
@@ -410,7 +463,21 @@ var _ = Describe("Config", func() {
 			Expect(config.DetectTimeoutDuration()).To(Equal(100 * time.Second))
 			Expect(config.SleepTimeoutDuration()).To(Equal(101 * time.Second))
 			Expect(config.SleepTimeoutDuration()).To(Equal(101 * time.Second))
+			Expect(config.RouterRequestTimeoutDuration()).To(Equal(15 * time.Second))
 			Expect(config.GetUnallocatedIPForSecurityGroup()).To(Equal("192.168.0.1"))
+			Expect(config.GetSyslogDrainMaxLossPercent()).To(Equal(0.5))
+		})
+	})
+
+	Context("when the syslog drain loss threshold is not a percentage", func() {
+		BeforeEach(func() {
+			testCfg.SyslogDrainMaxLossPercent = ptrToFloat(101)
+		})
+
+		It("returns an error", func() {
+			config, err := cfg.NewCatsConfig(tmpFilePath)
+			Expect(config).To(BeNil())
+			Expect(err).To(MatchError("* Invalid configuration: 'syslog_drain_max_loss_percent' must be between 0 and 100"))
 		})
 	})
 
@@ -505,6 +572,33 @@ var _ = Describe("Config", func() {
 		})
 	})
 
+	Context("when including volume services tests", func() {
+		BeforeEach(func() {
+			testCfg.IncludeVolumeServices = ptrToBool(true)
+			testCfg.VolumeServiceName = ptrToString("nfs")
+			testCfg.VolumeServicePlanName = ptrToString("Existing")
+		})
+
+		It("is valid", func() {
+			config, err := cfg.NewCatsConfig(tmpFilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.GetVolumeServiceName()).To(Equal("nfs"))
+			Expect(config.GetVolumeServicePlanName()).To(Equal("Existing"))
+		})
+
+		Context("when the service plan is an empty string", func() {
+			BeforeEach(func() {
+				testCfg.VolumeServicePlanName = ptrToString("")
+			})
+
+			It("returns an error", func() {
+				config, err := cfg.NewCatsConfig(tmpFilePath)
+				Expect(config).To(BeNil())
+				Expect(err).To(MatchError("* Invalid configuration: 'volume_service_name' and 'volume_service_plan_name' must be provided if 'include_volume_services' is true"))
+			})
+		})
+	})
+
 	Context("when including windows tests", func() {
 		BeforeEach(func() {
 			testCfg.IncludeWindows = ptrToBool(true)
@@ -563,6 +657,32 @@ var _ = Describe("Config", func() {
 
 	})
 
+	Context("when including tcp routing tests", func() {
+		BeforeEach(func() {
+			testCfg.IncludeTCPRouting = ptrToBool(true)
+			testCfg.TCPDomain = ptrToString("tcp.bosh-lite.com")
+		})
+
+		It("is loaded into the config", func() {
+			config, err := cfg.NewCatsConfig(tmpFilePath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.GetIncludeTCPRouting()).To(BeTrue())
+			Expect(config.GetTCPDomain()).To(Equal("tcp.bosh-lite.com"))
+		})
+
+		Context("when tcp_domain is an empty string", func() {
+			BeforeEach(func() {
+				testCfg.TCPDomain = ptrToString("")
+			})
+
+			It("returns an error", func() {
+				config, err := cfg.NewCatsConfig(tmpFilePath)
+				Expect(config).To(BeNil())
+				Expect(err).To(MatchError("* Invalid configuration: 'tcp_domain' must be provided if 'include_tcp_routing' is true"))
+			})
+		})
+	})
+
 	Context("when including routing isolation segment tests", func() {
 		BeforeEach(func() {
 			testCfg.IncludeRoutingIsolationSegments = ptrToBool(true)
@@ -0,0 +1,37 @@
+package logs
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// SequenceNumbers finds the lines written by the given catnip log generator
+// run and returns their sequence numbers in the order they appear.
+func SequenceNumbers(output []byte, runId string) []int {
+	line := regexp.MustCompile(fmt.Sprintf(`catnip-log run=%s seq=(\d+)`, regexp.QuoteMeta(runId)))
+
+	sequence := []int{}
+	for _, match := range line.FindAllSubmatch(output, -1) {
+		seq, err := strconv.Atoi(string(match[1]))
+		if err == nil {
+			sequence = append(sequence, seq)
+		}
+	}
+	return sequence
+}
+
+// Loss is the fraction of the sequence numbers 1 to sent that are missing.
+func Loss(sequence []int, sent int) float64 {
+	if sent == 0 {
+		return 0
+	}
+
+	received := map[int]bool{}
+	for _, seq := range sequence {
+		if seq >= 1 && seq <= sent {
+			received[seq] = true
+		}
+	}
+	return float64(sent-len(received)) / float64(sent)
+}
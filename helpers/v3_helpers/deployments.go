@@ -0,0 +1,100 @@
+package v3_helpers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+const (
+	DEPLOYMENT_DEPLOYING = "DEPLOYING"
+	DEPLOYMENT_DEPLOYED  = "DEPLOYED"
+	DEPLOYMENT_CANCELING = "CANCELING"
+	DEPLOYMENT_CANCELED  = "CANCELED"
+)
+
+type Deployment struct {
+	Guid    string `json:"guid"`
+	State   string `json:"state"`
+	Droplet struct {
+		Guid string `json:"guid"`
+	} `json:"droplet"`
+	PreviousDroplet struct {
+		Guid string `json:"guid"`
+	} `json:"previous_droplet"`
+	Revision struct {
+		Guid    string `json:"guid"`
+		Version int    `json:"version"`
+	} `json:"revision"`
+}
+
+func CreateDeployment(appGuid string) string {
+	deploymentBody := fmt.Sprintf(`{"relationships":{"app":{"data":{"guid":"%s"}}}}`, appGuid)
+	return createDeployment(deploymentBody)
+}
+
+func CreateDeploymentForDroplet(appGuid, dropletGuid string) string {
+	deploymentBody := fmt.Sprintf(`{"droplet":{"guid":"%s"},"relationships":{"app":{"data":{"guid":"%s"}}}}`, dropletGuid, appGuid)
+	return createDeployment(deploymentBody)
+}
+
+func CreateDeploymentForRevision(appGuid, revisionGuid string) string {
+	deploymentBody := fmt.Sprintf(`{"revision":{"guid":"%s"},"relationships":{"app":{"data":{"guid":"%s"}}}}`, revisionGuid, appGuid)
+	return createDeployment(deploymentBody)
+}
+
+func GetDeployment(deploymentGuid string) Deployment {
+	deploymentPath := fmt.Sprintf("/v3/deployments/%s", deploymentGuid)
+	session := cf.Cf("curl", deploymentPath).Wait(Config.DefaultTimeoutDuration())
+	Expect(session).To(Exit(0))
+
+	var deployment Deployment
+	err := json.Unmarshal(session.Out.Contents(), &deployment)
+	Expect(err).ToNot(HaveOccurred())
+	return deployment
+}
+
+func GetDeploymentState(deploymentGuid string) string {
+	return GetDeployment(deploymentGuid).State
+}
+
+func CancelDeployment(deploymentGuid string) {
+	cancelPath := fmt.Sprintf("/v3/deployments/%s/actions/cancel", deploymentGuid)
+	session := cf.Cf("curl", cancelPath, "-X", "POST").Wait(Config.DefaultTimeoutDuration())
+	Expect(session).To(Exit(0))
+	Expect(string(session.Out.Contents())).ToNot(ContainSubstring("errors"))
+}
+
+func WaitForDeploymentToReachState(deploymentGuid, state string) {
+	Eventually(func() string {
+		return GetDeploymentState(deploymentGuid)
+	}, Config.CfPushTimeoutDuration()).Should(Equal(state))
+}
+
+func GetCurrentDropletGuid(appGuid string) string {
+	currentDropletPath := fmt.Sprintf("/v3/apps/%s/droplets/current", appGuid)
+	session := cf.Cf("curl", currentDropletPath).Wait(Config.DefaultTimeoutDuration())
+	Expect(session).To(Exit(0))
+
+	var droplet struct {
+		Guid string `json:"guid"`
+	}
+	Expect(json.Unmarshal(session.Out.Contents(), &droplet)).To(Succeed(), string(session.Out.Contents()))
+	return droplet.Guid
+}
+
+//private
+
+func createDeployment(deploymentBody string) string {
+	session := cf.Cf("curl", "/v3/deployments", "-X", "POST", "-d", deploymentBody).Wait(Config.DefaultTimeoutDuration())
+	Expect(session).To(Exit(0))
+
+	var deployment Deployment
+	Expect(json.Unmarshal(session.Out.Contents(), &deployment)).To(Succeed(), string(session.Out.Contents()))
+	Expect(deployment.Guid).NotTo(BeEmpty(), string(session.Out.Contents()))
+	return deployment.Guid
+}
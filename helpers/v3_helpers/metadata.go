@@ -0,0 +1,132 @@
+package v3_helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+const (
+	METADATA_APPS              = "apps"
+	METADATA_BUILDPACKS        = "buildpacks"
+	METADATA_ORGANIZATIONS     = "organizations"
+	METADATA_PROCESSES         = "processes"
+	METADATA_ROUTES            = "routes"
+	METADATA_SERVICE_INSTANCES = "service_instances"
+	METADATA_SPACES            = "spaces"
+)
+
+type ResourceMetadata struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// A nil value in labels or annotations removes that key from the resource.
+type MetadataPatch struct {
+	Labels      map[string]*string `json:"labels,omitempty"`
+	Annotations map[string]*string `json:"annotations,omitempty"`
+}
+
+func SetLabels(resource, guid string, labels map[string]string) {
+	PatchMetadata(resource, guid, MetadataPatch{Labels: toPatchValues(labels)})
+}
+
+func SetAnnotations(resource, guid string, annotations map[string]string) {
+	PatchMetadata(resource, guid, MetadataPatch{Annotations: toPatchValues(annotations)})
+}
+
+func RemoveLabel(resource, guid, key string) {
+	PatchMetadata(resource, guid, MetadataPatch{Labels: map[string]*string{key: nil}})
+}
+
+func RemoveAnnotation(resource, guid, key string) {
+	PatchMetadata(resource, guid, MetadataPatch{Annotations: map[string]*string{key: nil}})
+}
+
+func PatchMetadata(resource, guid string, patch MetadataPatch) {
+	session := patchMetadata(resource, guid, patch)
+	Expect(string(session.Out.Contents())).ToNot(ContainSubstring("errors"))
+}
+
+// PatchMetadataExpectingError returns the detail of the first error returned by the API.
+func PatchMetadataExpectingError(resource, guid string, patch MetadataPatch) string {
+	session := patchMetadata(resource, guid, patch)
+
+	var response struct {
+		Errors []struct {
+			Detail string `json:"detail"`
+			Title  string `json:"title"`
+		} `json:"errors"`
+	}
+	err := json.Unmarshal(session.Out.Contents(), &response)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(response.Errors).NotTo(BeEmpty(), string(session.Out.Contents()))
+	return response.Errors[0].Detail
+}
+
+func GetMetadata(resource, guid string) ResourceMetadata {
+	resourcePath := fmt.Sprintf("/v3/%s/%s", resource, guid)
+	session := cf.Cf("curl", resourcePath).Wait(Config.DefaultTimeoutDuration())
+	Expect(session).To(Exit(0))
+
+	var response struct {
+		Metadata ResourceMetadata `json:"metadata"`
+	}
+	err := json.Unmarshal(session.Out.Contents(), &response)
+	Expect(err).ToNot(HaveOccurred())
+	return response.Metadata
+}
+
+// GetGuidsWithLabelSelector lists the guids of resources matching the selector.
+// Extra query parameters, such as space_guids, may be given to narrow the search.
+func GetGuidsWithLabelSelector(resource, labelSelector string, query url.Values) []string {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("label_selector", labelSelector)
+	resourcesPath := fmt.Sprintf("/v3/%s?%s", resource, query.Encode())
+	session := cf.Cf("curl", resourcesPath).Wait(Config.DefaultTimeoutDuration())
+	Expect(session).To(Exit(0))
+
+	var response struct {
+		Resources []struct {
+			Guid string `json:"guid"`
+		} `json:"resources"`
+	}
+	err := json.Unmarshal(session.Out.Contents(), &response)
+	Expect(err).ToNot(HaveOccurred(), string(session.Out.Contents()))
+
+	guids := []string{}
+	for _, resource := range response.Resources {
+		guids = append(guids, resource.Guid)
+	}
+	return guids
+}
+
+//private
+
+func patchMetadata(resource, guid string, patch MetadataPatch) *Session {
+	body, err := json.Marshal(struct {
+		Metadata MetadataPatch `json:"metadata"`
+	}{patch})
+	Expect(err).ToNot(HaveOccurred())
+
+	resourcePath := fmt.Sprintf("/v3/%s/%s", resource, guid)
+	session := cf.Cf("curl", resourcePath, "-X", "PATCH", "-d", string(body)).Wait(Config.DefaultTimeoutDuration())
+	Expect(session).To(Exit(0))
+	return session
+}
+
+func toPatchValues(values map[string]string) map[string]*string {
+	patchValues := map[string]*string{}
+	for key, value := range values {
+		v := value
+		patchValues[key] = &v
+	}
+	return patchValues
+}
@@ -3,9 +3,12 @@ package v3_helpers
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
 	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
 )
 
 type ProcessList struct {
@@ -13,10 +16,54 @@ type ProcessList struct {
 }
 
 type Process struct {
-	Guid    string `json:"guid"`
-	Type    string `json:"type"`
-	Command string `json:"command"`
-	Name    string `json:"-"`
+	Guid                         string `json:"guid"`
+	Type                         string `json:"type"`
+	Command                      string `json:"command"`
+	Instances                    int    `json:"instances"`
+	MemoryInMb                   int    `json:"memory_in_mb"`
+	DiskInMb                     int    `json:"disk_in_mb"`
+	LogRateLimitInBytesPerSecond int    `json:"log_rate_limit_in_bytes_per_second"`
+	Name                         string `json:"-"`
+}
+
+// ProcessScale only sends the fields that are set; zero values leave the
+// current setting untouched.
+type ProcessScale struct {
+	Instances                    int `json:"instances,omitempty"`
+	MemoryInMb                   int `json:"memory_in_mb,omitempty"`
+	DiskInMb                     int `json:"disk_in_mb,omitempty"`
+	LogRateLimitInBytesPerSecond int `json:"log_rate_limit_in_bytes_per_second,omitempty"`
+}
+
+type ProcessStats struct {
+	Instances []ProcessInstance `json:"resources"`
+}
+
+type ProcessInstance struct {
+	Index         int    `json:"index"`
+	State         string `json:"state"`
+	Host          string `json:"host"`
+	Uptime        int    `json:"uptime"`
+	InstancePorts []struct {
+		External int `json:"external"`
+		Internal int `json:"internal"`
+	} `json:"instance_ports"`
+	Usage struct {
+		Time    string  `json:"time"`
+		Cpu     float64 `json:"cpu"`
+		Mem     int     `json:"mem"`
+		Disk    int     `json:"disk"`
+		LogRate int     `json:"log_rate"`
+	} `json:"usage"`
+}
+
+// HostPort returns the cell address and the externally mapped port of the
+// instance's first application port.
+func (i ProcessInstance) HostPort() (string, int) {
+	if len(i.InstancePorts) == 0 {
+		return i.Host, 0
+	}
+	return i.Host, i.InstancePorts[0].External
 }
 
 func GetProcesses(appGuid, appName string) []Process {
@@ -53,3 +100,88 @@ func GetProcessByGuid(processGuid string) Process {
 
 	return process
 }
+
+func UpdateProcessCommand(processGuid, command string) {
+	processURL := fmt.Sprintf("/v3/processes/%s", processGuid)
+	processBody := fmt.Sprintf(`{"command":"%s"}`, command)
+	session := cf.Cf("curl", processURL, "-X", "PATCH", "-d", processBody).Wait(Config.DefaultTimeoutDuration())
+	Expect(session).To(Exit(0))
+
+	var process Process
+	json.Unmarshal(session.Out.Contents(), &process)
+	Expect(process.Command).To(Equal(command))
+}
+
+func ScaleProcessByGuid(processGuid string, scale ProcessScale) Process {
+	scalePath := fmt.Sprintf("/v3/processes/%s/actions/scale", processGuid)
+	scaleBody, err := json.Marshal(scale)
+	Expect(err).ToNot(HaveOccurred())
+	session := cf.Cf("curl", scalePath, "-X", "POST", "-d", string(scaleBody)).Wait(Config.DefaultTimeoutDuration())
+	Expect(session).To(Exit(0))
+	Expect(string(session.Out.Contents())).ToNot(ContainSubstring("errors"))
+
+	var process Process
+	json.Unmarshal(session.Out.Contents(), &process)
+	return process
+}
+
+func GetProcessStats(processGuid string) ProcessStats {
+	statsURL := fmt.Sprintf("/v3/processes/%s/stats", processGuid)
+	session := cf.Cf("curl", statsURL).Wait(Config.DefaultTimeoutDuration())
+	Expect(session).To(Exit(0))
+
+	var stats ProcessStats
+	err := json.Unmarshal(session.Out.Contents(), &stats)
+	Expect(err).ToNot(HaveOccurred())
+	return stats
+}
+
+func GetProcessInstance(processGuid string, index int) ProcessInstance {
+	for _, instance := range GetProcessStats(processGuid).Instances {
+		if instance.Index == index {
+			return instance
+		}
+	}
+	return ProcessInstance{}
+}
+
+func GetProcessInstanceState(processGuid string, index int) string {
+	return GetProcessInstance(processGuid, index).State
+}
+
+func WaitForAllProcessInstancesToBeRunning(processGuid string, instances int) {
+	Eventually(func() int {
+		running := 0
+		for _, instance := range GetProcessStats(processGuid).Instances {
+			if instance.State == "RUNNING" {
+				running++
+			}
+		}
+		return running
+	}, Config.CfPushTimeoutDuration()).Should(Equal(instances))
+}
+
+func RestartProcessInstance(processGuid string, index int) {
+	instancePath := fmt.Sprintf("/v3/processes/%s/instances/%d", processGuid, index)
+	session := cf.Cf("curl", instancePath, "-X", "DELETE").Wait(Config.DefaultTimeoutDuration())
+	Expect(session).To(Exit(0))
+	Expect(string(session.Out.Contents())).ToNot(ContainSubstring("errors"))
+}
+
+// RollingRestartProcess restarts each instance in turn, waiting for it to be
+// running again before moving on, so that the process never loses more than
+// one instance at a time.
+func RollingRestartProcess(processGuid string) {
+	for _, instance := range GetProcessStats(processGuid).Instances {
+		RestartProcessInstance(processGuid, instance.Index)
+
+		// Note that this depends on a 30s run loop waking up in Diego.
+		Eventually(func() string {
+			return GetProcessInstanceState(processGuid, instance.Index)
+		}, 45*time.Second).ShouldNot(Equal("RUNNING"))
+
+		Eventually(func() string {
+			return GetProcessInstanceState(processGuid, instance.Index)
+		}, Config.CfPushTimeoutDuration()).Should(Equal("RUNNING"))
+	}
+}
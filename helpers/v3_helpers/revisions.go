@@ -0,0 +1,96 @@
+package v3_helpers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+type RevisionList struct {
+	Revisions []Revision `json:"resources"`
+}
+
+type Revision struct {
+	Guid        string `json:"guid"`
+	Version     int    `json:"version"`
+	Description string `json:"description"`
+	Droplet     struct {
+		Guid string `json:"guid"`
+	} `json:"droplet"`
+	Processes map[string]struct {
+		Command string `json:"command"`
+	} `json:"processes"`
+}
+
+func EnableRevisions(appGuid string) {
+	featurePath := fmt.Sprintf("/v3/apps/%s/features/revisions", appGuid)
+	session := cf.Cf("curl", featurePath, "-X", "PATCH", "-d", `{"enabled":true}`).Wait(Config.DefaultTimeoutDuration())
+	Expect(session).To(Exit(0))
+	Expect(string(session.Out.Contents())).To(MatchRegexp(`"enabled":\s*true`))
+}
+
+func GetRevisions(appGuid string) []Revision {
+	revisionsPath := fmt.Sprintf("/v3/apps/%s/revisions?order_by=-created_at", appGuid)
+	session := cf.Cf("curl", revisionsPath).Wait(Config.DefaultTimeoutDuration())
+	Expect(session).To(Exit(0))
+
+	revisions := RevisionList{}
+	err := json.Unmarshal(session.Out.Contents(), &revisions)
+	Expect(err).ToNot(HaveOccurred())
+	return revisions.Revisions
+}
+
+func GetRevision(revisionGuid string) Revision {
+	revisionPath := fmt.Sprintf("/v3/revisions/%s", revisionGuid)
+	session := cf.Cf("curl", revisionPath).Wait(Config.DefaultTimeoutDuration())
+	Expect(session).To(Exit(0))
+
+	var revision Revision
+	err := json.Unmarshal(session.Out.Contents(), &revision)
+	Expect(err).ToNot(HaveOccurred())
+	return revision
+}
+
+func GetNewestRevision(appGuid string) Revision {
+	revisions := GetRevisions(appGuid)
+	Expect(revisions).NotTo(BeEmpty())
+
+	newest := revisions[0]
+	for _, revision := range revisions {
+		if revision.Version > newest.Version {
+			newest = revision
+		}
+	}
+	return newest
+}
+
+func GetRevisionDropletGuid(revisionGuid string) string {
+	return GetRevision(revisionGuid).Droplet.Guid
+}
+
+func GetRevisionCommand(revisionGuid, processType string) string {
+	return GetRevision(revisionGuid).Processes[processType].Command
+}
+
+func GetRevisionEnvironmentVariables(revisionGuid string) map[string]string {
+	envPath := fmt.Sprintf("/v3/revisions/%s/environment_variables", revisionGuid)
+	session := cf.Cf("curl", envPath).Wait(Config.DefaultTimeoutDuration())
+	Expect(session).To(Exit(0))
+
+	var env struct {
+		Var map[string]string `json:"var"`
+	}
+	err := json.Unmarshal(session.Out.Contents(), &env)
+	Expect(err).ToNot(HaveOccurred())
+	return env.Var
+}
+
+func RollbackToRevision(appGuid, revisionGuid string) string {
+	deploymentGuid := CreateDeploymentForRevision(appGuid, revisionGuid)
+	WaitForDeploymentToReachState(deploymentGuid, DEPLOYMENT_DEPLOYED)
+	return deploymentGuid
+}
@@ -0,0 +1,77 @@
+package v3_helpers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+type SidecarList struct {
+	Sidecars []Sidecar `json:"resources"`
+}
+
+type Sidecar struct {
+	Guid         string   `json:"guid"`
+	Name         string   `json:"name"`
+	Command      string   `json:"command"`
+	ProcessTypes []string `json:"process_types"`
+	MemoryInMb   int      `json:"memory_in_mb"`
+	Origin       string   `json:"origin"`
+}
+
+func CreateSidecar(appGuid, name, command string, processTypes ...string) string {
+	sidecarsURL := fmt.Sprintf("/v3/apps/%s/sidecars", appGuid)
+	sidecarBody, err := json.Marshal(struct {
+		Name         string   `json:"name"`
+		Command      string   `json:"command"`
+		ProcessTypes []string `json:"process_types"`
+	}{name, command, processTypes})
+	Expect(err).ToNot(HaveOccurred())
+	session := cf.Cf("curl", sidecarsURL, "-X", "POST", "-d", string(sidecarBody)).Wait(Config.DefaultTimeoutDuration())
+	Expect(session).To(Exit(0))
+
+	var sidecar Sidecar
+	json.Unmarshal(session.Out.Contents(), &sidecar)
+	Expect(sidecar.Guid).NotTo(BeEmpty(), string(session.Out.Contents()))
+	return sidecar.Guid
+}
+
+func GetSidecars(appGuid string) []Sidecar {
+	sidecarsURL := fmt.Sprintf("/v3/apps/%s/sidecars", appGuid)
+	return getSidecars(sidecarsURL)
+}
+
+func GetSidecarsForProcess(processGuid string) []Sidecar {
+	sidecarsURL := fmt.Sprintf("/v3/processes/%s/sidecars", processGuid)
+	return getSidecars(sidecarsURL)
+}
+
+func GetSidecarByName(sidecars []Sidecar, name string) Sidecar {
+	for _, sidecar := range sidecars {
+		if sidecar.Name == name {
+			return sidecar
+		}
+	}
+	return Sidecar{}
+}
+
+func DeleteSidecar(sidecarGuid string) {
+	sidecarURL := fmt.Sprintf("/v3/sidecars/%s", sidecarGuid)
+	Expect(cf.Cf("curl", sidecarURL, "-X", "DELETE").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+}
+
+//private
+
+func getSidecars(sidecarsURL string) []Sidecar {
+	session := cf.Cf("curl", sidecarsURL).Wait(Config.DefaultTimeoutDuration())
+	Expect(session).To(Exit(0))
+
+	sidecars := SidecarList{}
+	err := json.Unmarshal(session.Out.Contents(), &sidecars)
+	Expect(err).ToNot(HaveOccurred())
+	return sidecars.Sidecars
+}
@@ -23,6 +23,16 @@ const (
 	V3_JAVA_MEMORY_LIMIT    = "1024"
 )
 
+func ApplyManifest(appGuid, manifest string) {
+	applyManifestPath := fmt.Sprintf("/v3/apps/%s/actions/apply_manifest", appGuid)
+	session := cf.Cf("curl", applyManifestPath, "-X", "POST", "-H", "Content-Type: application/x-yaml", "-d", manifest, "-i")
+	Expect(session.Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+	response := session.Out.Contents()
+	Expect(string(response)).To(ContainSubstring("202 Accepted"))
+
+	PollJob(GetJobPath(response))
+}
+
 func AssignDropletToApp(appGuid, dropletGuid string) {
 	appUpdatePath := fmt.Sprintf("/v3/apps/%s/relationships/current_droplet", appGuid)
 	appUpdateBody := fmt.Sprintf(`{"data": {"guid":"%s"}}`, dropletGuid)
@@ -312,8 +322,19 @@ func ScaleProcess(appGuid, processType, memoryInMb string) {
 	Expect(strings.Contains(string(result), "errors")).To(BeFalse())
 }
 
+func ScaleProcessInstances(appGuid, processType string, instances int) {
+	scalePath := fmt.Sprintf("/v3/apps/%s/processes/%s/actions/scale", appGuid, processType)
+	scaleBody := fmt.Sprintf(`{"instances":%d}`, instances)
+	session := cf.Cf("curl", scalePath, "-X", "POST", "-d", scaleBody).Wait(Config.DefaultTimeoutDuration())
+	Expect(session).To(Exit(0))
+	result := session.Out.Contents()
+	Expect(strings.Contains(string(result), "errors")).To(BeFalse())
+}
+
+// SendRequestWithSpoofedHeader asks the router for domain for catnip's
+// /request, addressed to host.
 func SendRequestWithSpoofedHeader(host, domain string) *http.Response {
-	req, _ := http.NewRequest("GET", fmt.Sprintf("http://wildcard-path.%s", domain), nil)
+	req, _ := http.NewRequest("GET", fmt.Sprintf("http://wildcard-path.%s/request", domain), nil)
 	req.Host = host
 
 	resp, err := http.DefaultClient.Do(req)
@@ -321,6 +342,18 @@ func SendRequestWithSpoofedHeader(host, domain string) *http.Response {
 	return resp
 }
 
+// ExpectCatnipToAnswer checks that a request sent with
+// SendRequestWithSpoofedHeader was routed to a catnip app under host.
+func ExpectCatnipToAnswer(resp *http.Response, host string) {
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	var request struct {
+		Host string `json:"host"`
+	}
+	Expect(json.NewDecoder(resp.Body).Decode(&request)).To(Succeed())
+	Expect(request.Host).To(Equal(host))
+}
+
 func SetDefaultIsolationSegment(orgGuid, isoSegGuid string) {
 	Eventually(cf.Cf("curl",
 		fmt.Sprintf("/v3/organizations/%s/relationships/default_isolation_segment", orgGuid),
@@ -385,6 +418,14 @@ func UnsetDefaultIsolationSegment(orgGuid string) {
 		Config.DefaultTimeoutDuration()).Should(Exit(0))
 }
 
+func UpdateEnvironmentVariables(appGuid, environmentVariables string) {
+	envPath := fmt.Sprintf("/v3/apps/%s/environment_variables", appGuid)
+	envBody := fmt.Sprintf(`{"var":%s}`, environmentVariables)
+	session := cf.Cf("curl", envPath, "-X", "PATCH", "-d", envBody).Wait(Config.DefaultTimeoutDuration())
+	Expect(session).To(Exit(0))
+	Expect(strings.Contains(string(session.Out.Contents()), "errors")).To(BeFalse())
+}
+
 func UploadPackage(uploadUrl, packageZipPath, token string) {
 	bits := fmt.Sprintf(`bits=@%s`, packageZipPath)
 	curl := helpers.Curl(Config, "-v", "-s", uploadUrl, "-F", bits, "-H", fmt.Sprintf("Authorization: %s", token)).Wait(Config.DefaultTimeoutDuration())
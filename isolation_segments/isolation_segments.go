@@ -2,7 +2,6 @@ package isolation_segments
 
 import (
 	"fmt"
-	"io/ioutil"
 
 	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
 	. "github.com/onsi/ginkgo"
@@ -20,7 +19,7 @@ import (
 
 const (
 	SHARED_ISOLATION_SEGMENT_GUID = "933b4c58-120b-499a-b85d-4b6fc9e2903b"
-	binaryHi                      = "Hello from a binary"
+	catnipRoot                    = "Catnip?"
 )
 
 var _ = IsolationSegmentsDescribe("IsolationSegments", func() {
@@ -91,14 +90,14 @@ var _ = IsolationSegmentsDescribe("IsolationSegments", func() {
 				appName := random_name.CATSRandomName("APP")
 				Eventually(cf.Cf(
 					"push", appName,
-					"-p", assets.NewAssets().Binary,
+					"-p", assets.NewAssets().Catnip,
 					"-m", DEFAULT_MEMORY_LIMIT,
 					"-b", "binary_buildpack",
 					"-d", appsDomain,
-					"-c", "./app"),
+					"-c", "./catnip"),
 					Config.CfPushTimeoutDuration()).Should(Exit(0))
 
-				Eventually(helpers.CurlingAppRoot(Config, appName), Config.DefaultTimeoutDuration()).Should(ContainSubstring(binaryHi))
+				Eventually(helpers.CurlingAppRoot(Config, appName), Config.DefaultTimeoutDuration()).Should(ContainSubstring(catnipRoot))
 			})
 		})
 	})
@@ -119,20 +118,18 @@ var _ = IsolationSegmentsDescribe("IsolationSegments", func() {
 				appName := random_name.CATSRandomName("APP")
 				Eventually(cf.Cf(
 					"push", appName,
-					"-p", assets.NewAssets().Binary,
+					"-p", assets.NewAssets().Catnip,
 					"-m", DEFAULT_MEMORY_LIMIT,
 					"-b", "binary_buildpack",
 					"-d", isoSegDomain,
-					"-c", "./app"),
+					"-c", "./catnip"),
 					Config.CfPushTimeoutDuration()).Should(Exit(0))
 
-				resp := v3_helpers.SendRequestWithSpoofedHeader(fmt.Sprintf("%s.%s", appName, isoSegDomain), isoSegDomain)
+				host := fmt.Sprintf("%s.%s", appName, isoSegDomain)
+				resp := v3_helpers.SendRequestWithSpoofedHeader(host, isoSegDomain)
 				defer resp.Body.Close()
 
-				Expect(resp.StatusCode).To(Equal(200))
-				htmlData, err := ioutil.ReadAll(resp.Body)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(string(htmlData)).To(ContainSubstring(binaryHi))
+				v3_helpers.ExpectCatnipToAnswer(resp, host)
 			})
 		})
 	})
@@ -166,11 +163,11 @@ var _ = IsolationSegmentsDescribe("IsolationSegments", func() {
 				appName := random_name.CATSRandomName("APP")
 				Eventually(cf.Cf(
 					"push", appName,
-					"-p", assets.NewAssets().Binary,
+					"-p", assets.NewAssets().Catnip,
 					"-m", DEFAULT_MEMORY_LIMIT,
 					"-b", "binary_buildpack",
 					"-d", isoSegDomain,
-					"-c", "./app"),
+					"-c", "./catnip"),
 					Config.CfPushTimeoutDuration()).Should(Exit(1))
 			})
 		})
@@ -216,20 +213,18 @@ var _ = IsolationSegmentsDescribe("IsolationSegments", func() {
 				appName := random_name.CATSRandomName("APP")
 				Eventually(cf.Cf(
 					"push", appName,
-					"-p", assets.NewAssets().Binary,
+					"-p", assets.NewAssets().Catnip,
 					"-m", DEFAULT_MEMORY_LIMIT,
 					"-b", "binary_buildpack",
 					"-d", isoSegDomain,
-					"-c", "./app"),
+					"-c", "./catnip"),
 					Config.CfPushTimeoutDuration()).Should(Exit(0))
 
-				resp := v3_helpers.SendRequestWithSpoofedHeader(fmt.Sprintf("%s.%s", appName, isoSegDomain), isoSegDomain)
+				host := fmt.Sprintf("%s.%s", appName, isoSegDomain)
+				resp := v3_helpers.SendRequestWithSpoofedHeader(host, isoSegDomain)
 				defer resp.Body.Close()
 
-				Expect(resp.StatusCode).To(Equal(200))
-				htmlData, err := ioutil.ReadAll(resp.Body)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(string(htmlData)).To(ContainSubstring(binaryHi))
+				v3_helpers.ExpectCatnipToAnswer(resp, host)
 			})
 		})
 	})
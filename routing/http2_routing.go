@@ -0,0 +1,171 @@
+package routing
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	cf_helpers "github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/assets/catnip/grpc"
+	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = RoutingDescribe("HTTP/2 routing", func() {
+	var appName string
+
+	BeforeEach(func() {
+		appName = random_name.CATSRandomName("APP")
+		Expect(cf.Cf("push",
+			appName,
+			"--no-start",
+			"-b", Config.GetBinaryBuildpackName(),
+			"-p", assets.NewAssets().Catnip,
+			"-c", "./catnip",
+			"-m", DEFAULT_MEMORY_LIMIT,
+			"-d", Config.GetAppsDomain(),
+		).Wait(Config.CfPushTimeoutDuration())).To(gexec.Exit(0))
+		Expect(cf.Cf("set-env", appName, "CATNIP_H2C", "true").Wait(Config.DefaultTimeoutDuration())).To(gexec.Exit(0))
+
+		setDestinationProtocol(GuidForAppName(appName), "http2")
+
+		Expect(cf.Cf("start", appName).Wait(Config.CfPushTimeoutDuration())).To(gexec.Exit(0))
+	})
+
+	AfterEach(func() {
+		helpers.AppReport(appName, Config.DefaultTimeoutDuration())
+		helpers.DeleteApp(appName, Config.DefaultTimeoutDuration())
+	})
+
+	It("forwards requests to the app over HTTP/2", func() {
+		var request struct {
+			Protocol string `json:"protocol"`
+		}
+		response := cf_helpers.CurlApp(Config, appName, "/request")
+		Expect(json.Unmarshal([]byte(response), &request)).To(Succeed(), response)
+		Expect(request.Protocol).To(Equal("HTTP/2.0"))
+	})
+
+	It("still serves HTTP/1.1 clients", func() {
+		curl := cf_helpers.Curl(Config, "-i", "--http1.1", cf_helpers.AppUri(appName, "/", Config)).Wait(Config.DefaultTimeoutDuration())
+		Expect(curl).To(gexec.Exit(0))
+		Expect(string(curl.Out.Contents())).To(MatchRegexp(`^HTTP/1.1 200`))
+		Expect(string(curl.Out.Contents())).To(HaveSuffix("Catnip?"))
+	})
+
+	Context("gRPC", func() {
+		var client *http.Client
+
+		BeforeEach(func() {
+			if Config.Protocol() != "https://" {
+				Skip("Skipping this test because gRPC clients need HTTP/2 over TLS to reach the router and Config.UseHttp is set.")
+			}
+
+			client = &http.Client{
+				Transport: &http.Transport{
+					TLSClientConfig:   &tls.Config{InsecureSkipVerify: Config.GetSkipSSLValidation()},
+					ForceAttemptHTTP2: true,
+				},
+				Timeout: Config.DefaultTimeoutDuration(),
+			}
+		})
+
+		It("completes unary calls", func() {
+			status, responses := grpcCall(client, appName, "/grpc.health.v1.Health/Check", nil)
+			Expect(status).To(Equal("0"))
+			Expect(responses).To(HaveLen(1))
+			Expect(responses[0].Int(1)).To(Equal(int64(grpc.Serving)), "the health check should report SERVING")
+
+			status, responses = grpcCall(client, appName, "/catnip.Echo/Echo", grpc.AppendString(nil, 1, "hello"))
+			Expect(status).To(Equal("0"))
+			Expect(responses).To(HaveLen(1))
+			Expect(responses[0].String(1)).To(Equal("hello"))
+			Expect(responses[0].String(3)).To(Equal("HTTP/2.0"))
+		})
+
+		It("streams responses from server-streaming calls", func() {
+			request := grpc.AppendString(nil, 1, "hello")
+			request = grpc.AppendVarint(request, 2, 5)
+			request = grpc.AppendVarint(request, 3, 200)
+
+			status, responses := grpcCall(client, appName, "/catnip.Echo/EchoStream", request)
+			Expect(status).To(Equal("0"))
+			Expect(responses).To(HaveLen(5))
+			for i, response := range responses {
+				Expect(response.String(1)).To(Equal("hello"))
+				Expect(response.Int(2)).To(Equal(int64(i + 1)))
+			}
+		})
+	})
+})
+
+// setDestinationProtocol switches every route mapped to the app to the given
+// backend protocol.
+func setDestinationProtocol(appGuid, protocol string) {
+	session := cf.Cf("curl", fmt.Sprintf("/v3/apps/%s/routes", appGuid)).Wait(Config.DefaultTimeoutDuration())
+	Expect(session).To(gexec.Exit(0))
+
+	var routes struct {
+		Resources []struct {
+			Guid string `json:"guid"`
+		} `json:"resources"`
+	}
+	Expect(json.Unmarshal(session.Out.Contents(), &routes)).To(Succeed(), string(session.Out.Contents()))
+	Expect(routes.Resources).NotTo(BeEmpty())
+
+	body := fmt.Sprintf(`{"destinations":[{"app":{"guid":"%s"},"protocol":"%s"}]}`, appGuid, protocol)
+	for _, route := range routes.Resources {
+		session := cf.Cf("curl", fmt.Sprintf("/v3/routes/%s/destinations", route.Guid), "-X", "PATCH", "-d", body).Wait(Config.DefaultTimeoutDuration())
+		Expect(session).To(gexec.Exit(0))
+		Expect(string(session.Out.Contents())).NotTo(ContainSubstring("errors"))
+	}
+}
+
+// grpcCall makes a gRPC call through the router, returning the grpc-status
+// trailer and the fields of each response message.
+func grpcCall(client *http.Client, appName, method string, message []byte) (string, []grpc.Fields) {
+	// Retry until the route is registered with its http2 destination.
+	var res *http.Response
+	Eventually(func() (int, error) {
+		req, err := http.NewRequest(http.MethodPost, cf_helpers.AppUri(appName, method, Config), bytes.NewReader(grpc.Frame(message)))
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("Content-Type", "application/grpc")
+		req.Header.Set("TE", "trailers")
+
+		res, err = client.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+		}
+		return res.StatusCode, nil
+	}, Config.DefaultTimeoutDuration(), "2s").Should(Equal(http.StatusOK))
+	defer res.Body.Close()
+	Expect(res.ProtoMajor).To(Equal(2), "the router should have negotiated HTTP/2")
+
+	responses := []grpc.Fields{}
+	for {
+		message, err := grpc.ReadMessage(res.Body)
+		if err == io.EOF {
+			break
+		}
+		Expect(err).NotTo(HaveOccurred())
+
+		fields, err := grpc.Decode(message)
+		Expect(err).NotTo(HaveOccurred())
+		responses = append(responses, fields)
+	}
+	return res.Trailer.Get("Grpc-Status"), responses
+}
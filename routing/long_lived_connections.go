@@ -0,0 +1,175 @@
+package routing
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	cf_helpers "github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
+	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/v3_helpers"
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = RoutingDescribe("Long-lived connections", func() {
+	var (
+		appName     string
+		appGuid     string
+		processGuid string
+	)
+
+	BeforeEach(func() {
+		appName = random_name.CATSRandomName("APP")
+		Expect(cf.Cf("push",
+			appName,
+			"-b", Config.GetBinaryBuildpackName(),
+			"-p", assets.NewAssets().Catnip,
+			"-c", "./catnip",
+			"-i", "2",
+			"-m", DEFAULT_MEMORY_LIMIT,
+			"-d", Config.GetAppsDomain(),
+		).Wait(Config.CfPushTimeoutDuration())).To(gexec.Exit(0))
+
+		appGuid = GuidForAppName(appName)
+		processGuid = v3_helpers.GetProcessByType(v3_helpers.GetProcesses(appGuid, appName), "web").Guid
+		v3_helpers.WaitForAllProcessInstancesToBeRunning(processGuid, 2)
+	})
+
+	AfterEach(func() {
+		helpers.AppReport(appName, Config.DefaultTimeoutDuration())
+		helpers.DeleteApp(appName, Config.DefaultTimeoutDuration())
+	})
+
+	Describe("WebSockets", func() {
+		var conn *websocket.Conn
+
+		BeforeEach(func() {
+			conn = dialWebSocket(appName, fmt.Sprintf("%s:%d", appGuid, 0))
+		})
+
+		AfterEach(func() {
+			conn.Close()
+		})
+
+		It("round-trips messages through the router", func() {
+			for i := 0; i < 10; i++ {
+				expectEcho(conn, fmt.Sprintf("message %d", i))
+			}
+		})
+
+		It("keeps the connection open while other instances restart", func() {
+			expectEcho(conn, "before restart")
+
+			v3_helpers.RestartProcessInstance(processGuid, 1)
+			Eventually(func() string {
+				return v3_helpers.GetProcessInstanceState(processGuid, 1)
+			}, 45*time.Second).ShouldNot(Equal("RUNNING"))
+			v3_helpers.WaitForAllProcessInstancesToBeRunning(processGuid, 2)
+
+			expectEcho(conn, "after restart")
+		})
+
+		It("closes the connection when its instance is stopped", func() {
+			expectEcho(conn, "before stop")
+			readErrors := awaitClose(conn)
+
+			v3_helpers.RestartProcessInstance(processGuid, 0)
+
+			expectClosed(readErrors)
+		})
+
+		// Restarting instance 1 above only shows that the router leaves
+		// connections to other instances alone; restarting the app checks
+		// that every instance's connections are torn down together.
+		It("closes the connections to every instance when the app is restarted", func() {
+			otherConn := dialWebSocket(appName, fmt.Sprintf("%s:%d", appGuid, 1))
+			defer otherConn.Close()
+			expectEcho(conn, "before restart")
+			expectEcho(otherConn, "before restart")
+			readErrors := awaitClose(conn)
+			otherReadErrors := awaitClose(otherConn)
+
+			v3_helpers.StopApp(appGuid)
+			v3_helpers.StartApp(appGuid)
+
+			expectClosed(readErrors)
+			expectClosed(otherReadErrors)
+			v3_helpers.WaitForAllProcessInstancesToBeRunning(processGuid, 2)
+		})
+	})
+
+	Describe("server-sent events", func() {
+		It("streams every event through the router", func() {
+			curl := cf_helpers.Curl(Config, "-N", cf_helpers.AppUri(appName, "/events/5/1000", Config))
+			Eventually(curl, Config.DefaultTimeoutDuration()).Should(gexec.Exit(0))
+
+			output := string(curl.Out.Contents())
+			for i := 1; i <= 5; i++ {
+				Expect(output).To(ContainSubstring(fmt.Sprintf("id: %d\ndata: Event %d of 5\n\n", i, i)))
+			}
+		})
+	})
+})
+
+// dialWebSocket connects to catnip's echo endpoint on the given instance,
+// retrying until the route is registered.
+func dialWebSocket(appName, instance string) *websocket.Conn {
+	dialer := websocket.Dialer{
+		TLSClientConfig:  &tls.Config{InsecureSkipVerify: Config.GetSkipSSLValidation()},
+		HandshakeTimeout: Config.DefaultTimeoutDuration(),
+	}
+	uri := strings.Replace(cf_helpers.AppUri(appName, "/websocket", Config), "http", "ws", 1)
+	header := http.Header{"X-Cf-App-Instance": []string{instance}}
+
+	var conn *websocket.Conn
+	Eventually(func() error {
+		var err error
+		conn, _, err = dialer.Dial(uri, header)
+		return err
+	}, Config.DefaultTimeoutDuration(), "2s").Should(Succeed())
+	return conn
+}
+
+func expectEcho(conn *websocket.Conn, message string) {
+	conn.SetWriteDeadline(time.Now().Add(Config.DefaultTimeoutDuration()))
+	Expect(conn.WriteMessage(websocket.TextMessage, []byte(message))).To(Succeed())
+
+	conn.SetReadDeadline(time.Now().Add(Config.DefaultTimeoutDuration()))
+	_, echoed, err := conn.ReadMessage()
+	Expect(err).NotTo(HaveOccurred())
+	Expect(string(echoed)).To(Equal(message))
+}
+
+// awaitClose reads from conn in the background and delivers the error that
+// ends the read.
+func awaitClose(conn *websocket.Conn) <-chan error {
+	readErrors := make(chan error, 1)
+	go func() {
+		conn.SetReadDeadline(time.Now().Add(Config.CfPushTimeoutDuration()))
+		_, _, err := conn.ReadMessage()
+		readErrors <- err
+	}()
+	return readErrors
+}
+
+// expectClosed expects the read to end because the connection was closed,
+// either with a close frame or by the router dropping it, rather than by
+// running into the read deadline.
+func expectClosed(readErrors <-chan error) {
+	var err error
+	Eventually(readErrors, Config.CfPushTimeoutDuration()).Should(Receive(&err))
+	Expect(websocket.IsCloseError(err,
+		websocket.CloseNormalClosure,
+		websocket.CloseGoingAway,
+		websocket.CloseAbnormalClosure,
+	)).To(BeTrue(), "expected the connection to be closed, got: %v", err)
+}
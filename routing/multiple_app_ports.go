@@ -1,20 +1,38 @@
 package routing
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
+	"net"
+	"strconv"
+	"time"
 
 	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
 
 	"path/filepath"
 
 	. "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
 	"github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
 	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
 	"github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	. "github.com/onsi/gomega/gexec"
 )
 
+// portIdentity is what each port of the multi-port app reports at /id and,
+// along with whether it is healthy, at /health.
+type portIdentity struct {
+	Port          int    `json:"port"`
+	Mode          string `json:"mode"`
+	InstanceGuid  string `json:"instance_guid"`
+	InstanceIndex int    `json:"instance_index"`
+	Healthy       bool   `json:"healthy"`
+}
+
 var _ = RoutingDescribe("Multiple App Ports", func() {
 	var (
 		app               string
@@ -22,9 +40,25 @@ var _ = RoutingDescribe("Multiple App Ports", func() {
 		multiPortAppAsset = assets.NewAssets().MultiPortApp
 	)
 
+	identify := func(route, path string) portIdentity {
+		var id portIdentity
+		body := helpers.CurlApp(Config, route, path)
+		Expect(json.Unmarshal([]byte(body), &id)).To(Succeed(), body)
+		return id
+	}
+
+	// tryIdentify is identify for polling while an instance may be
+	// restarting: a router error page gives the zero value instead of
+	// failing the spec.
+	tryIdentify := func(route, path string) portIdentity {
+		var id portIdentity
+		json.Unmarshal([]byte(helpers.CurlApp(Config, route, path)), &id)
+		return id
+	}
+
 	BeforeEach(func() {
 		app = random_name.CATSRandomName("APP")
-		cmd := fmt.Sprintf("go-online --ports=7777,8888,8080")
+		cmd := fmt.Sprintf("go-online --ports=7777,8888,9999:tcp,8080")
 
 		PushAppNoStart(app, multiPortAppAsset, Config.GetGoBuildpackName(), Config.GetAppsDomain(), Config.CfPushTimeoutDuration(), DEFAULT_MEMORY_LIMIT, "-c", cmd, "-f", filepath.Join(multiPortAppAsset, "manifest.yml"))
 		EnableDiego(app, Config.DefaultTimeoutDuration())
@@ -43,6 +77,14 @@ var _ = RoutingDescribe("Multiple App Ports", func() {
 					return helpers.CurlApp(Config, app, "/port")
 				}, Config.DefaultTimeoutDuration(), "5s").Should(ContainSubstring("8080"))
 			})
+
+			It("identifies the instance and port that answered", func() {
+				id := identify(app, "/id")
+				Expect(id.Port).To(Equal(8080))
+				Expect(id.Mode).To(Equal("http"))
+				Expect(id.InstanceIndex).To(Equal(0))
+				Expect(id.InstanceGuid).NotTo(BeEmpty())
+			})
 		})
 	})
 
@@ -67,5 +109,141 @@ var _ = RoutingDescribe("Multiple App Ports", func() {
 				return helpers.CurlApp(Config, app, "/port")
 			}, Config.SleepTimeoutDuration(), "5s").Should(ContainSubstring("8080"))
 		})
+
+		Context("with an http health check", func() {
+			BeforeEach(func() {
+				// Diego runs the http check against the first of the app's
+				// ports, 7777, and only checks that the others accept
+				// connections.
+				Expect(cf.Cf("set-health-check", app, "http", "--endpoint", "/health").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+				RestartApp(app, APP_START_TIMEOUT)
+			})
+
+			It("restarts the instance once the checked port turns unhealthy", func() {
+				var before portIdentity
+				Eventually(func() int {
+					before = tryIdentify(secondRoute, "/health")
+					return before.Port
+				}, Config.DefaultTimeoutDuration(), "5s").Should(Equal(7777))
+				Expect(before.Healthy).To(BeTrue())
+
+				helpers.CurlApp(Config, secondRoute, "/health/unhealthy", "-X", "PUT")
+
+				Eventually(func() *Session {
+					return cf.Cf("events", app).Wait(Config.DefaultTimeoutDuration())
+				}, Config.CfPushTimeoutDuration(), "5s").Should(Say("app.crash"))
+
+				Eventually(func() portIdentity {
+					return tryIdentify(secondRoute, "/health")
+				}, Config.CfPushTimeoutDuration(), "5s").Should(And(
+					WithTransform(func(id portIdentity) string { return id.InstanceGuid }, Not(Or(BeEmpty(), Equal(before.InstanceGuid)))),
+					WithTransform(func(id portIdentity) bool { return id.Healthy }, BeTrue()),
+				))
+			})
+
+			It("keeps the instance running while an unchecked port is unhealthy", func() {
+				Eventually(func() int {
+					return tryIdentify(secondRoute, "/health").Port
+				}, Config.DefaultTimeoutDuration(), "5s").Should(Equal(7777))
+				before := identify(app, "/id")
+				Expect(before.Port).To(Equal(8080))
+
+				helpers.CurlApp(Config, app, "/health/unhealthy", "-X", "PUT")
+				Expect(identify(app, "/health").Healthy).To(BeFalse())
+				Expect(identify(secondRoute, "/health").Healthy).To(BeTrue())
+
+				Consistently(func() string {
+					return tryIdentify(app, "/id").InstanceGuid
+				}, Config.SleepTimeoutDuration(), "5s").Should(Equal(before.InstanceGuid))
+			})
+		})
+
+		It("moves a route to another port without restarting the app", func() {
+			var before portIdentity
+			Eventually(func() int {
+				before = identify(secondRoute, "/id")
+				return before.Port
+			}, Config.DefaultTimeoutDuration(), "5s").Should(Equal(7777))
+
+			routeGuid := GetRouteGuid(secondRoute, "", Config.DefaultTimeoutDuration())
+			deleteRouteMapping(routeGuid, 7777)
+			CreateRouteMapping(app, secondRoute, 0, 8888, Config.DefaultTimeoutDuration())
+
+			Eventually(func() int {
+				return identify(secondRoute, "/id").Port
+			}, Config.DefaultTimeoutDuration(), "5s").Should(Equal(8888))
+			Expect(identify(secondRoute, "/id").InstanceGuid).To(Equal(before.InstanceGuid))
+		})
+	})
+
+	Context("when a TCP route is mapped to a non-default port", func() {
+		var tcpPort uint16
+
+		BeforeEach(func() {
+			if !Config.GetIncludeTCPRouting() {
+				Skip("Skipping this test because Config.IncludeTCPRouting is set to 'false'.")
+			}
+
+			UpdatePorts(app, []uint16{9999, 8080}, Config.DefaultTimeoutDuration())
+			spacename := TestSetup.RegularUserContext().Space
+			tcpPort = CreateTcpRouteWithRandomPort(spacename, Config.GetTCPDomain(), Config.DefaultTimeoutDuration())
+			CreateRouteMapping(app, "", tcpPort, 9999, Config.DefaultTimeoutDuration())
+		})
+
+		AfterEach(func() {
+			if !Config.GetIncludeTCPRouting() {
+				return
+			}
+			DeleteTcpRoute(Config.GetTCPDomain(), fmt.Sprintf("%d", tcpPort), Config.DefaultTimeoutDuration())
+		})
+
+		It("echoes what it is sent over the TCP route", func() {
+			address := net.JoinHostPort(Config.GetTCPDomain(), strconv.Itoa(int(tcpPort)))
+
+			Eventually(func() string {
+				conn, err := net.DialTimeout("tcp", address, 5*time.Second)
+				if err != nil {
+					return err.Error()
+				}
+				defer conn.Close()
+				conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+				reader := bufio.NewReader(conn)
+				banner, _ := reader.ReadString('\n')
+				fmt.Fprint(conn, "hello\n")
+				echo, _ := reader.ReadString('\n')
+				return banner + echo
+			}, Config.DefaultTimeoutDuration(), "5s").Should(And(
+				ContainSubstring("port=9999 mode=tcp"),
+				HaveSuffix("\nhello\n"),
+			))
+		})
 	})
 })
+
+// deleteRouteMapping unmaps the route from the app port it was mapped to,
+// leaving the app running.
+func deleteRouteMapping(routeGuid string, appPort int) {
+	var mappings struct {
+		Resources []struct {
+			Metadata struct {
+				Guid string `json:"guid"`
+			} `json:"metadata"`
+			Entity struct {
+				AppPort int `json:"app_port"`
+			} `json:"entity"`
+		} `json:"resources"`
+	}
+
+	session := cf.Cf("curl", fmt.Sprintf("/v2/routes/%s/route_mappings", routeGuid)).Wait(Config.DefaultTimeoutDuration())
+	Expect(session).To(Exit(0))
+	Expect(json.Unmarshal(session.Out.Contents(), &mappings)).To(Succeed())
+
+	for _, mapping := range mappings.Resources {
+		if mapping.Entity.AppPort == appPort {
+			Expect(cf.Cf("curl", "/v2/route_mappings/"+mapping.Metadata.Guid, "-X", "DELETE").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+			return
+		}
+	}
+	Fail(fmt.Sprintf("route %s is not mapped to port %d", routeGuid, appPort))
+}
@@ -0,0 +1,133 @@
+package routing
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	cf_helpers "github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
+	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = RoutingDescribe("Request and response transfer", func() {
+	var (
+		appName string
+		appGuid string
+	)
+
+	BeforeEach(func() {
+		appName = random_name.CATSRandomName("APP")
+		Expect(cf.Cf("push",
+			appName,
+			"-b", Config.GetBinaryBuildpackName(),
+			"-p", assets.NewAssets().Catnip,
+			"-c", "./catnip",
+			"-m", DEFAULT_MEMORY_LIMIT,
+			"-d", Config.GetAppsDomain(),
+		).Wait(Config.CfPushTimeoutDuration())).To(gexec.Exit(0))
+
+		appGuid = GuidForAppName(appName)
+	})
+
+	AfterEach(func() {
+		helpers.AppReport(appName, Config.DefaultTimeoutDuration())
+		helpers.DeleteApp(appName, Config.DefaultTimeoutDuration())
+	})
+
+	It("delivers large uploads intact", func() {
+		const uploadSize = 64 * 1024 * 1024
+
+		file, err := ioutil.TempFile("", "cats-upload")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.Remove(file.Name())
+
+		hash := sha256.New()
+		_, err = io.CopyN(io.MultiWriter(file, hash), rand.Reader, uploadSize)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(file.Close()).To(Succeed())
+
+		curl := cf_helpers.Curl(Config,
+			"-X", "POST",
+			"-H", "Content-Type: application/octet-stream",
+			"--data-binary", "@"+file.Name(),
+			cf_helpers.AppUri(appName, "/upload", Config),
+		).Wait(Config.LongCurlTimeoutDuration())
+		Expect(curl).To(gexec.Exit(0))
+
+		var upload struct {
+			Bytes  int64  `json:"bytes"`
+			SHA256 string `json:"sha256"`
+		}
+		Expect(json.Unmarshal(curl.Out.Contents(), &upload)).To(Succeed(), string(curl.Out.Contents()))
+		Expect(upload.Bytes).To(Equal(int64(uploadSize)))
+		Expect(upload.SHA256).To(Equal(hex.EncodeToString(hash.Sum(nil))))
+	})
+
+	It("streams chunked responses of unknown length", func() {
+		curl := cf_helpers.Curl(Config, "-i", "-N", cf_helpers.AppUri(appName, "/chunked/64/16384?interval_ms=50", Config)).Wait(Config.LongCurlTimeoutDuration())
+		Expect(curl).To(gexec.Exit(0))
+
+		headers, body := splitResponse(curl.Out.Contents())
+		Expect(headers).To(MatchRegexp(`(?i)transfer-encoding:\s*chunked`))
+		Expect(headers).NotTo(MatchRegexp(`(?i)content-length:`))
+		Expect(body).To(HaveLen(64 * 16384))
+	})
+
+	It("delivers responses that trickle in slowly", func() {
+		curl := cf_helpers.Curl(Config, cf_helpers.AppUri(appName, "/trickle/4096/1024", Config)).Wait(Config.LongCurlTimeoutDuration())
+		Expect(curl).To(gexec.Exit(0))
+		Expect(curl.Out.Contents()).To(HaveLen(4096))
+	})
+
+	It("returns a 502 with X-Cf-Routererror when the app dies before responding", func() {
+		instance := fmt.Sprintf("%s:%d", appGuid, 0)
+		slowRequest := cf_helpers.Curl(Config, "-i", "-H", "X-Cf-App-Instance: "+instance, cf_helpers.AppUri(appName, "/slow/60000", Config))
+		// Give the slow request time to reach the app before killing it.
+		time.Sleep(2 * time.Second)
+
+		cf_helpers.Curl(Config, "-H", "X-Cf-App-Instance: "+instance, cf_helpers.AppUri(appName, "/sigterm/KILL", Config)).Wait(Config.DefaultTimeoutDuration())
+
+		Eventually(slowRequest, Config.DefaultTimeoutDuration()).Should(gexec.Exit(0))
+		headers, _ := splitResponse(slowRequest.Out.Contents())
+		Expect(headers).To(MatchRegexp(`^HTTP/[\d.]+ 502`))
+		Expect(headers).To(MatchRegexp(`(?i)x-cf-routererror:\s*endpoint_failure`))
+	})
+
+	It("returns a 504 with X-Cf-Routererror when the app is slower than the router request timeout", func() {
+		routerTimeout := Config.RouterRequestTimeoutDuration()
+		if routerTimeout == 0 {
+			Skip("Skipping this test because Config.RouterRequestTimeout is not set.")
+		}
+
+		delay := routerTimeout + 10*time.Second
+		curl := cf_helpers.Curl(Config, "-i", cf_helpers.AppUri(appName, fmt.Sprintf("/slow/%d", delay/time.Millisecond), Config)).Wait(delay + Config.DefaultTimeoutDuration())
+		Expect(curl).To(gexec.Exit(0))
+
+		headers, _ := splitResponse(curl.Out.Contents())
+		Expect(headers).To(MatchRegexp(`^HTTP/[\d.]+ 504`))
+		Expect(headers).To(MatchRegexp(`(?i)x-cf-routererror:\s*endpoint_failure`))
+	})
+})
+
+// splitResponse separates the headers that curl -i prints from the body.
+func splitResponse(output []byte) (string, []byte) {
+	parts := bytes.SplitN(output, []byte("\r\n\r\n"), 2)
+	if len(parts) < 2 {
+		return string(output), nil
+	}
+	return string(parts[0]), parts[1]
+}
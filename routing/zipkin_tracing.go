@@ -1,90 +1,108 @@
 package routing
 
 import (
+	"encoding/json"
 	"fmt"
 	"regexp"
 
-	"code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
 	cf_helpers "github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
 	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/app_helpers"
 	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
 	"github.com/cloudfoundry/cf-acceptance-tests/helpers/logs"
 	"github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
-	"github.com/onsi/gomega/gbytes"
 	"github.com/onsi/gomega/gexec"
 )
 
+// b3Trace is the part of catnip's /request that shows the zipkin headers the
+// router passed on.
+type b3Trace struct {
+	TraceId      string `json:"x_b3_traceid"`
+	SpanId       string `json:"x_b3_spanid"`
+	ParentSpanId string `json:"x_b3_parentspanid"`
+}
+
 var _ = ZipkinDescribe("Zipkin Tracing", func() {
-	var (
-		app1              string
-		helloRoutingAsset = assets.NewAssets().SpringSleuthZip
-		hostname          string
-	)
+	var appName string
+
+	// requestTrace asks catnip which zipkin headers reached it.
+	requestTrace := func(headers ...string) b3Trace {
+		var request struct {
+			Trace b3Trace `json:"trace"`
+		}
+		args := []string{}
+		for _, header := range headers {
+			args = append(args, "-H", header)
+		}
+		body := cf_helpers.CurlApp(Config, appName, "/request", args...)
+		Expect(json.Unmarshal([]byte(body), &request)).To(Succeed(), body)
+		return request.Trace
+	}
+
+	// accessLogSpan returns the span and parent span the router logged for
+	// the trace.
+	accessLogSpan := func(traceId string) (string, string) {
+		pattern := regexp.MustCompile(fmt.Sprintf(`x_b3_traceid:"%s" x_b3_spanid:"([0-9a-fA-F]*)" x_b3_parentspanid:"([0-9a-fA-F-]*)"`, traceId))
+
+		var matches []string
+		Eventually(func() []string {
+			appLogsSession := logs.Tail(Config.GetUseLogCache(), appName).Wait(Config.DefaultTimeoutDuration())
+			matches = pattern.FindStringSubmatch(string(appLogsSession.Out.Contents()))
+			return matches
+		}, Config.DefaultTimeoutDuration(), "5s").Should(HaveLen(3))
+		return matches[1], matches[2]
+	}
 
 	BeforeEach(func() {
-		app1 = random_name.CATSRandomName("APP")
-		helpers.PushApp(app1, helloRoutingAsset, Config.GetJavaBuildpackName(), Config.GetAppsDomain(), CF_JAVA_TIMEOUT, "1024M")
-
-		hostname = app1
+		appName = random_name.CATSRandomName("APP")
+		Expect(cf.Cf(
+			"push", appName,
+			"-b", Config.GetBinaryBuildpackName(),
+			"-m", DEFAULT_MEMORY_LIMIT,
+			"-p", assets.NewAssets().Catnip,
+			"-c", "./catnip",
+			"-d", Config.GetAppsDomain(),
+		).Wait(Config.CfPushTimeoutDuration())).To(gexec.Exit(0))
 	})
 
 	AfterEach(func() {
-		helpers.AppReport(app1, Config.DefaultTimeoutDuration())
-		helpers.DeleteApp(app1, Config.DefaultTimeoutDuration())
+		app_helpers.AppReport(appName, Config.DefaultTimeoutDuration())
+		Expect(cf.Cf("delete", appName, "-f", "-r").Wait(Config.DefaultTimeoutDuration())).To(gexec.Exit(0))
 	})
 
 	Context("when zipkin tracing is enabled", func() {
 		Context("when zipkin headers are not in the request", func() {
-			It("the sleuth error response has no error", func() {
-				var curlOutput string
-				// when req does not have headers
-				Eventually(func() string {
-					curlOutput = cf_helpers.CurlAppRoot(Config, hostname)
-					return curlOutput
-				}, Config.DefaultTimeoutDuration()).Should(ContainSubstring("parents:"))
-
-				appLogsSession := logs.Tail(Config.GetUseLogCache(), app1)
-
-				Eventually(appLogsSession, Config.DefaultTimeoutDuration()).Should(gexec.Exit(0))
-
-				Eventually(appLogsSession.Out).Should(gbytes.Say("x_b3_traceid"))
-				parentSpanID := getID(`x_b3_parentspanid:"([0-9a-fA-F-]*)"`, string(appLogsSession.Out.Contents()))
-
-				Expect(parentSpanID).To(Equal("-"))
-
-				By("when request has zipkin trace headers")
-
-				traceID := "fee1f7ba6aeec41c"
-
-				header1 := fmt.Sprintf(`X-B3-TraceId: %s `, traceID)
-				header2 := `X-B3-SpanId: 579b36fd31cd8714`
-				Eventually(func() string {
-					curlOutput = cf_helpers.CurlApp(Config, hostname, "/", "-H", header1, "-H", header2)
-					return curlOutput
-				}, Config.DefaultTimeoutDuration()).Should(ContainSubstring("parents:"))
-
-				appLogsSession = logs.Tail(Config.GetUseLogCache(), hostname)
-
-				Eventually(appLogsSession, Config.DefaultTimeoutDuration()).Should(gexec.Exit(0))
-
-				Expect(appLogsSession.Out).To(gbytes.Say(`x_b3_traceid:"fee1f7ba6aeec41c"`))
-
-				spanIDRegex := fmt.Sprintf("x_b3_traceid:\"%s\" x_b3_spanid:\"([0-9a-fA-F]*)\"", traceID)
-
-				appLogSpanID := getID(spanIDRegex, string(appLogsSession.Out.Contents()))
+			It("starts a trace and logs it", func() {
+				trace := requestTrace()
+				Expect(trace.TraceId).NotTo(BeEmpty())
+				Expect(trace.SpanId).NotTo(BeEmpty())
+				Expect(trace.ParentSpanId).To(BeEmpty())
+
+				spanId, parentSpanId := accessLogSpan(trace.TraceId)
+				Expect(spanId).To(Equal(trace.SpanId))
+				Expect(parentSpanId).To(Equal("-"))
+			})
+		})
 
-				Expect(curlOutput).To(ContainSubstring(traceID))
-				Expect(curlOutput).To(ContainSubstring(fmt.Sprintf("parents: [%s]", appLogSpanID)))
+		Context("when the request has zipkin trace headers", func() {
+			It("continues the trace with a span of its own", func() {
+				const (
+					traceId = "fee1f7ba6aeec41c"
+					spanId  = "579b36fd31cd8714"
+				)
+
+				trace := requestTrace("X-B3-TraceId: "+traceId, "X-B3-SpanId: "+spanId)
+				Expect(trace.TraceId).To(Equal(traceId))
+				Expect(trace.ParentSpanId).To(Equal(spanId))
+				Expect(trace.SpanId).NotTo(Or(BeEmpty(), Equal(spanId)))
+
+				routerSpanId, routerParentSpanId := accessLogSpan(traceId)
+				Expect(routerSpanId).To(Equal(trace.SpanId))
+				Expect(routerParentSpanId).To(Equal(spanId))
 			})
 		})
 	})
 })
-
-func getID(logRegex, logLines string) string {
-	matches := regexp.MustCompile(logRegex).FindStringSubmatch(logLines)
-	Expect(matches).To(HaveLen(2))
-
-	return matches[1]
-}
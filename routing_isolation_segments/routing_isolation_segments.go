@@ -2,7 +2,6 @@ package routing_isolation_segments
 
 import (
 	"fmt"
-	"io/ioutil"
 
 	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
 	. "github.com/onsi/ginkgo"
@@ -18,7 +17,7 @@ import (
 
 const (
 	SHARED_ISOLATION_SEGMENT_GUID = "933b4c58-120b-499a-b85d-4b6fc9e2903b"
-	binaryHi                      = "Hello from a binary"
+	catnipRoot                    = "Catnip?"
 )
 
 var _ = RoutingIsolationSegmentsDescribe("RoutingIsolationSegments", func() {
@@ -80,23 +79,21 @@ var _ = RoutingIsolationSegmentsDescribe("RoutingIsolationSegments", func() {
 
 				Eventually(cf.Cf(
 					"push", appName,
-					"-p", assets.NewAssets().Binary,
+					"-p", assets.NewAssets().Catnip,
 					"-m", DEFAULT_MEMORY_LIMIT,
 					"-b", "binary_buildpack",
 					"-d", appsDomain,
-					"-c", "./app"),
+					"-c", "./catnip"),
 					Config.CfPushTimeoutDuration()).Should(Exit(0))
 			})
 		})
 
 		It("is reachable from the shared router", func() {
-			resp := v3_helpers.SendRequestWithSpoofedHeader(fmt.Sprintf("%s.%s", appName, appsDomain), appsDomain)
+			host := fmt.Sprintf("%s.%s", appName, appsDomain)
+			resp := v3_helpers.SendRequestWithSpoofedHeader(host, appsDomain)
 			defer resp.Body.Close()
 
-			Expect(resp.StatusCode).To(Equal(200))
-			htmlData, err := ioutil.ReadAll(resp.Body)
-			Expect(err).NotTo(HaveOccurred())
-			Expect(string(htmlData)).To(ContainSubstring(binaryHi))
+			v3_helpers.ExpectCatnipToAnswer(resp, host)
 		})
 
 		It("is not reachable from the isolation segment router", func() {
@@ -124,23 +121,21 @@ var _ = RoutingIsolationSegmentsDescribe("RoutingIsolationSegments", func() {
 				appName = random_name.CATSRandomName("APP")
 				Eventually(cf.Cf(
 					"push", appName,
-					"-p", assets.NewAssets().Binary,
+					"-p", assets.NewAssets().Catnip,
 					"-m", DEFAULT_MEMORY_LIMIT,
 					"-b", "binary_buildpack",
 					"-d", isoSegDomain,
-					"-c", "./app"),
+					"-c", "./catnip"),
 					Config.CfPushTimeoutDuration()).Should(Exit(0))
 			})
 		})
 
 		It("the app is reachable from the isolated router", func() {
-			resp := v3_helpers.SendRequestWithSpoofedHeader(fmt.Sprintf("%s.%s", appName, isoSegDomain), isoSegDomain)
+			host := fmt.Sprintf("%s.%s", appName, isoSegDomain)
+			resp := v3_helpers.SendRequestWithSpoofedHeader(host, isoSegDomain)
 			defer resp.Body.Close()
 
-			Expect(resp.StatusCode).To(Equal(200))
-			htmlData, err := ioutil.ReadAll(resp.Body)
-			Expect(err).NotTo(HaveOccurred())
-			Expect(string(htmlData)).To(ContainSubstring(binaryHi))
+			v3_helpers.ExpectCatnipToAnswer(resp, host)
 		})
 
 		It("the app is not reachable from the shared router", func() {
@@ -21,23 +21,9 @@ import (
 	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
 	"github.com/cloudfoundry/cf-acceptance-tests/helpers/logs"
 	"github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/v3_helpers"
 )
 
-type AppsResponse struct {
-	Resources []struct {
-		Metadata struct {
-			Url string
-		}
-	}
-}
-
-type StatsResponse map[string]struct {
-	Stats struct {
-		Host string
-		Port int
-	}
-}
-
 type CatnipCurlResponse struct {
 	Stdout     string
 	Stderr     string
@@ -56,16 +42,9 @@ func pushApp(appName, buildpack string) {
 }
 
 func getAppHostIpAndPort(appName string) (string, int) {
-	var appsResponse AppsResponse
-	cfResponse := cf.Cf("curl", fmt.Sprintf("/v2/apps?q=name:%s", appName)).Wait(Config.DefaultTimeoutDuration()).Out.Contents()
-	json.Unmarshal(cfResponse, &appsResponse)
-	serverAppUrl := appsResponse.Resources[0].Metadata.Url
-
-	var statsResponse StatsResponse
-	cfResponse = cf.Cf("curl", fmt.Sprintf("%s/stats", serverAppUrl)).Wait(Config.DefaultTimeoutDuration()).Out.Contents()
-	json.Unmarshal(cfResponse, &statsResponse)
-
-	return statsResponse["0"].Stats.Host, statsResponse["0"].Stats.Port
+	appGuid := GuidForAppName(appName)
+	webProcess := v3_helpers.GetProcessByType(v3_helpers.GetProcesses(appGuid, appName), "web")
+	return v3_helpers.GetProcessInstance(webProcess.Guid, 0).HostPort()
 }
 
 func testAppConnectivity(clientAppName string, privateHost string, privatePort int) CatnipCurlResponse {
@@ -0,0 +1,126 @@
+package service_discovery
+
+import (
+	"encoding/json"
+	"fmt"
+
+	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/app_helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/workflowhelpers"
+)
+
+const (
+	tcpEchoPort = 9000
+	udpEchoPort = 9001
+)
+
+type probeResult struct {
+	Success  bool   `json:"success"`
+	Response string `json:"response"`
+	Error    string `json:"error"`
+}
+
+var _ = ServiceDiscoveryDescribe("Container-to-container traffic", func() {
+	var (
+		appNameFrontend  string
+		appNameBackend   string
+		orgName          string
+		spaceName        string
+		internalHostName string
+	)
+
+	probe := func(protocol string, port int) probeResult {
+		path := fmt.Sprintf("/probe/%s/%s.apps.internal:%d?message=%s", protocol, internalHostName, port, appNameBackend)
+
+		var result probeResult
+		Expect(json.Unmarshal([]byte(helpers.CurlApp(Config, appNameFrontend, path)), &result)).To(Succeed())
+		return result
+	}
+
+	addPolicy := func(protocol string, port int) {
+		workflowhelpers.AsUser(TestSetup.AdminUserContext(), Config.DefaultTimeoutDuration(), func() {
+			Expect(cf.Cf("target", "-o", orgName, "-s", spaceName).Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+			Expect(cf.Cf("add-network-policy", appNameFrontend,
+				"--destination-app", appNameBackend,
+				"--protocol", protocol,
+				"--port", fmt.Sprintf("%d", port),
+			).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+		})
+	}
+
+	BeforeEach(func() {
+		orgName = TestSetup.RegularUserContext().Org
+		spaceName = TestSetup.RegularUserContext().Space
+		internalHostName = random_name.CATSRandomName("HOST")
+		appNameFrontend = random_name.CATSRandomName("APP-FRONT")
+		appNameBackend = random_name.CATSRandomName("APP-BACK")
+
+		Expect(cf.Cf(
+			"push", appNameBackend,
+			"--no-start",
+			"-b", Config.GetBinaryBuildpackName(),
+			"-m", DEFAULT_MEMORY_LIMIT,
+			"-p", assets.NewAssets().Catnip,
+			"-c", "./catnip",
+			"-d", Config.GetAppsDomain(),
+		).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+		Expect(cf.Cf("set-env", appNameBackend, "CATNIP_TCP_PORTS", fmt.Sprintf("%d", tcpEchoPort)).Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+		Expect(cf.Cf("set-env", appNameBackend, "CATNIP_UDP_PORTS", fmt.Sprintf("%d", udpEchoPort)).Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+		Expect(cf.Cf("map-route", appNameBackend, "apps.internal", "--hostname", internalHostName).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+		Expect(cf.Cf("start", appNameBackend).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+
+		Expect(cf.Cf(
+			"push", appNameFrontend,
+			"-b", Config.GetBinaryBuildpackName(),
+			"-m", DEFAULT_MEMORY_LIMIT,
+			"-p", assets.NewAssets().Catnip,
+			"-c", "./catnip",
+			"-d", Config.GetAppsDomain(),
+		).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+	})
+
+	AfterEach(func() {
+		app_helpers.AppReport(appNameFrontend, Config.DefaultTimeoutDuration())
+		app_helpers.AppReport(appNameBackend, Config.DefaultTimeoutDuration())
+
+		Expect(cf.Cf("delete", appNameFrontend, "-f", "-r").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+		Expect(cf.Cf("delete", appNameBackend, "-f", "-r").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+	})
+
+	It("allows tcp traffic only once a tcp policy is added", func() {
+		Expect(probe("tcp", tcpEchoPort).Success).To(BeFalse())
+
+		addPolicy("tcp", tcpEchoPort)
+
+		Eventually(func() probeResult {
+			return probe("tcp", tcpEchoPort)
+		}, Config.DefaultTimeoutDuration()).Should(Equal(probeResult{Success: true, Response: appNameBackend}))
+
+		By("not opening other ports")
+		Expect(probe("tcp", 8080).Success).To(BeFalse())
+	})
+
+	It("allows udp traffic only once a udp policy is added", func() {
+		Expect(probe("udp", udpEchoPort).Success).To(BeFalse())
+
+		By("not letting a tcp policy through")
+		addPolicy("tcp", udpEchoPort)
+		Consistently(func() bool {
+			return probe("udp", udpEchoPort).Success
+		}, "10s", "2s").Should(BeFalse())
+
+		addPolicy("udp", udpEchoPort)
+
+		Eventually(func() probeResult {
+			return probe("udp", udpEchoPort)
+		}, Config.DefaultTimeoutDuration()).Should(Equal(probeResult{Success: true, Response: appNameBackend}))
+	})
+})
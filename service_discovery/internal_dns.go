@@ -0,0 +1,157 @@
+package service_discovery
+
+import (
+	"encoding/json"
+	"net"
+
+	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/app_helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
+)
+
+type dnsRecord struct {
+	Type    string `json:"type"`
+	TTL     uint32 `json:"ttl"`
+	Address string `json:"address"`
+}
+
+type dialResult struct {
+	Success    bool   `json:"success"`
+	RemoteAddr string `json:"remote_addr"`
+	Error      string `json:"error"`
+}
+
+var _ = ServiceDiscoveryDescribe("Internal DNS", func() {
+	var (
+		appNameFrontend  string
+		appNameBackend   string
+		internalHostName string
+	)
+
+	pushCatnip := func(appName string, instances string) {
+		Expect(cf.Cf(
+			"push", appName,
+			"-b", Config.GetBinaryBuildpackName(),
+			"-m", DEFAULT_MEMORY_LIMIT,
+			"-p", assets.NewAssets().Catnip,
+			"-c", "./catnip",
+			"-i", instances,
+			"-d", Config.GetAppsDomain(),
+		).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+	}
+
+	// curlFrontend requests path from the frontend, returning an empty body
+	// when curl fails so that callers polling it retry.
+	curlFrontend := func(path string) []byte {
+		curl := helpers.Curl(Config, helpers.AppUri(appNameFrontend, path, Config)).Wait(Config.DefaultTimeoutDuration())
+		if curl.ExitCode() != 0 {
+			return nil
+		}
+		return curl.Out.Contents()
+	}
+
+	// aRecords resolves the backend's internal route from inside the frontend.
+	// A failed lookup has no records, so polling it retries.
+	aRecords := func() []dnsRecord {
+		var answer struct {
+			Lookups []struct {
+				Rcode   string      `json:"rcode"`
+				Records []dnsRecord `json:"records"`
+				Error   string      `json:"error"`
+			} `json:"lookups"`
+		}
+		if err := json.Unmarshal(curlFrontend("/dig/"+internalHostName+".apps.internal?type=A"), &answer); err != nil || len(answer.Lookups) != 1 {
+			return nil
+		}
+		return answer.Lookups[0].Records
+	}
+
+	// dial opens a connection from the frontend to the backend's internal
+	// route. A failed request is reported as an unsuccessful dial.
+	dial := func() dialResult {
+		var result dialResult
+		if err := json.Unmarshal(curlFrontend("/dial/"+internalHostName+".apps.internal:8080"), &result); err != nil {
+			return dialResult{}
+		}
+		return result
+	}
+
+	addresses := func(records []dnsRecord) []string {
+		addresses := []string{}
+		for _, record := range records {
+			addresses = append(addresses, record.Address)
+		}
+		return addresses
+	}
+
+	BeforeEach(func() {
+		internalHostName = random_name.CATSRandomName("HOST")
+		appNameFrontend = random_name.CATSRandomName("APP-FRONT")
+		appNameBackend = random_name.CATSRandomName("APP-BACK")
+
+		pushCatnip(appNameBackend, "2")
+		Expect(cf.Cf("map-route", appNameBackend, "apps.internal", "--hostname", internalHostName).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+		pushCatnip(appNameFrontend, "1")
+
+		Expect(cf.Cf("add-network-policy", appNameFrontend, "--destination-app", appNameBackend, "--protocol", "tcp", "--port", "8080").Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+	})
+
+	AfterEach(func() {
+		app_helpers.AppReport(appNameFrontend, Config.DefaultTimeoutDuration())
+		app_helpers.AppReport(appNameBackend, Config.DefaultTimeoutDuration())
+
+		Expect(cf.Cf("delete", appNameFrontend, "-f", "-r").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+		Expect(cf.Cf("delete", appNameBackend, "-f", "-r").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+	})
+
+	It("answers with an uncached A record per instance", func() {
+		Eventually(aRecords, Config.DefaultTimeoutDuration(), "2s").Should(HaveLen(2))
+
+		records := aRecords()
+		Expect(records[0].Address).NotTo(Equal(records[1].Address))
+		for _, record := range records {
+			// Internal routes change as instances come and go, so resolvers
+			// must not cache them.
+			Expect(record.TTL).To(BeZero())
+		}
+	})
+
+	It("spreads connections across the instances", func() {
+		Eventually(aRecords, Config.DefaultTimeoutDuration(), "2s").Should(HaveLen(2))
+		expected := addresses(aRecords())
+
+		// Failed dials are retried, and each successful one adds the backend
+		// instance that answered.
+		seen := map[string]bool{}
+		Eventually(func() []string {
+			if result := dial(); result.Success {
+				if host, _, err := net.SplitHostPort(result.RemoteAddr); err == nil {
+					seen[host] = true
+				}
+			}
+
+			hosts := []string{}
+			for host := range seen {
+				hosts = append(hosts, host)
+			}
+			return hosts
+		}, Config.DefaultTimeoutDuration()).Should(ConsistOf(expected))
+	})
+
+	It("removes the records of instances that are scaled away", func() {
+		Eventually(aRecords, Config.DefaultTimeoutDuration(), "2s").Should(HaveLen(2))
+		before := addresses(aRecords())
+
+		Expect(cf.Cf("scale", appNameBackend, "-i", "1").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+
+		Eventually(aRecords, Config.DefaultTimeoutDuration(), "2s").Should(HaveLen(1))
+		Expect(before).To(ContainElement(aRecords()[0].Address))
+	})
+})
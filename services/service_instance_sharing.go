@@ -147,11 +147,15 @@ var _ = ServiceInstanceSharingDescribe("Service Instance Sharing", func() {
 				Expect(bindCmd).To(Say("OK"))
 
 				Expect(cf.Cf("restage", appName).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
-				envJSON := helpers.CurlApp(Config, appName, "/env.json")
-				var envVars map[string]string
-				json.Unmarshal([]byte(envJSON), &envVars)
-
-				Expect(envVars["VCAP_SERVICES"]).To(ContainSubstring("credentials"))
+				bindingJSON := helpers.CurlApp(Config, appName, "/vcap/services/instance/"+serviceInstanceName)
+				var binding struct {
+					InstanceName string                 `json:"instance_name"`
+					Credentials  map[string]interface{} `json:"credentials"`
+				}
+				Expect(json.Unmarshal([]byte(bindingJSON), &binding)).To(Succeed(), bindingJSON)
+
+				Expect(binding.InstanceName).To(Equal(serviceInstanceName))
+				Expect(binding.Credentials).To(HaveKeyWithValue("username", "fake-user"))
 			})
 		})
 
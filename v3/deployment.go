@@ -0,0 +1,246 @@
+package v3
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
+	. "github.com/cloudfoundry/cf-acceptance-tests/helpers/v3_helpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = DeploymentsDescribe("deployments", func() {
+	var (
+		appName             string
+		appGuid             string
+		packageGuid         string
+		spaceGuid           string
+		token               string
+		originalDropletGuid string
+		newDropletGuid      string
+	)
+
+	BeforeEach(func() {
+		appName = random_name.CATSRandomName("APP")
+		spaceName := TestSetup.RegularUserContext().Space
+		spaceGuid = GetSpaceGuidFromName(spaceName)
+		appGuid = CreateApp(appName, spaceGuid, `{"DEPLOYMENT_MARKER":"original"}`)
+		packageGuid = CreatePackage(appGuid)
+		token = GetAuthToken()
+		uploadUrl := fmt.Sprintf("%s%s/v3/packages/%s/upload", Config.Protocol(), Config.GetApiEndpoint(), packageGuid)
+		UploadPackage(uploadUrl, assets.NewAssets().CatnipZip, token)
+		WaitForPackageToBeReady(packageGuid)
+
+		buildGuid := StageBuildpackPackage(packageGuid, Config.GetBinaryBuildpackName())
+		WaitForBuildToStage(buildGuid)
+		originalDropletGuid = GetDropletFromBuild(buildGuid)
+		AssignDropletToApp(appGuid, originalDropletGuid)
+
+		webProcess := GetProcessByType(GetProcesses(appGuid, appName), "web")
+		UpdateProcessCommand(webProcess.Guid, "./catnip")
+		ScaleProcessInstances(appGuid, "web", 2)
+
+		CreateAndMapRoute(appGuid, spaceName, Config.GetAppsDomain(), appName)
+		StartApp(appGuid)
+
+		Eventually(func() string {
+			return helpers.CurlAppRoot(Config, appName)
+		}, Config.DefaultTimeoutDuration()).Should(ContainSubstring("Catnip?"))
+
+		buildGuid = StageBuildpackPackage(packageGuid, Config.GetBinaryBuildpackName())
+		WaitForBuildToStage(buildGuid)
+		newDropletGuid = GetDropletFromBuild(buildGuid)
+	})
+
+	AfterEach(func() {
+		FetchRecentLogs(appGuid, token, Config)
+		DeleteApp(appGuid)
+	})
+
+	Describe("deploying a new droplet", func() {
+		It("replaces every instance without dropping requests", func() {
+			originalInstanceGuids := waitForInstanceGuids(appName, 2)
+
+			recorder := newRolloutRecorder(appName)
+			recorder.Start()
+
+			deploymentGuid := CreateDeploymentForDroplet(appGuid, newDropletGuid)
+			Expect(GetDeployment(deploymentGuid).Droplet.Guid).To(Equal(newDropletGuid))
+			WaitForDeploymentToReachState(deploymentGuid, DEPLOYMENT_DEPLOYED)
+
+			recorder.Stop()
+
+			Expect(recorder.Failures()).To(BeEmpty())
+			Expect(GetCurrentDropletGuid(appGuid)).To(Equal(newDropletGuid))
+
+			By("overlapping old and new instances during the rollout")
+			servedGuids := recorder.InstanceGuids()
+			firstNewResponse := -1
+			lastOriginalResponse := -1
+			for i, instanceGuid := range servedGuids {
+				if originalInstanceGuids[instanceGuid] {
+					lastOriginalResponse = i
+				} else if firstNewResponse == -1 {
+					firstNewResponse = i
+				}
+			}
+			Expect(firstNewResponse).NotTo(Equal(-1), "no new instance served a request during the rollout")
+			Expect(lastOriginalResponse).To(BeNumerically(">", firstNewResponse), "original instances stopped serving before any new instance was routable")
+
+			By("serving only from new instances once deployed")
+			for instanceGuid := range waitForInstanceGuids(appName, 2) {
+				Expect(originalInstanceGuids).NotTo(HaveKey(instanceGuid))
+			}
+		})
+	})
+
+	Describe("deploying the current droplet", func() {
+		It("restarts instances with updated environment variables", func() {
+			UpdateEnvironmentVariables(appGuid, `{"DEPLOYMENT_MARKER":"updated"}`)
+
+			recorder := newRolloutRecorder(appName)
+			recorder.Start()
+
+			deploymentGuid := CreateDeployment(appGuid)
+			WaitForDeploymentToReachState(deploymentGuid, DEPLOYMENT_DEPLOYED)
+
+			recorder.Stop()
+
+			Expect(recorder.Failures()).To(BeEmpty())
+			Expect(GetCurrentDropletGuid(appGuid)).To(Equal(originalDropletGuid))
+			Eventually(func() string {
+				return helpers.CurlApp(Config, appName, "/env/DEPLOYMENT_MARKER")
+			}, Config.DefaultTimeoutDuration()).Should(Equal("updated"))
+		})
+	})
+
+	Describe("canceling a deployment", func() {
+		It("rolls back to the previous droplet", func() {
+			recorder := newRolloutRecorder(appName)
+			recorder.Start()
+
+			deploymentGuid := CreateDeploymentForDroplet(appGuid, newDropletGuid)
+			Expect(GetDeploymentState(deploymentGuid)).To(Equal(DEPLOYMENT_DEPLOYING))
+
+			CancelDeployment(deploymentGuid)
+			WaitForDeploymentToReachState(deploymentGuid, DEPLOYMENT_CANCELED)
+
+			recorder.Stop()
+
+			Expect(recorder.Failures()).To(BeEmpty())
+			Expect(GetCurrentDropletGuid(appGuid)).To(Equal(originalDropletGuid))
+			Eventually(func() string {
+				return helpers.CurlAppRoot(Config, appName)
+			}, Config.DefaultTimeoutDuration()).Should(ContainSubstring("Catnip?"))
+		})
+	})
+})
+
+// rolloutRecorder continuously requests the app's /id endpoint and records
+// which instance served each request and any request that failed.
+type rolloutRecorder struct {
+	url    string
+	client *http.Client
+
+	mutex         sync.Mutex
+	instanceGuids []string
+	failures      []string
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newRolloutRecorder(appName string) *rolloutRecorder {
+	return &rolloutRecorder{
+		url: helpers.AppUri(appName, "/id", Config),
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig:   &tls.Config{InsecureSkipVerify: Config.GetSkipSSLValidation()},
+				DisableKeepAlives: true,
+			},
+		},
+		stop: make(chan struct{}),
+	}
+}
+
+func (r *rolloutRecorder) Start() {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		for {
+			select {
+			case <-r.stop:
+				return
+			default:
+			}
+
+			r.request()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}()
+}
+
+func (r *rolloutRecorder) Stop() {
+	close(r.stop)
+	r.wg.Wait()
+}
+
+func (r *rolloutRecorder) InstanceGuids() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]string{}, r.instanceGuids...)
+}
+
+func (r *rolloutRecorder) Failures() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]string{}, r.failures...)
+}
+
+func (r *rolloutRecorder) request() {
+	resp, err := r.client.Get(r.url)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err != nil {
+		r.failures = append(r.failures, err.Error())
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		r.failures = append(r.failures, err.Error())
+		return
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		r.failures = append(r.failures, fmt.Sprintf("%d: %s", resp.StatusCode, strings.TrimSpace(string(body))))
+		return
+	}
+
+	r.instanceGuids = append(r.instanceGuids, strings.TrimSpace(string(body)))
+}
+
+func waitForInstanceGuids(appName string, instances int) map[string]bool {
+	instanceGuids := map[string]bool{}
+	Eventually(func() int {
+		instanceGuids[strings.TrimSpace(helpers.CurlApp(Config, appName, "/id"))] = true
+		return len(instanceGuids)
+	}, Config.DefaultTimeoutDuration()).Should(BeNumerically(">=", instances))
+	return instanceGuids
+}
@@ -100,3 +100,110 @@ var _ = V3Describe("Healthcheck", func() {
 		})
 	})
 })
+
+var _ = V3Describe("Healthcheck failures", func() {
+	var (
+		appName    string
+		appGuid    string
+		token      string
+		webProcess Process
+	)
+
+	BeforeEach(func() {
+		appName = random_name.CATSRandomName("APP")
+		spaceName := TestSetup.RegularUserContext().Space
+		spaceGuid := GetSpaceGuidFromName(spaceName)
+		appGuid = CreateApp(appName, spaceGuid, `{}`)
+		packageGuid := CreatePackage(appGuid)
+		token = GetAuthToken()
+		uploadUrl := fmt.Sprintf("%s%s/v3/packages/%s/upload", Config.Protocol(), Config.GetApiEndpoint(), packageGuid)
+
+		UploadPackage(uploadUrl, assets.NewAssets().CatnipZip, token)
+		WaitForPackageToBeReady(packageGuid)
+
+		buildGuid := StageBuildpackPackage(packageGuid, Config.GetBinaryBuildpackName())
+		WaitForBuildToStage(buildGuid)
+		dropletGuid := GetDropletFromBuild(buildGuid)
+		AssignDropletToApp(appGuid, dropletGuid)
+		webProcess = GetProcessByType(GetProcesses(appGuid, appName), "web")
+		UpdateProcessCommand(webProcess.Guid, "./catnip")
+		CreateAndMapRoute(appGuid, spaceName, Config.GetAppsDomain(), appName)
+
+		updateProcessPath := fmt.Sprintf("/v3/processes/%s", webProcess.Guid)
+		setHealthCheckBody := `{"health_check": {"type": "http", "data":{"endpoint":"/health", "invocation_timeout": 1}}}`
+		Expect(cf.Cf("curl", updateProcessPath, "-X", "PATCH", "-d", setHealthCheckBody).Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+
+		ScaleProcessByGuid(webProcess.Guid, ProcessScale{Instances: 2})
+		StartApp(appGuid)
+		WaitForAllProcessInstancesToBeRunning(webProcess.Guid, 2)
+	})
+
+	AfterEach(func() {
+		FetchRecentLogs(appGuid, token, Config)
+		DeleteApp(appGuid)
+	})
+
+	setInstanceHealth := func(index int, state string) {
+		helpers.CurlApp(Config, appName, "/health/admin",
+			"-X", "PUT",
+			"-H", fmt.Sprintf("X-Cf-App-Instance: %s:%d", appGuid, index),
+			"-d", state,
+		)
+	}
+
+	It("restarts only the instance whose healthcheck fails", func() {
+		Eventually(func() string {
+			return GetProcessInstanceState(webProcess.Guid, 1)
+		}, Config.DefaultTimeoutDuration()).Should(Equal("RUNNING"))
+		healthyUptime := GetProcessInstance(webProcess.Guid, 1).Uptime
+
+		setInstanceHealth(0, `{"state":"unhealthy"}`)
+
+		Eventually(func() string {
+			return GetProcessInstanceState(webProcess.Guid, 0)
+		}, Config.DefaultTimeoutDuration()).ShouldNot(Equal("RUNNING"))
+		Eventually(func() string {
+			return GetProcessInstanceState(webProcess.Guid, 0)
+		}, Config.CfPushTimeoutDuration()).Should(Equal("RUNNING"))
+
+		Expect(GetProcessInstance(webProcess.Guid, 1).Uptime).To(BeNumerically(">=", healthyUptime))
+	})
+
+	It("restarts an instance whose healthcheck exceeds the invocation timeout", func() {
+		setInstanceHealth(0, `{"state":"slow","delay_ms":5000}`)
+
+		Eventually(func() string {
+			return GetProcessInstanceState(webProcess.Guid, 0)
+		}, Config.DefaultTimeoutDuration()).ShouldNot(Equal("RUNNING"))
+		Eventually(func() string {
+			return GetProcessInstanceState(webProcess.Guid, 0)
+		}, Config.CfPushTimeoutDuration()).Should(Equal("RUNNING"))
+	})
+
+	It("restarts an instance whose healthcheck hangs", func() {
+		setInstanceHealth(0, `{"state":"hanging"}`)
+
+		Eventually(func() string {
+			return GetProcessInstanceState(webProcess.Guid, 0)
+		}, Config.DefaultTimeoutDuration()).ShouldNot(Equal("RUNNING"))
+		Eventually(func() string {
+			return GetProcessInstanceState(webProcess.Guid, 0)
+		}, Config.CfPushTimeoutDuration()).Should(Equal("RUNNING"))
+	})
+
+	// Diego fails a liveness check on its first failed invocation, so there
+	// is no run of failures short enough to ride out; a response that is slow
+	// but inside the invocation timeout is the closest the app can get.
+	It("keeps the instance running while its healthcheck is slow but inside the invocation timeout", func() {
+		instanceGuid := helpers.CurlApp(Config, appName, "/id", "-H", fmt.Sprintf("X-Cf-App-Instance: %s:0", appGuid))
+		uptime := GetProcessInstance(webProcess.Guid, 0).Uptime
+
+		setInstanceHealth(0, `{"state":"slow","delay_ms":500,"calls":3}`)
+
+		Consistently(func() string {
+			return GetProcessInstanceState(webProcess.Guid, 0)
+		}, 30*time.Second, 5*time.Second).Should(Equal("RUNNING"))
+		Expect(GetProcessInstance(webProcess.Guid, 0).Uptime).To(BeNumerically(">", uptime))
+		Expect(helpers.CurlApp(Config, appName, "/id", "-H", fmt.Sprintf("X-Cf-App-Instance: %s:0", appGuid))).To(Equal(instanceGuid))
+	})
+})
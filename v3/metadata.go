@@ -0,0 +1,293 @@
+package v3
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/workflowhelpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/app_helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
+	. "github.com/cloudfoundry/cf-acceptance-tests/helpers/v3_helpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+var _ = MetadataDescribe("metadata", func() {
+	var (
+		appName   string
+		appGuid   string
+		spaceName string
+		spaceGuid string
+	)
+
+	BeforeEach(func() {
+		appName = random_name.CATSRandomName("APP")
+		spaceName = TestSetup.RegularUserContext().Space
+		spaceGuid = GetSpaceGuidFromName(spaceName)
+
+		Expect(cf.Cf("push", appName,
+			"--no-start",
+			"-b", Config.GetBinaryBuildpackName(),
+			"-m", DEFAULT_MEMORY_LIMIT,
+			"-p", assets.NewAssets().Catnip,
+			"-c", "./catnip",
+			"-d", Config.GetAppsDomain(),
+		).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+		appGuid = GuidForAppName(appName)
+	})
+
+	AfterEach(func() {
+		app_helpers.AppReport(appName, Config.DefaultTimeoutDuration())
+		Expect(cf.Cf("delete", appName, "-f", "-r").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+	})
+
+	Describe("labels and annotations", func() {
+		itSupportsMetadata := func(resource string, guid func() string) {
+			It(fmt.Sprintf("sets, updates and removes metadata on %s", resource), func() {
+				resourceGuid := guid()
+
+				SetLabels(resource, resourceGuid, map[string]string{
+					"environment":             "production",
+					"example.com/cats-marker": "original",
+				})
+				SetAnnotations(resource, resourceGuid, map[string]string{
+					"contact": "cats@example.com",
+				})
+
+				metadata := GetMetadata(resource, resourceGuid)
+				Expect(metadata.Labels).To(HaveKeyWithValue("environment", "production"))
+				Expect(metadata.Labels).To(HaveKeyWithValue("example.com/cats-marker", "original"))
+				Expect(metadata.Annotations).To(HaveKeyWithValue("contact", "cats@example.com"))
+
+				By("patching only the keys that change")
+				SetLabels(resource, resourceGuid, map[string]string{"example.com/cats-marker": "updated"})
+				RemoveLabel(resource, resourceGuid, "environment")
+				RemoveAnnotation(resource, resourceGuid, "contact")
+
+				metadata = GetMetadata(resource, resourceGuid)
+				Expect(metadata.Labels).NotTo(HaveKey("environment"))
+				Expect(metadata.Labels).To(HaveKeyWithValue("example.com/cats-marker", "updated"))
+				Expect(metadata.Annotations).NotTo(HaveKey("contact"))
+
+				RemoveLabel(resource, resourceGuid, "example.com/cats-marker")
+			})
+		}
+
+		itSupportsMetadata(METADATA_APPS, func() string {
+			return appGuid
+		})
+
+		itSupportsMetadata(METADATA_SPACES, func() string {
+			return spaceGuid
+		})
+
+		itSupportsMetadata(METADATA_PROCESSES, func() string {
+			return GetProcessByType(GetProcesses(appGuid, appName), "web").Guid
+		})
+
+		itSupportsMetadata(METADATA_ROUTES, func() string {
+			return routing_helpers.GetRouteGuid(appName, "", Config.DefaultTimeoutDuration())
+		})
+
+		Context("on service instances", func() {
+			var serviceInstanceName string
+
+			BeforeEach(func() {
+				serviceInstanceName = random_name.CATSRandomName("SVIN")
+				Expect(cf.Cf("create-user-provided-service", serviceInstanceName, "-p", `{"username":"cats"}`).Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+			})
+
+			AfterEach(func() {
+				Expect(cf.Cf("delete-service", serviceInstanceName, "-f").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+			})
+
+			itSupportsMetadata(METADATA_SERVICE_INSTANCES, func() string {
+				session := cf.Cf("service", serviceInstanceName, "--guid").Wait(Config.DefaultTimeoutDuration())
+				Expect(session).To(Exit(0))
+				return strings.TrimSpace(string(session.Out.Contents()))
+			})
+		})
+
+		Context("on admin-owned resources", func() {
+			var (
+				orgGuid       string
+				buildpackGuid string
+			)
+
+			BeforeEach(func() {
+				orgName := TestSetup.RegularUserContext().Org
+				workflowhelpers.AsUser(TestSetup.AdminUserContext(), Config.DefaultTimeoutDuration(), func() {
+					session := cf.Cf("org", orgName, "--guid").Wait(Config.DefaultTimeoutDuration())
+					Expect(session).To(Exit(0))
+					orgGuid = strings.TrimSpace(string(session.Out.Contents()))
+
+					session = cf.Cf("curl", fmt.Sprintf("/v3/buildpacks?names=%s", Config.GetBinaryBuildpackName())).Wait(Config.DefaultTimeoutDuration())
+					Expect(session).To(Exit(0))
+					buildpackGuid = GetGuidFromResponse(session.Out.Contents())
+				})
+			})
+
+			It("sets and removes metadata on organizations and buildpacks", func() {
+				workflowhelpers.AsUser(TestSetup.AdminUserContext(), Config.DefaultTimeoutDuration(), func() {
+					for resource, guid := range map[string]string{
+						METADATA_ORGANIZATIONS: orgGuid,
+						METADATA_BUILDPACKS:    buildpackGuid,
+					} {
+						SetLabels(resource, guid, map[string]string{"example.com/cats-marker": appName})
+						SetAnnotations(resource, guid, map[string]string{"contact": "cats@example.com"})
+
+						metadata := GetMetadata(resource, guid)
+						Expect(metadata.Labels).To(HaveKeyWithValue("example.com/cats-marker", appName))
+						Expect(metadata.Annotations).To(HaveKeyWithValue("contact", "cats@example.com"))
+
+						RemoveLabel(resource, guid, "example.com/cats-marker")
+						RemoveAnnotation(resource, guid, "contact")
+
+						metadata = GetMetadata(resource, guid)
+						Expect(metadata.Labels).NotTo(HaveKey("example.com/cats-marker"))
+						Expect(metadata.Annotations).NotTo(HaveKey("contact"))
+					}
+				})
+			})
+		})
+	})
+
+	Describe("label_selector", func() {
+		var (
+			productionGuid string
+			stagingGuid    string
+			unlabeledGuid  string
+			spaceQuery     url.Values
+		)
+
+		BeforeEach(func() {
+			productionGuid = CreateApp(random_name.CATSRandomName("APP"), spaceGuid, `{}`)
+			stagingGuid = CreateApp(random_name.CATSRandomName("APP"), spaceGuid, `{}`)
+			unlabeledGuid = CreateApp(random_name.CATSRandomName("APP"), spaceGuid, `{}`)
+
+			SetLabels(METADATA_APPS, productionGuid, map[string]string{"environment": "production", "tier": "backend"})
+			SetLabels(METADATA_APPS, stagingGuid, map[string]string{"environment": "staging"})
+
+			spaceQuery = url.Values{"space_guids": []string{spaceGuid}}
+		})
+
+		AfterEach(func() {
+			DeleteApp(productionGuid)
+			DeleteApp(stagingGuid)
+			DeleteApp(unlabeledGuid)
+		})
+
+		It("filters on equality", func() {
+			Expect(GetGuidsWithLabelSelector(METADATA_APPS, "environment=production", spaceQuery)).To(ConsistOf(productionGuid))
+			Expect(GetGuidsWithLabelSelector(METADATA_APPS, "environment==staging", spaceQuery)).To(ConsistOf(stagingGuid))
+		})
+
+		It("filters on inequality", func() {
+			Expect(GetGuidsWithLabelSelector(METADATA_APPS, "environment!=production", spaceQuery)).To(ConsistOf(stagingGuid, unlabeledGuid, appGuid))
+		})
+
+		It("filters on set membership", func() {
+			Expect(GetGuidsWithLabelSelector(METADATA_APPS, "environment in (production,staging)", spaceQuery)).To(ConsistOf(productionGuid, stagingGuid))
+			Expect(GetGuidsWithLabelSelector(METADATA_APPS, "environment notin (production)", spaceQuery)).To(ConsistOf(stagingGuid, unlabeledGuid, appGuid))
+		})
+
+		It("filters on existence", func() {
+			Expect(GetGuidsWithLabelSelector(METADATA_APPS, "tier", spaceQuery)).To(ConsistOf(productionGuid))
+			Expect(GetGuidsWithLabelSelector(METADATA_APPS, "!tier", spaceQuery)).To(ConsistOf(stagingGuid, unlabeledGuid, appGuid))
+		})
+
+		It("combines requirements", func() {
+			Expect(GetGuidsWithLabelSelector(METADATA_APPS, "environment,!tier", spaceQuery)).To(ConsistOf(stagingGuid))
+		})
+	})
+
+	Describe("validation", func() {
+		It("rejects label keys with a reserved prefix", func() {
+			value := "value"
+			detail := PatchMetadataExpectingError(METADATA_APPS, appGuid, MetadataPatch{
+				Labels: map[string]*string{"cloudfoundry.org/cats": &value},
+			})
+			Expect(detail).To(ContainSubstring("label key error"))
+			Expect(detail).To(ContainSubstring("cloudfoundry.org"))
+		})
+
+		It("rejects label keys with an invalid prefix", func() {
+			value := "value"
+			detail := PatchMetadataExpectingError(METADATA_APPS, appGuid, MetadataPatch{
+				Labels: map[string]*string{"-not-a-dns-name-/cats": &value},
+			})
+			Expect(detail).To(ContainSubstring("label key error"))
+		})
+
+		It("rejects annotation keys with a prefix that is too long", func() {
+			value := "value"
+			detail := PatchMetadataExpectingError(METADATA_APPS, appGuid, MetadataPatch{
+				Annotations: map[string]*string{strings.Repeat("a", 254) + ".com/cats": &value},
+			})
+			Expect(detail).To(ContainSubstring("annotation key error"))
+		})
+	})
+
+	Describe("app lifecycle", func() {
+		BeforeEach(func() {
+			SetLabels(METADATA_APPS, appGuid, map[string]string{"example.com/cats-marker": "survives"})
+			SetAnnotations(METADATA_APPS, appGuid, map[string]string{"contact": "cats@example.com"})
+
+			Expect(cf.Cf("start", appName).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+		})
+
+		It("keeps labels and annotations across a restage", func() {
+			Expect(cf.Cf("restage", appName).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+			Eventually(func() string {
+				return helpers.CurlAppRoot(Config, appName)
+			}, Config.DefaultTimeoutDuration()).Should(ContainSubstring("Catnip?"))
+
+			metadata := GetMetadata(METADATA_APPS, appGuid)
+			Expect(metadata.Labels).To(HaveKeyWithValue("example.com/cats-marker", "survives"))
+			Expect(metadata.Annotations).To(HaveKeyWithValue("contact", "cats@example.com"))
+		})
+
+		Context("when bits are copied into the app", func() {
+			var sourceAppName string
+
+			BeforeEach(func() {
+				sourceAppName = random_name.CATSRandomName("APP")
+				Expect(cf.Cf("push", sourceAppName,
+					"--no-start",
+					"-b", Config.GetBinaryBuildpackName(),
+					"-m", DEFAULT_MEMORY_LIMIT,
+					"-p", assets.NewAssets().Catnip,
+					"-c", "./catnip",
+					"-d", Config.GetAppsDomain(),
+				).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+				SetLabels(METADATA_APPS, GuidForAppName(sourceAppName), map[string]string{"example.com/cats-marker": "source"})
+			})
+
+			AfterEach(func() {
+				Expect(cf.Cf("delete", sourceAppName, "-f", "-r").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+			})
+
+			It("keeps the destination app's labels and annotations", func() {
+				Expect(cf.Cf("copy-source", sourceAppName, appName).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+				Eventually(func() string {
+					return helpers.CurlAppRoot(Config, appName)
+				}, Config.DefaultTimeoutDuration()).Should(ContainSubstring("Catnip?"))
+
+				metadata := GetMetadata(METADATA_APPS, appGuid)
+				Expect(metadata.Labels).To(HaveKeyWithValue("example.com/cats-marker", "survives"))
+				Expect(metadata.Annotations).To(HaveKeyWithValue("contact", "cats@example.com"))
+
+				Expect(GetMetadata(METADATA_APPS, GuidForAppName(sourceAppName)).Labels).To(HaveKeyWithValue("example.com/cats-marker", "source"))
+			})
+		})
+	})
+})
@@ -1,8 +1,8 @@
 package v3
 
 import (
-	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
@@ -16,12 +16,6 @@ import (
 	. "github.com/onsi/gomega"
 )
 
-type ProcessStats struct {
-	Instance []struct {
-		State string `json:"state"`
-	} `json:"resources"`
-}
-
 var _ = V3Describe("process", func() {
 	var (
 		appName     string
@@ -77,13 +71,8 @@ var _ = V3Describe("process", func() {
 
 		Context("/v3/apps/:guid/processes/:type/instances/:index", func() {
 			It("restarts the instance", func() {
-				statsUrl := fmt.Sprintf("/v3/apps/%s/processes/web/stats", appGuid)
-
 				By("ensuring the instance is running")
-				statsBody := cf.Cf("curl", statsUrl).Wait(Config.DefaultTimeoutDuration()).Out.Contents()
-				statsJSON := ProcessStats{}
-				json.Unmarshal(statsBody, &statsJSON)
-				Expect(statsJSON.Instance[0].State).To(Equal("RUNNING"))
+				Expect(GetProcessInstanceState(webProcess.Guid, index)).To(Equal("RUNNING"))
 
 				By("terminating the instance")
 				terminateUrl := fmt.Sprintf("/v3/apps/%s/processes/%s/instances/%d", appGuid, processType, index)
@@ -92,49 +81,154 @@ var _ = V3Describe("process", func() {
 				By("ensuring the instance is no longer running")
 				// Note that this depends on a 30s run loop waking up in Diego.
 				Eventually(func() string {
-					statsBodyAfter := cf.Cf("curl", statsUrl).Wait(Config.DefaultTimeoutDuration()).Out.Contents()
-					json.Unmarshal(statsBodyAfter, &statsJSON)
-					return statsJSON.Instance[0].State
+					return GetProcessInstanceState(webProcess.Guid, index)
 				}, 45*time.Second).ShouldNot(Equal("RUNNING"))
 
 				By("ensuring the instance is running again")
 				Eventually(func() string {
-					statsBodyAfter := cf.Cf("curl", statsUrl).Wait(Config.DefaultTimeoutDuration()).Out.Contents()
-					json.Unmarshal(statsBodyAfter, &statsJSON)
-					return statsJSON.Instance[0].State
+					return GetProcessInstanceState(webProcess.Guid, index)
 				}, 45*time.Second).Should(Equal("RUNNING"))
 			})
 		})
 
 		Context("/v3/processes/:guid/instances/:index", func() {
 			It("restarts the instance", func() {
-				statsUrl := fmt.Sprintf("/v3/apps/%s/processes/web/stats", appGuid)
-
 				By("ensuring the instance is running")
-				statsBody := cf.Cf("curl", statsUrl).Wait(Config.DefaultTimeoutDuration()).Out.Contents()
-				statsJSON := ProcessStats{}
-				json.Unmarshal(statsBody, &statsJSON)
-				Expect(statsJSON.Instance[0].State).To(Equal("RUNNING"))
+				Expect(GetProcessInstanceState(webProcess.Guid, index)).To(Equal("RUNNING"))
 
 				By("terminating the instance")
-				terminateUrl := fmt.Sprintf("/v3/processes/%s/instances/%d", webProcess.Guid, index)
-				cf.Cf("curl", terminateUrl, "-X", "DELETE").Wait(Config.DefaultTimeoutDuration())
+				RestartProcessInstance(webProcess.Guid, index)
 
 				By("ensuring the instance is no longer running")
 				// Note that this depends on a 30s run loop waking up in Diego.
 				Eventually(func() string {
-					statsBodyAfter := cf.Cf("curl", statsUrl).Wait(Config.DefaultTimeoutDuration()).Out.Contents()
-					json.Unmarshal(statsBodyAfter, &statsJSON)
-					return statsJSON.Instance[0].State
+					return GetProcessInstanceState(webProcess.Guid, index)
 				}, 45*time.Second).ShouldNot(Equal("RUNNING"))
 
 				By("ensuring the instance is running again")
 				Eventually(func() string {
-					statsBodyAfter := cf.Cf("curl", statsUrl).Wait(Config.DefaultTimeoutDuration()).Out.Contents()
-					json.Unmarshal(statsBodyAfter, &statsJSON)
-					return statsJSON.Instance[0].State
+					return GetProcessInstanceState(webProcess.Guid, index)
 				}, 45*time.Second).Should(Equal("RUNNING"))
 			})
 		})
+
+		Context("with multiple instances", func() {
+			BeforeEach(func() {
+				ScaleProcessByGuid(webProcess.Guid, ProcessScale{Instances: 2})
+				WaitForAllProcessInstancesToBeRunning(webProcess.Guid, 2)
+			})
+
+			It("restarts one instance at a time", func() {
+				// Wait until an instance left alone would outlast any restarted one.
+				Eventually(func() int {
+					shortest := -1
+					for _, instance := range GetProcessStats(webProcess.Guid).Instances {
+						if shortest < 0 || instance.Uptime < shortest {
+							shortest = instance.Uptime
+						}
+					}
+					return shortest
+				}, Config.DefaultTimeoutDuration()).Should(BeNumerically(">=", 5))
+				restartedAt := time.Now()
+
+				runningCounts := pollRunningInstances(webProcess.Guid, func() {
+					RollingRestartProcess(webProcess.Guid)
+				})
+
+				Expect(runningCounts).NotTo(BeEmpty())
+				Expect(runningCounts).NotTo(ContainElement(BeNumerically("<", 1)), "no instance was running at some point during the restart")
+
+				WaitForAllProcessInstancesToBeRunning(webProcess.Guid, 2)
+				sinceRestart := int(time.Since(restartedAt).Seconds())
+				for _, instance := range GetProcessStats(webProcess.Guid).Instances {
+					Expect(instance.Uptime).To(BeNumerically("<=", sinceRestart+1), "instance %d was not restarted", instance.Index)
+				}
+				Eventually(func() string {
+					return helpers.CurlAppRoot(Config, webProcess.Name)
+				}, Config.DefaultTimeoutDuration()).Should(ContainSubstring("Hi, I'm Dora!"))
+			})
+		})
+	})
+
+	Describe("scaling a process", func() {
+		var webProcess Process
+
+		BeforeEach(func() {
+			buildGuid := StageBuildpackPackage(packageGuid, Config.GetRubyBuildpackName())
+			WaitForBuildToStage(buildGuid)
+			dropletGuid := GetDropletFromBuild(buildGuid)
+			AssignDropletToApp(appGuid, dropletGuid)
+
+			webProcess = GetProcessByType(GetProcesses(appGuid, appName), "web")
+			CreateAndMapRoute(appGuid, TestSetup.RegularUserContext().Space, Config.GetAppsDomain(), webProcess.Name)
+			StartApp(appGuid)
+			WaitForAllProcessInstancesToBeRunning(webProcess.Guid, 1)
+		})
+
+		It("updates instances, memory, disk and log rate", func() {
+			scaledProcess := ScaleProcessByGuid(webProcess.Guid, ProcessScale{
+				Instances:                    2,
+				MemoryInMb:                   300,
+				DiskInMb:                     512,
+				LogRateLimitInBytesPerSecond: 16384,
+			})
+			Expect(scaledProcess.Instances).To(Equal(2))
+			Expect(scaledProcess.MemoryInMb).To(Equal(300))
+			Expect(scaledProcess.DiskInMb).To(Equal(512))
+			Expect(scaledProcess.LogRateLimitInBytesPerSecond).To(Equal(16384))
+
+			WaitForAllProcessInstancesToBeRunning(webProcess.Guid, 2)
+		})
+
+		It("reports per-instance stats", func() {
+			Eventually(func() int {
+				return GetProcessInstance(webProcess.Guid, 0).Usage.Mem
+			}, Config.DefaultTimeoutDuration()).Should(BeNumerically(">", 0))
+
+			instance := GetProcessInstance(webProcess.Guid, 0)
+			Expect(instance.State).To(Equal("RUNNING"))
+			Expect(instance.Usage.Disk).To(BeNumerically(">", 0))
+
+			host, port := instance.HostPort()
+			Expect(host).NotTo(BeEmpty())
+			Expect(port).To(BeNumerically(">", 0))
+		})
 	})
 })
+
+// pollRunningInstances samples the process stats while action runs, returning
+// how many instances were RUNNING in each sample.
+func pollRunningInstances(processGuid string, action func()) []int {
+	var (
+		wg            sync.WaitGroup
+		runningCounts []int
+	)
+	done := make(chan struct{})
+
+	wg.Add(1)
+	go func() {
+		defer GinkgoRecover()
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(time.Second):
+			}
+
+			running := 0
+			for _, instance := range GetProcessStats(processGuid).Instances {
+				if instance.State == "RUNNING" {
+					running++
+				}
+			}
+			runningCounts = append(runningCounts, running)
+		}
+	}()
+
+	action()
+	close(done)
+	wg.Wait()
+
+	return runningCounts
+}
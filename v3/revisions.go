@@ -0,0 +1,114 @@
+package v3
+
+import (
+	"fmt"
+
+	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
+	. "github.com/cloudfoundry/cf-acceptance-tests/helpers/v3_helpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = DeploymentsDescribe("revisions", func() {
+	var (
+		appName             string
+		appGuid             string
+		token               string
+		webProcessGuid      string
+		originalDropletGuid string
+		newDropletGuid      string
+		originalRevision    Revision
+		newRevision         Revision
+	)
+
+	BeforeEach(func() {
+		appName = random_name.CATSRandomName("APP")
+		spaceName := TestSetup.RegularUserContext().Space
+		spaceGuid := GetSpaceGuidFromName(spaceName)
+		appGuid = CreateApp(appName, spaceGuid, `{"REVISION_MARKER":"original"}`)
+		EnableRevisions(appGuid)
+
+		packageGuid := CreatePackage(appGuid)
+		token = GetAuthToken()
+		uploadUrl := fmt.Sprintf("%s%s/v3/packages/%s/upload", Config.Protocol(), Config.GetApiEndpoint(), packageGuid)
+		UploadPackage(uploadUrl, assets.NewAssets().CatnipZip, token)
+		WaitForPackageToBeReady(packageGuid)
+
+		buildGuid := StageBuildpackPackage(packageGuid, Config.GetBinaryBuildpackName())
+		WaitForBuildToStage(buildGuid)
+		originalDropletGuid = GetDropletFromBuild(buildGuid)
+		AssignDropletToApp(appGuid, originalDropletGuid)
+
+		webProcessGuid = GetProcessByType(GetProcesses(appGuid, appName), "web").Guid
+		UpdateProcessCommand(webProcessGuid, "COMMAND_MARKER=original ./catnip")
+
+		CreateAndMapRoute(appGuid, spaceName, Config.GetAppsDomain(), appName)
+		StartApp(appGuid)
+
+		Eventually(func() string {
+			return helpers.CurlApp(Config, appName, "/env/COMMAND_MARKER")
+		}, Config.DefaultTimeoutDuration()).Should(Equal("original"))
+		originalRevision = GetNewestRevision(appGuid)
+
+		By("pushing a new droplet with changed environment variables and start command")
+		buildGuid = StageBuildpackPackage(packageGuid, Config.GetBinaryBuildpackName())
+		WaitForBuildToStage(buildGuid)
+		newDropletGuid = GetDropletFromBuild(buildGuid)
+
+		UpdateEnvironmentVariables(appGuid, `{"REVISION_MARKER":"updated"}`)
+		UpdateProcessCommand(webProcessGuid, "COMMAND_MARKER=updated ./catnip")
+
+		deploymentGuid := CreateDeploymentForDroplet(appGuid, newDropletGuid)
+		WaitForDeploymentToReachState(deploymentGuid, DEPLOYMENT_DEPLOYED)
+
+		Eventually(func() string {
+			return helpers.CurlApp(Config, appName, "/env/COMMAND_MARKER")
+		}, Config.DefaultTimeoutDuration()).Should(Equal("updated"))
+		newRevision = GetNewestRevision(appGuid)
+	})
+
+	AfterEach(func() {
+		FetchRecentLogs(appGuid, token, Config)
+		DeleteApp(appGuid)
+	})
+
+	It("captures the droplet, environment and command of each push", func() {
+		Expect(newRevision.Version).To(BeNumerically(">", originalRevision.Version))
+
+		Expect(GetRevisionDropletGuid(originalRevision.Guid)).To(Equal(originalDropletGuid))
+		Expect(GetRevisionEnvironmentVariables(originalRevision.Guid)).To(HaveKeyWithValue("REVISION_MARKER", "original"))
+		Expect(GetRevisionCommand(originalRevision.Guid, "web")).To(Equal("COMMAND_MARKER=original ./catnip"))
+
+		Expect(GetRevisionDropletGuid(newRevision.Guid)).To(Equal(newDropletGuid))
+		Expect(GetRevisionEnvironmentVariables(newRevision.Guid)).To(HaveKeyWithValue("REVISION_MARKER", "updated"))
+		Expect(GetRevisionCommand(newRevision.Guid, "web")).To(Equal("COMMAND_MARKER=updated ./catnip"))
+	})
+
+	Describe("rolling back to an older revision", func() {
+		It("runs the droplet, environment and command of that revision", func() {
+			deploymentGuid := RollbackToRevision(appGuid, originalRevision.Guid)
+			Expect(GetDeployment(deploymentGuid).Revision.Guid).NotTo(BeEmpty())
+
+			Expect(GetCurrentDropletGuid(appGuid)).To(Equal(originalDropletGuid))
+			Expect(GetProcessByGuid(webProcessGuid).Command).To(Equal("COMMAND_MARKER=original ./catnip"))
+
+			Eventually(func() string {
+				return helpers.CurlApp(Config, appName, "/env/REVISION_MARKER")
+			}, Config.DefaultTimeoutDuration()).Should(Equal("original"))
+			Eventually(func() string {
+				return helpers.CurlApp(Config, appName, "/env/COMMAND_MARKER")
+			}, Config.DefaultTimeoutDuration()).Should(Equal("original"))
+
+			By("recording the rollback as a new revision")
+			rollbackRevision := GetNewestRevision(appGuid)
+			Expect(rollbackRevision.Version).To(BeNumerically(">", newRevision.Version))
+			Expect(rollbackRevision.Droplet.Guid).To(Equal(originalDropletGuid))
+			Expect(rollbackRevision.Description).To(ContainSubstring(fmt.Sprintf("Rolled back to revision %d", originalRevision.Version)))
+		})
+	})
+})
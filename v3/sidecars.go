@@ -0,0 +1,154 @@
+package v3
+
+import (
+	"fmt"
+
+	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/skip_messages"
+	. "github.com/cloudfoundry/cf-acceptance-tests/helpers/v3_helpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+var _ = V3Describe("sidecars", func() {
+	var (
+		appName      string
+		appGuid      string
+		spaceName    string
+		token        string
+		sidecarRoute string
+		webProcess   Process
+	)
+
+	BeforeEach(func() {
+		appName = random_name.CATSRandomName("APP")
+		spaceName = TestSetup.RegularUserContext().Space
+		spaceGuid := GetSpaceGuidFromName(spaceName)
+		appGuid = CreateApp(appName, spaceGuid, `{"SIDECAR_SHARED":"shared-by-app-and-sidecar"}`)
+		packageGuid := CreatePackage(appGuid)
+		token = GetAuthToken()
+		uploadUrl := fmt.Sprintf("%s%s/v3/packages/%s/upload", Config.Protocol(), Config.GetApiEndpoint(), packageGuid)
+		UploadPackage(uploadUrl, assets.NewAssets().CatnipZip, token)
+		WaitForPackageToBeReady(packageGuid)
+
+		buildGuid := StageBuildpackPackage(packageGuid, Config.GetBinaryBuildpackName())
+		WaitForBuildToStage(buildGuid)
+		dropletGuid := GetDropletFromBuild(buildGuid)
+		AssignDropletToApp(appGuid, dropletGuid)
+
+		webProcess = GetProcessByType(GetProcesses(appGuid, appName), "web")
+		UpdateProcessCommand(webProcess.Guid, "./catnip")
+
+		CreateAndMapRoute(appGuid, spaceName, Config.GetAppsDomain(), appName)
+	})
+
+	AfterEach(func() {
+		FetchRecentLogs(appGuid, token, Config)
+		DeleteApp(appGuid)
+	})
+
+	Context("when a sidecar listens on a second port", func() {
+		BeforeEach(func() {
+			CreateSidecar(appGuid, "catnip-sidecar", "PORT=8081 ./catnip", "web")
+
+			routing_helpers.UpdatePorts(appName, []uint16{8080, 8081}, Config.DefaultTimeoutDuration())
+			sidecarRoute = fmt.Sprintf("%s-sidecar", appName)
+			routing_helpers.CreateRoute(sidecarRoute, "", spaceName, Config.GetAppsDomain(), Config.DefaultTimeoutDuration())
+			routing_helpers.CreateRouteMapping(appName, sidecarRoute, 0, 8081, Config.DefaultTimeoutDuration())
+
+			StartApp(appGuid)
+
+			Eventually(func() string {
+				return helpers.CurlAppRoot(Config, appName)
+			}, Config.DefaultTimeoutDuration()).Should(ContainSubstring("Catnip?"))
+			Eventually(func() string {
+				return helpers.CurlAppRoot(Config, sidecarRoute)
+			}, Config.DefaultTimeoutDuration()).Should(ContainSubstring("Catnip?"))
+		})
+
+		AfterEach(func() {
+			routing_helpers.DeleteRoute(sidecarRoute, "", Config.GetAppsDomain(), Config.DefaultTimeoutDuration())
+		})
+
+		It("lists the sidecar on the app and its process", func() {
+			sidecar := GetSidecarByName(GetSidecars(appGuid), "catnip-sidecar")
+			Expect(sidecar.Command).To(Equal("PORT=8081 ./catnip"))
+			Expect(sidecar.ProcessTypes).To(ConsistOf("web"))
+
+			processSidecar := GetSidecarByName(GetSidecarsForProcess(webProcess.Guid), "catnip-sidecar")
+			Expect(processSidecar.Guid).To(Equal(sidecar.Guid))
+		})
+
+		It("runs the sidecar in the same container as the process", func() {
+			Expect(helpers.CurlApp(Config, sidecarRoute, "/id")).To(Equal(helpers.CurlApp(Config, appName, "/id")))
+			Expect(helpers.CurlApp(Config, sidecarRoute, "/myip")).To(Equal(helpers.CurlApp(Config, appName, "/myip")))
+
+			Expect(helpers.CurlApp(Config, appName, "/env/SIDECAR_SHARED")).To(Equal("shared-by-app-and-sidecar"))
+			Expect(helpers.CurlApp(Config, sidecarRoute, "/env/SIDECAR_SHARED")).To(Equal("shared-by-app-and-sidecar"))
+
+			Expect(helpers.CurlApp(Config, appName, "/env/PORT")).To(Equal("8080"))
+			Expect(helpers.CurlApp(Config, sidecarRoute, "/env/PORT")).To(Equal("8081"))
+		})
+
+		It("crashes the instance when the sidecar crashes", func() {
+			instanceGuid := helpers.CurlApp(Config, appName, "/id")
+
+			helpers.CurlApp(Config, sidecarRoute, "/sigterm/KILL")
+
+			Eventually(func() string {
+				return string(cf.Cf("events", appName).Wait(Config.DefaultTimeoutDuration()).Out.Contents())
+			}, Config.DefaultTimeoutDuration()).Should(MatchRegexp("[eE]xited"))
+
+			Eventually(func() string {
+				return helpers.CurlApp(Config, appName, "/id")
+			}, Config.DefaultTimeoutDuration()).ShouldNot(Equal(instanceGuid))
+		})
+	})
+
+	Context("when a sidecar is removed", func() {
+		BeforeEach(func() {
+			if !Config.GetIncludeSsh() {
+				Skip(skip_messages.SkipSSHMessage)
+			}
+		})
+
+		// runningCommands lists the command lines of every process in the
+		// app's container.
+		runningCommands := func() string {
+			session := cf.Cf("ssh", appName, "-c", "ps -eo args").Wait(Config.DefaultTimeoutDuration())
+			Expect(session).To(Exit(0))
+			return string(session.Out.Contents())
+		}
+
+		It("no longer runs the sidecar", func() {
+			sidecarGuid := CreateSidecar(appGuid, "doomed-sidecar", "sleep 3600", "web")
+			Expect(GetSidecarByName(GetSidecars(appGuid), "doomed-sidecar").Guid).To(Equal(sidecarGuid))
+
+			StartApp(appGuid)
+			Eventually(func() string {
+				return helpers.CurlAppRoot(Config, appName)
+			}, Config.DefaultTimeoutDuration()).Should(ContainSubstring("Catnip?"))
+			Expect(runningCommands()).To(ContainSubstring("sleep 3600"))
+
+			DeleteSidecar(sidecarGuid)
+
+			Expect(GetSidecars(appGuid)).To(BeEmpty())
+			Expect(GetSidecarsForProcess(webProcess.Guid)).To(BeEmpty())
+
+			StopApp(appGuid)
+			StartApp(appGuid)
+			Eventually(func() string {
+				return helpers.CurlAppRoot(Config, appName)
+			}, Config.DefaultTimeoutDuration()).Should(ContainSubstring("Catnip?"))
+			Expect(runningCommands()).To(And(ContainSubstring("catnip"), Not(ContainSubstring("sleep 3600"))))
+		})
+	})
+})
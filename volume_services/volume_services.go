@@ -0,0 +1,119 @@
+package volume_services
+
+import (
+	"encoding/json"
+	"fmt"
+
+	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/app_helpers"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/v3_helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
+)
+
+// catnipFile mirrors what catnip's /files endpoints report.
+type catnipFile struct {
+	Path   string `json:"path"`
+	Bytes  int64  `json:"bytes"`
+	SHA256 string `json:"sha256"`
+}
+
+var _ = VolumeServicesDescribe("Volume services", func() {
+	var (
+		appName             string
+		appGuid             string
+		serviceInstanceName string
+	)
+
+	filePath := func(name string) string {
+		return fmt.Sprintf("/files/volume/%s/%s", serviceInstanceName, name)
+	}
+
+	curlFile := func(instance int, path string, args ...string) catnipFile {
+		args = append([]string{"-H", fmt.Sprintf("X-Cf-App-Instance: %s:%d", appGuid, instance)}, args...)
+		response := helpers.CurlApp(Config, appName, path, args...)
+
+		var file catnipFile
+		Expect(json.Unmarshal([]byte(response), &file)).To(Succeed(), response)
+		return file
+	}
+
+	// readHash polls without failing so that it can be used while instances
+	// are still starting.
+	readHash := func(instance int, path string) string {
+		curl := helpers.Curl(Config, "-f", "-H", fmt.Sprintf("X-Cf-App-Instance: %s:%d", appGuid, instance), helpers.AppUri(appName, path, Config)).Wait(Config.DefaultTimeoutDuration())
+
+		var file catnipFile
+		json.Unmarshal(curl.Out.Contents(), &file)
+		return file.SHA256
+	}
+
+	BeforeEach(func() {
+		appName = random_name.CATSRandomName("APP")
+		serviceInstanceName = random_name.CATSRandomName("SVIN")
+
+		createArgs := []string{"create-service", Config.GetVolumeServiceName(), Config.GetVolumeServicePlanName(), serviceInstanceName}
+		if Config.GetVolumeServiceCreateConfig() != "" {
+			createArgs = append(createArgs, "-c", Config.GetVolumeServiceCreateConfig())
+		}
+		Expect(cf.Cf(createArgs...).Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+
+		Expect(cf.Cf("push",
+			appName,
+			"--no-start",
+			"-b", Config.GetBinaryBuildpackName(),
+			"-p", assets.NewAssets().Catnip,
+			"-c", "./catnip",
+			"-i", "2",
+			"-m", DEFAULT_MEMORY_LIMIT,
+			"-d", Config.GetAppsDomain(),
+		).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+		Expect(cf.Cf("bind-service", appName, serviceInstanceName).Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+		Expect(cf.Cf("start", appName).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+
+		appGuid = GuidForAppName(appName)
+		processGuid := v3_helpers.GetProcessByType(v3_helpers.GetProcesses(appGuid, appName), "web").Guid
+		v3_helpers.WaitForAllProcessInstancesToBeRunning(processGuid, 2)
+	})
+
+	AfterEach(func() {
+		app_helpers.AppReport(appName, Config.DefaultTimeoutDuration())
+
+		Expect(cf.Cf("delete", appName, "-f", "-r").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+		Expect(cf.Cf("delete-service", serviceInstanceName, "-f").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+	})
+
+	It("shares written data between instances", func() {
+		written := curlFile(0, filePath("shared")+"?bytes=10485760", "-X", "PUT")
+		Expect(written.Bytes).To(Equal(int64(10485760)))
+
+		read := curlFile(1, filePath("shared"))
+		Expect(read.Bytes).To(Equal(written.Bytes))
+		Expect(read.SHA256).To(Equal(written.SHA256))
+	})
+
+	It("keeps data across restages and instance restarts", func() {
+		written := curlFile(0, filePath("persistent")+"?bytes=1048576", "-X", "PUT")
+
+		Expect(cf.Cf("restage", appName).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+		Eventually(func() string {
+			return readHash(0, filePath("persistent"))
+		}, Config.DefaultTimeoutDuration(), "2s").Should(Equal(written.SHA256))
+
+		instanceHeader := fmt.Sprintf("X-Cf-App-Instance: %s:%d", appGuid, 1)
+		oldInstanceGuid := helpers.CurlApp(Config, appName, "/id", "-H", instanceHeader)
+		Expect(cf.Cf("restart-app-instance", appName, "1").Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
+		Eventually(func() string {
+			return string(helpers.Curl(Config, "-f", "-H", instanceHeader, helpers.AppUri(appName, "/id", Config)).Wait(Config.DefaultTimeoutDuration()).Out.Contents())
+		}, Config.DefaultTimeoutDuration(), "2s").ShouldNot(Or(Equal(oldInstanceGuid), BeEmpty()))
+		Eventually(func() string {
+			return readHash(1, filePath("persistent"))
+		}, Config.DefaultTimeoutDuration(), "2s").Should(Equal(written.SHA256))
+	})
+})
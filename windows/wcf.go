@@ -11,11 +11,11 @@ import (
 
 	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
 	"github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
-	. "github.com/cloudfoundry-incubator/cf-test-helpers/workflowhelpers"
 	. "github.com/cloudfoundry/cf-acceptance-tests/cats_suite_helpers"
 	"github.com/cloudfoundry/cf-acceptance-tests/helpers/app_helpers"
 	"github.com/cloudfoundry/cf-acceptance-tests/helpers/assets"
 	"github.com/cloudfoundry/cf-acceptance-tests/helpers/random_name"
+	"github.com/cloudfoundry/cf-acceptance-tests/helpers/v3_helpers"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gexec"
@@ -54,24 +54,13 @@ var _ = WindowsDescribe("WCF", func() {
 
 func allInstancesRunning(appName string, instances int) func() error {
 	return func() error {
-		type StatsResponse map[string]struct {
-			State string `json:"state"`
-		}
-
-		session := cf.Cf("app", appName, "--guid")
-		Expect(session.Wait(Config.DefaultTimeoutDuration())).To(Exit(0))
-
-		appGuid := strings.Replace(string(session.Out.Contents()), "\n", "", -1)
-
-		endpoint := fmt.Sprintf("/v2/apps/%s/stats", appGuid)
-
-		var response StatsResponse
-		ApiRequest("GET", endpoint, &response, Config.DefaultTimeoutDuration())
+		appGuid := GuidForAppName(appName)
+		webProcess := v3_helpers.GetProcessByType(v3_helpers.GetProcesses(appGuid, appName), "web")
 
 		var err error
-		for k, v := range response {
-			if v.State != "RUNNING" {
-				err = errors.New(fmt.Sprintf("App %s instance %s is not running: State = %s", appName, k, v.State))
+		for _, instance := range v3_helpers.GetProcessStats(webProcess.Guid).Instances {
+			if instance.State != "RUNNING" {
+				err = errors.New(fmt.Sprintf("App %s instance %d is not running: State = %s", appName, instance.Index, instance.State))
 			}
 		}
 		return err